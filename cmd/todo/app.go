@@ -0,0 +1,16 @@
+package main
+
+import (
+	"os"
+
+	"github.com/alexsniffin/go-api-starter/internal/todo/cli"
+)
+
+// Entry point to the todo CLI, a client of the Todo API.
+//
+// Exit status codes:
+//    * 0 - success
+//    * 1 - a failed command
+func main() {
+	os.Exit(cli.Execute())
+}
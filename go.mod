@@ -3,24 +3,44 @@ module github.com/alexsniffin/go-api-starter
 go 1.15
 
 require (
+	github.com/andybalholm/brotli v1.0.6
 	github.com/docker/go-connections v0.4.0
+	github.com/fsnotify/fsnotify v1.4.7
+	github.com/fxamacker/cbor/v2 v2.4.0
 	github.com/go-chi/chi v4.0.2+incompatible
 	github.com/go-chi/cors v1.1.1
 	github.com/go-ozzo/ozzo-validation/v4 v4.2.2
 	github.com/go-pg/pg v8.0.6+incompatible
+	github.com/golang/protobuf v1.5.2
+	github.com/gorilla/websocket v1.4.2
+	github.com/graphql-go/graphql v0.8.1
 	github.com/jinzhu/inflection v1.0.0 // indirect
 	github.com/justinas/alice v1.2.0
+	github.com/klauspost/compress v1.13.6
 	github.com/onsi/ginkgo v1.12.0 // indirect
 	github.com/onsi/gomega v1.9.0 // indirect
 	github.com/pkg/errors v0.9.1
 	github.com/prometheus/client_golang v1.6.0
+	github.com/rs/xid v1.2.1
 	github.com/rs/zerolog v1.19.0
 	github.com/slok/go-http-metrics v0.8.0
+	github.com/spf13/cobra v1.8.1
+	github.com/spf13/pflag v1.0.5
 	github.com/spf13/viper v1.4.0
-	github.com/stretchr/testify v1.6.1
+	github.com/stretchr/testify v1.7.0
 	github.com/testcontainers/testcontainers-go v0.7.0
 	github.com/unrolled/render v1.0.1
 	github.com/urfave/negroni v1.0.0
-	golang.org/x/net v0.0.0-20200226121028-0de0cce0169b
+	github.com/vmihailenco/msgpack/v5 v5.3.5
+	go.opentelemetry.io/otel v1.0.1
+	go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracegrpc v1.0.1
+	go.opentelemetry.io/otel/exporters/stdout/stdouttrace v1.0.1
+	go.opentelemetry.io/otel/sdk v1.0.1
+	go.opentelemetry.io/otel/trace v1.0.1
+	golang.org/x/net v0.0.0-20200822124328-c89045814202
+	google.golang.org/grpc v1.41.0
+	google.golang.org/protobuf v1.27.1
+	gopkg.in/natefinch/lumberjack.v2 v2.2.1
+	gopkg.in/yaml.v2 v2.3.0
 	mellium.im/sasl v0.2.1 // indirect
 )
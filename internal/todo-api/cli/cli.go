@@ -0,0 +1,145 @@
+package cli
+
+import (
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/rs/zerolog"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+
+	"github.com/alexsniffin/go-api-starter/internal/todo-api/models"
+	"github.com/alexsniffin/go-api-starter/internal/todo-api/store/todo"
+	"github.com/alexsniffin/go-api-starter/pkg/config"
+	"github.com/alexsniffin/go-api-starter/pkg/logger"
+)
+
+const (
+	configName = "todo-api"
+	prefix     = "TODO"
+)
+
+// exitError sets the exit status of a failed command, the error is printed unless it's nil because it was logged
+type exitError struct {
+	code int
+	err  error
+}
+
+func (e *exitError) Error() string {
+	if e.err == nil {
+		return fmt.Sprintf("exit status %d", e.code)
+	}
+	return e.err.Error()
+}
+
+// options shared by the commands to load the config and set up the logger
+type options struct {
+	configFile   string
+	strictConfig bool
+	// bindings of the flags of every command to the config keys they override
+	bindings []binding
+	// newStore connects the store of the commands using the database, the close func has to be called by the caller
+	newStore func(cfg models.DatabaseConfig, logger zerolog.Logger) (todo.TodoStore, func() error, error)
+}
+
+// binding of a flag to the config key it overrides
+type binding struct {
+	key  string
+	flag *pflag.Flag
+}
+
+// NewCommand creates the root command of the todo-api binary with its subcommands
+func NewCommand() *cobra.Command {
+	return newCommand(&options{newStore: newStore})
+}
+
+func newCommand(opts *options) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:           "todo-api",
+		Short:         "Todo API service",
+		SilenceUsage:  true,
+		SilenceErrors: true,
+	}
+	cmd.PersistentFlags().StringVar(&opts.configFile, "config", "",
+		"path of the config file, searched for in ./configs and . by default")
+	cmd.PersistentFlags().BoolVar(&opts.strictConfig, "strict-config", false, "reject unknown keys in the config file")
+	cmd.PersistentFlags().String("log-level", "", "log level, overrides Logger.Level")
+	opts.bindFlag(cmd.PersistentFlags(), "log-level", "Logger.Level")
+
+	cmd.AddCommand(
+		newServeCommand(opts),
+		newMigrateCommand(opts),
+		newSeedCommand(opts),
+		newExportCommand(opts),
+		newImportCommand(opts),
+		newHealthcheckCommand(opts),
+		newConfigCommand(opts),
+		newVersionCommand(),
+	)
+	return cmd
+}
+
+// Execute runs the command of the arguments and returns the exit status
+func Execute() int {
+	err := NewCommand().Execute()
+	if err == nil {
+		return 0
+	}
+
+	code := 1
+	if exitErr, ok := err.(*exitError); ok {
+		code = exitErr.code
+		err = exitErr.err
+	}
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+	}
+	return code
+}
+
+// bindFlag maps the flag of the set to the config key, the flag overrides the key if it's set
+func (o *options) bindFlag(flags *pflag.FlagSet, name, key string) {
+	o.bindings = append(o.bindings, binding{key: key, flag: flags.Lookup(name)})
+}
+
+// newLoader creates the loader of the config with the flags set for the command, commands share config keys so only
+// the flags which are set are bound
+func (o *options) newLoader() *config.Loader {
+	flags := map[string]*pflag.Flag{}
+	for _, b := range o.bindings {
+		if b.flag.Changed {
+			flags[b.key] = b.flag
+		}
+	}
+
+	return config.NewLoader(configName, prefix, config.Options{
+		Strict: o.strictConfig,
+		File:   o.configFile,
+		Flags:  flags,
+	})
+}
+
+// loadConfig loads the config, an invalid config exits with status 2
+func (o *options) loadConfig() (models.Config, *config.Loader, error) {
+	cfg := models.Config{}
+	loader := o.newLoader()
+	if err := loader.Load(&cfg); err != nil {
+		return models.Config{}, nil, &exitError{code: 2, err: err}
+	}
+	return cfg, loader, nil
+}
+
+// setUp loads the config and creates the logger of the commands writing to logOut
+func (o *options) setUp(logOut io.Writer) (models.Config, *config.Loader, zerolog.Logger, error) {
+	cfg, loader, err := o.loadConfig()
+	if err != nil {
+		return models.Config{}, nil, zerolog.Logger{}, err
+	}
+
+	newLogger, err := logger.NewLogger(cfg, logOut)
+	if err != nil {
+		return models.Config{}, nil, zerolog.Logger{}, &exitError{code: 2, err: err}
+	}
+	return cfg, loader, newLogger, nil
+}
@@ -0,0 +1,154 @@
+package cli
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/rs/zerolog"
+	"github.com/rs/zerolog/log"
+	"github.com/stretchr/testify/mock"
+
+	"github.com/alexsniffin/go-api-starter/internal/todo-api/models"
+	"github.com/alexsniffin/go-api-starter/internal/todo-api/store/todo"
+	"github.com/alexsniffin/go-api-starter/mocks"
+)
+
+const configFile = "../../../configs/todo-api.yaml"
+
+// run executes the command of the arguments, returning its output
+func run(args ...string) (string, error) {
+	var out bytes.Buffer
+	cmd := NewCommand()
+	cmd.SetArgs(args)
+	cmd.SetOut(&out)
+	err := cmd.Execute()
+	return out.String(), err
+}
+
+func TestNewCommand(t *testing.T) {
+	t.Run("validConfig", func(t *testing.T) {
+		out, err := run("config", "validate", "--config", configFile)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if out != "config is valid\n" {
+			t.Errorf("wrong output: got %v want %v", out, "config is valid\n")
+		}
+	})
+
+	t.Run("invalidFlagValue", func(t *testing.T) {
+		_, err := run("config", "validate", "--config", configFile, "--log-level", "nope")
+		exitErr, ok := err.(*exitError)
+		if !ok {
+			t.Fatalf("wrong error: got %v want exit error", err)
+		}
+		if exitErr.code != 2 {
+			t.Errorf("wrong exit status: got %v want %v", exitErr.code, 2)
+		}
+	})
+
+	t.Run("flagOverride", func(t *testing.T) {
+		out, err := run("config", "print", "--config", configFile, "--log-level", "warn")
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if !strings.Contains(out, "Level: warn\n") {
+			t.Errorf("level not overridden: got %v", out)
+		}
+	})
+
+	t.Run("invalidSeedCount", func(t *testing.T) {
+		if _, err := run("seed", "0", "--config", configFile); err == nil {
+			t.Errorf("expected error of the count")
+		}
+	})
+
+	t.Run("version", func(t *testing.T) {
+		out, err := run("version")
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if !strings.HasPrefix(out, "todo-api dev") {
+			t.Errorf("wrong output: got %v want prefix %v", out, "todo-api dev")
+		}
+	})
+}
+
+func TestExportCommand(t *testing.T) {
+	t.Run("importStdout", func(t *testing.T) {
+		// the logs are JSON outside of localhost, they mustn't end up in the export
+		os.Setenv("TODO_ENVIRONMENT", "production")
+		defer os.Unsetenv("TODO_ENVIRONMENT")
+
+		todos := []models.TodoItem{{ID: 1, Todo: "buy milk", UserID: "alice"}, {ID: 2, Todo: "call mom", UserID: "bob"}}
+		store := &mocks.TodoStore{}
+		store.On("ListTodos", mock.Anything, mock.Anything).Run(func(args mock.Arguments) {
+			log.Ctx(args.Get(0).(context.Context)).Info().Msg("todos listed")
+		}).Return(todos, nil)
+		newStore := func(models.DatabaseConfig, zerolog.Logger) (todo.TodoStore, func() error, error) {
+			return store, func() error { return nil }, nil
+		}
+		cmd := newCommand(&options{newStore: newStore})
+
+		var out, errOut bytes.Buffer
+		cmd.SetArgs([]string{"export", "--config", configFile})
+		cmd.SetOut(&out)
+		cmd.SetErr(&errOut)
+		if err := cmd.Execute(); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		count, err := importTodos(&out, func(models.TodoItem) error { return nil })
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if count != len(todos) {
+			t.Errorf("wrong count: got %v want %v", count, len(todos))
+		}
+		if !strings.Contains(errOut.String(), "todos exported") {
+			t.Errorf("missing logs: got %v", errOut.String())
+		}
+	})
+}
+
+func TestImportTodos(t *testing.T) {
+	t.Run("postNewIDs", func(t *testing.T) {
+		in := `{"id":7,"todo":"buy milk","done":true,"created_on":"2020-01-02T03:04:05Z"}
+{"id":8,"todo":"call mom","list":"home"}
+`
+		var posted []models.TodoItem
+		count, err := importTodos(strings.NewReader(in), func(todo models.TodoItem) error {
+			posted = append(posted, todo)
+			return nil
+		})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if count != 2 {
+			t.Errorf("wrong count: got %v want %v", count, 2)
+		}
+		if posted[0].ID != 0 || !posted[0].Done || !posted[0].UpdatedOn.Equal(posted[0].CreatedOn) {
+			t.Errorf("wrong todo: got %+v", posted[0])
+		}
+		if posted[1].List != "home" || posted[1].CreatedOn.IsZero() {
+			t.Errorf("wrong todo: got %+v", posted[1])
+		}
+	})
+
+	t.Run("invalidTodo", func(t *testing.T) {
+		in := `{"todo":"buy milk"}
+{"todo":""}
+{"todo":"call mom"}
+`
+		count, err := importTodos(strings.NewReader(in), func(models.TodoItem) error { return nil })
+		if err == nil || !strings.Contains(err.Error(), "invalid todo 2") {
+			t.Errorf("wrong error: got %v want %v", err, "invalid todo 2")
+		}
+		if count != 1 {
+			t.Errorf("wrong count: got %v want %v", count, 1)
+		}
+	})
+}
@@ -0,0 +1,36 @@
+package cli
+
+import (
+	"github.com/pkg/errors"
+	"github.com/rs/zerolog"
+	"github.com/spf13/cobra"
+
+	"github.com/alexsniffin/go-api-starter/internal/todo-api/clients/postgres"
+	"github.com/alexsniffin/go-api-starter/internal/todo-api/models"
+	"github.com/alexsniffin/go-api-starter/internal/todo-api/store/todo"
+)
+
+// bindDatabaseFlags adds the flags overriding the connection to the database to the command
+func bindDatabaseFlags(cmd *cobra.Command, opts *options) {
+	cmd.Flags().String("db-host", "", "host of the database, overrides Database.Host")
+	cmd.Flags().Int("db-port", 0, "port of the database, overrides Database.Port")
+	cmd.Flags().String("db-user", "", "user of the database, overrides Database.User")
+	cmd.Flags().String("db-name", "", "name of the database, overrides Database.DbName")
+	opts.bindFlag(cmd.Flags(), "db-host", "Database.Host")
+	opts.bindFlag(cmd.Flags(), "db-port", "Database.Port")
+	opts.bindFlag(cmd.Flags(), "db-user", "Database.User")
+	opts.bindFlag(cmd.Flags(), "db-name", "Database.DbName")
+}
+
+// newStore connects to the database without creating the schema and creates the todo store, the returned func shuts
+// the client down
+func newStore(cfg models.DatabaseConfig, logger zerolog.Logger) (todo.TodoStore, func() error, error) {
+	cfg.CreateTable = false
+	newPgClient, err := postgres.NewClient(logger, cfg)
+	if err != nil {
+		return nil, nil, errors.Wrap(err, "failed to initialize pg client")
+	}
+
+	newTodoStore := todo.NewStore(newPgClient)
+	return &newTodoStore, newPgClient.Shutdown, nil
+}
@@ -0,0 +1,52 @@
+package cli
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+)
+
+// newHealthcheckCommand creates the command probing the admin server of a running service, e.g. for the HEALTHCHECK of
+// the container image which has no curl
+func newHealthcheckCommand(opts *options) *cobra.Command {
+	var probe, url string
+	var timeout time.Duration
+
+	cmd := &cobra.Command{
+		Use:   "healthcheck",
+		Short: "Probe the health of a running service, failing unless it's healthy",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if probe != "livez" && probe != "readyz" && probe != "startupz" {
+				return errors.Errorf("invalid probe %q, must be livez, readyz or startupz", probe)
+			}
+			if url == "" {
+				cfg, _, err := opts.loadConfig()
+				if err != nil {
+					return err
+				}
+				url = fmt.Sprintf("http://127.0.0.1:%d/%s", cfg.AdminServer.Port, probe)
+			}
+
+			client := http.Client{Timeout: timeout}
+			resp, err := client.Get(url)
+			if err != nil {
+				return errors.Wrap(err, "unhealthy")
+			}
+			defer resp.Body.Close()
+			if resp.StatusCode != http.StatusOK {
+				return errors.Errorf("unhealthy: %s returned %s", url, resp.Status)
+			}
+			return nil
+		},
+	}
+	cmd.Flags().StringVar(&probe, "probe", "readyz", "probe of the admin server, livez, readyz or startupz")
+	cmd.Flags().StringVar(&url, "url", "", "url to probe instead of the probe of the admin server")
+	cmd.Flags().DurationVar(&timeout, "timeout", 3*time.Second, "timeout of the probe")
+	cmd.Flags().Int("admin-port", 0, "port of the admin server, overrides AdminServer.Port")
+	opts.bindFlag(cmd.Flags(), "admin-port", "AdminServer.Port")
+	return cmd
+}
@@ -0,0 +1,46 @@
+package cli
+
+import (
+	"context"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+
+	"github.com/alexsniffin/go-api-starter/internal/todo-api/clients/postgres"
+)
+
+// newMigrateCommand creates the command creating the todo table and applying the migrations to the database
+func newMigrateCommand(opts *options) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "migrate",
+		Short: "Create the tables and apply the migrations to the database",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cfg, _, logger, err := opts.setUp(cmd.ErrOrStderr())
+			if err != nil {
+				return err
+			}
+
+			// the tables are checked after migrating, they don't exist yet on a new database
+			cfg.Database.CreateTable = false
+			cfg.Database.Tables = nil
+			newPgClient, err := postgres.NewClient(logger, cfg.Database)
+			if err != nil {
+				return errors.Wrap(err, "failed to initialize pg client")
+			}
+			defer newPgClient.Shutdown()
+
+			if err = newPgClient.CreateSchema(); err != nil {
+				return err
+			}
+			if err = newPgClient.CheckMigrations(context.Background()); err != nil {
+				return err
+			}
+
+			logger.Info().Msg("migrations applied")
+			return nil
+		},
+	}
+	bindDatabaseFlags(cmd, opts)
+	return cmd
+}
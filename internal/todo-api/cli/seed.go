@@ -0,0 +1,72 @@
+package cli
+
+import (
+	"context"
+	"math/rand"
+	"strconv"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+
+	"github.com/alexsniffin/go-api-starter/internal/todo-api/models"
+)
+
+var (
+	seedVerbs = []string{"buy", "call", "clean", "fix", "plan", "read", "review", "write"}
+	seedNouns = []string{"groceries", "the dentist", "the garage", "the bike", "the trip", "a book", "the report",
+		"a letter"}
+)
+
+// newSeedCommand creates the command inserting generated todos into the database, e.g. for local testing
+func newSeedCommand(opts *options) *cobra.Command {
+	var userID, list string
+	var doneRatio float64
+
+	cmd := &cobra.Command{
+		Use:   "seed <count>",
+		Short: "Insert generated todos into the database",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			count, err := strconv.Atoi(args[0])
+			if err != nil || count < 1 {
+				return errors.Errorf("invalid count %q, must be a positive number", args[0])
+			}
+
+			cfg, _, logger, err := opts.setUp(cmd.ErrOrStderr())
+			if err != nil {
+				return err
+			}
+			newTodoStore, closeStore, err := opts.newStore(cfg.Database, logger)
+			if err != nil {
+				return err
+			}
+			defer closeStore()
+
+			ctx := logger.WithContext(context.Background())
+			random := rand.New(rand.NewSource(time.Now().UnixNano()))
+			for i := 0; i < count; i++ {
+				now := time.Now()
+				_, err = newTodoStore.PostTodo(ctx, models.TodoItem{
+					Todo:      seedVerbs[random.Intn(len(seedVerbs))] + " " + seedNouns[random.Intn(len(seedNouns))],
+					List:      list,
+					UserID:    userID,
+					Done:      random.Float64() < doneRatio,
+					CreatedOn: now,
+					UpdatedOn: now,
+				})
+				if err != nil {
+					return errors.Wrapf(err, "failed to insert todo %d", i+1)
+				}
+			}
+
+			logger.Info().Int("count", count).Msg("todos seeded")
+			return nil
+		},
+	}
+	cmd.Flags().StringVar(&userID, "user", "", "user of the todos")
+	cmd.Flags().StringVar(&list, "list", "", "list of the todos")
+	cmd.Flags().Float64Var(&doneRatio, "done-ratio", 0.25, "ratio of the todos which are done")
+	bindDatabaseFlags(cmd, opts)
+	return cmd
+}
@@ -0,0 +1,88 @@
+package cli
+
+import (
+	"context"
+	"errors"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/spf13/cobra"
+
+	"github.com/alexsniffin/go-api-starter/internal/todo-api/server"
+	"github.com/alexsniffin/go-api-starter/pkg/tracing"
+)
+
+// newServeCommand creates the command running the service until it's interrupted or a critical process fails
+func newServeCommand(opts *options) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "serve",
+		Short: "Run the todo api service",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return serve(opts)
+		},
+	}
+	cmd.Flags().Int("http-port", 0, "port of the http server, overrides HTTPServer.Port")
+	cmd.Flags().Int("admin-port", 0, "port of the admin server, overrides AdminServer.Port")
+	cmd.Flags().Int("grpc-port", 0, "port of the grpc server, overrides GRPCServer.Port")
+	opts.bindFlag(cmd.Flags(), "http-port", "HTTPServer.Port")
+	opts.bindFlag(cmd.Flags(), "admin-port", "AdminServer.Port")
+	opts.bindFlag(cmd.Flags(), "grpc-port", "GRPCServer.Port")
+	bindDatabaseFlags(cmd, opts)
+	return cmd
+}
+
+func serve(opts *options) error {
+	newCfg, newConfigLoader, newLogger, err := opts.setUp(os.Stdout)
+	if err != nil {
+		return err
+	}
+
+	newTracerProvider, err := tracing.NewTracerProvider(newCfg)
+	if err != nil {
+		newLogger.Error().Caller().Err(err).Msg("failed to set up tracing")
+		return &exitError{code: 1}
+	}
+
+	newLogger.Info().Msg("setting up todo api service")
+	newServer, err := server.NewServer(newCfg, newLogger, newTracerProvider, newConfigLoader)
+	if err != nil {
+		newLogger.Error().Caller().Err(err).Msg("failed to set up todo api service")
+		return &exitError{code: 1}
+	}
+
+	fatalErrCh := make(chan error, 1)
+	go func() {
+		fatalErrCh <- newServer.Start()
+	}()
+
+	stop := make(chan os.Signal, 1)
+	signal.Notify(stop, os.Interrupt)
+	signal.Notify(stop, syscall.SIGTERM)
+
+	exitCode := 0
+	select {
+	case stopped := <-stop:
+		newLogger.Info().Msg(stopped.String() + " signal received, attempting to gracefully shutdown")
+	case err = <-fatalErrCh:
+		newLogger.Error().Caller().Err(err).Msg("fatal error received from process, attempting to gracefully shutdown")
+		exitCode = 1
+	}
+
+	err = newServer.Shutdown(context.Background())
+	if errors.Is(err, server.ErrShutdownTimeout) {
+		newLogger.Error().Caller().Err(err).Msg("failed to shutdown before the deadline")
+		return &exitError{code: 2}
+	}
+	if err != nil {
+		newLogger.Error().Caller().Err(err).Msg("failed to shutdown gracefully")
+		exitCode = 1
+	}
+
+	newLogger.Info().Int("code", exitCode).Msg("exiting todo api service")
+	if exitCode != 0 {
+		return &exitError{code: exitCode}
+	}
+	return nil
+}
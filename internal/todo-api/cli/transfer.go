@@ -0,0 +1,162 @@
+package cli
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"io"
+	"os"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+
+	"github.com/alexsniffin/go-api-starter/internal/todo-api/models"
+)
+
+// exportPageSize is the number of todos read from the database at once
+const exportPageSize = 1000
+
+// newExportCommand creates the command writing the todos of every user as JSON lines
+func newExportCommand(opts *options) *cobra.Command {
+	var file string
+
+	cmd := &cobra.Command{
+		Use:   "export",
+		Short: "Export the todos of every user as JSON lines",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cfg, _, logger, err := opts.setUp(cmd.ErrOrStderr())
+			if err != nil {
+				return err
+			}
+			newTodoStore, closeStore, err := opts.newStore(cfg.Database, logger)
+			if err != nil {
+				return err
+			}
+			defer closeStore()
+
+			out := cmd.OutOrStdout()
+			if file != "" {
+				f, err := os.Create(file)
+				if err != nil {
+					return err
+				}
+				defer f.Close()
+				out = f
+			}
+			w := bufio.NewWriter(out)
+			enc := json.NewEncoder(w)
+
+			ctx := logger.WithContext(context.Background())
+			filter := models.TodoFilter{AllUsers: true, Limit: exportPageSize}
+			count := 0
+			for {
+				todos, err := newTodoStore.ListTodos(ctx, filter)
+				if err != nil {
+					return err
+				}
+				for i := 0; i < len(todos); i++ {
+					if err = enc.Encode(todos[i]); err != nil {
+						return err
+					}
+				}
+				count += len(todos)
+				if len(todos) < filter.Limit {
+					break
+				}
+				filter.AfterID = todos[len(todos)-1].ID
+			}
+			if err = w.Flush(); err != nil {
+				return err
+			}
+
+			logger.Info().Int("count", count).Msg("todos exported")
+			return nil
+		},
+	}
+	cmd.Flags().StringVarP(&file, "file", "f", "", "file to write to instead of stdout")
+	bindDatabaseFlags(cmd, opts)
+	return cmd
+}
+
+// newImportCommand creates the command inserting the todos of JSON lines, e.g. of an export. The todos are assigned new
+// IDs.
+func newImportCommand(opts *options) *cobra.Command {
+	var file string
+
+	cmd := &cobra.Command{
+		Use:   "import",
+		Short: "Import todos from JSON lines",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			in := cmd.InOrStdin()
+			if file != "" {
+				f, err := os.Open(file)
+				if err != nil {
+					return err
+				}
+				defer f.Close()
+				in = f
+			}
+
+			cfg, _, logger, err := opts.setUp(cmd.ErrOrStderr())
+			if err != nil {
+				return err
+			}
+			newTodoStore, closeStore, err := opts.newStore(cfg.Database, logger)
+			if err != nil {
+				return err
+			}
+			defer closeStore()
+
+			ctx := logger.WithContext(context.Background())
+			count, err := importTodos(in, func(todo models.TodoItem) error {
+				_, err := newTodoStore.PostTodo(ctx, todo)
+				return err
+			})
+			if err != nil {
+				return errors.Wrapf(err, "imported %d todos before failing", count)
+			}
+
+			logger.Info().Int("count", count).Msg("todos imported")
+			return nil
+		},
+	}
+	cmd.Flags().StringVarP(&file, "file", "f", "", "file to read from instead of stdin")
+	bindDatabaseFlags(cmd, opts)
+	return cmd
+}
+
+// importTodos decodes the todos of the JSON lines and posts them in order, returning the number of todos posted
+func importTodos(r io.Reader, post func(models.TodoItem) error) (int, error) {
+	dec := json.NewDecoder(r)
+	dec.DisallowUnknownFields()
+
+	count := 0
+	for line := 1; ; line++ {
+		var todo models.TodoItem
+		err := dec.Decode(&todo)
+		if err == io.EOF {
+			return count, nil
+		}
+		if err != nil {
+			return count, errors.Wrapf(err, "invalid todo %d", line)
+		}
+		if todo.Todo == "" {
+			return count, errors.Errorf("invalid todo %d: todo cannot be blank", line)
+		}
+		if todo.CreatedOn.IsZero() {
+			todo.CreatedOn = time.Now()
+		}
+		if todo.UpdatedOn.IsZero() {
+			todo.UpdatedOn = todo.CreatedOn
+		}
+
+		todo.ID = 0
+		if err = post(todo); err != nil {
+			return count, errors.Wrapf(err, "failed to insert todo %d", line)
+		}
+		count++
+	}
+}
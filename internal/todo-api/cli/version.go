@@ -0,0 +1,22 @@
+package cli
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+
+	"github.com/alexsniffin/go-api-starter/pkg/version"
+)
+
+// newVersionCommand creates the command printing the build details of the binary
+func newVersionCommand() *cobra.Command {
+	return &cobra.Command{
+		Use:   "version",
+		Short: "Print the version of the binary",
+		Args:  cobra.NoArgs,
+		Run: func(cmd *cobra.Command, args []string) {
+			fmt.Fprintf(cmd.OutOrStdout(), "todo-api %s (commit %s, built %s)\n", version.Version, version.Commit,
+				version.BuildDate)
+		},
+	}
+}
@@ -0,0 +1,239 @@
+package postgres
+
+import (
+	"context"
+	"encoding/json"
+	"net"
+	"time"
+
+	"github.com/go-pg/pg"
+	"github.com/rs/zerolog"
+
+	"github.com/alexsniffin/go-api-starter/internal/todo-api/models"
+)
+
+const (
+	// ChangeFeedChannel is the channel TodoEvents are notified on
+	ChangeFeedChannel = "todo_changes"
+
+	// maxNotifyPayload is the limit of a NOTIFY payload, larger payloads are loaded from the outbox by the listener
+	maxNotifyPayload = 7900
+
+	receiveTimeout = time.Second
+)
+
+// ChangeHandler receives the TodoEvents of the change feed
+type ChangeHandler interface {
+	Publish(ctx context.Context, event models.TodoEvent) error
+}
+
+// ChangeFeed listens for TodoEvents notified by any replica on a dedicated connection and hands them to the
+// ChangeHandler. The connection is re-established with backoff if it's lost and events committed while disconnected
+// are backfilled from the outbox.
+type ChangeFeed struct {
+	cfg    models.ChangeFeedConfig
+	logger zerolog.Logger
+
+	pgClient DatabaseClient
+	handler  ChangeHandler
+
+	lastID     int64
+	backfilled map[int64]struct{}
+}
+
+// notification is the payload of a change feed NOTIFY, `Truncated` is set if the payload of the event was too large and
+// has to be loaded from the outbox
+type notification struct {
+	models.TodoEvent
+	Truncated bool `json:"truncated,omitempty"`
+}
+
+// Notify publishes the event to the change feed, must be called within the transaction of the mutation so the
+// notification is only delivered on commit
+func Notify(ctx context.Context, tx *pg.Tx, event models.TodoEvent) error {
+	payload, err := encodeNotification(event)
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.ExecContext(ctx, `SELECT pg_notify(?, ?)`, ChangeFeedChannel, payload)
+	return err
+}
+
+// encodeNotification encodes the event for a NOTIFY, leaving out its payload if the notification would be too large
+func encodeNotification(event models.TodoEvent) (string, error) {
+	payload, err := json.Marshal(notification{TodoEvent: event})
+	if err != nil {
+		return "", err
+	}
+	if len(payload) > maxNotifyPayload {
+		event.Payload = nil
+		if payload, err = json.Marshal(notification{TodoEvent: event, Truncated: true}); err != nil {
+			return "", err
+		}
+	}
+	return string(payload), nil
+}
+
+// decodeNotification decodes the event of a NOTIFY, `truncated` is true if its payload has to be loaded from the outbox
+func decodeNotification(payload string) (event models.TodoEvent, truncated bool, err error) {
+	var n notification
+	if err = json.Unmarshal([]byte(payload), &n); err != nil {
+		return models.TodoEvent{}, false, err
+	}
+	if n.Truncated {
+		n.TodoEvent.Payload = nil
+	}
+	return n.TodoEvent, n.Truncated, nil
+}
+
+// NewChangeFeed creates a new ChangeFeed
+func NewChangeFeed(
+	cfg models.ChangeFeedConfig,
+	logger zerolog.Logger,
+	pgClient DatabaseClient,
+	handler ChangeHandler,
+) *ChangeFeed {
+	return &ChangeFeed{
+		cfg:      cfg,
+		logger:   logger,
+		pgClient: pgClient,
+		handler:  handler,
+	}
+}
+
+func (c *ChangeFeed) Name() string {
+	return "change-feed"
+}
+
+// Start listening to the change feed which will block the current goroutine until the context is cancelled.
+func (c *ChangeFeed) Start(ctx context.Context) error {
+	c.logger.Info().Msg("running change feed listener")
+
+	backoff := time.Duration(0)
+	for {
+		err := c.listen(ctx)
+		if err == nil {
+			c.logger.Info().Msg("change feed listener process stopped")
+			return nil
+		}
+
+		backoff = nextBackoff(backoff, time.Duration(c.cfg.MaxBackoffMs)*time.Millisecond)
+		c.logger.Error().Caller().Err(err).Dur("backoff", backoff).Msg("change feed connection lost, reconnecting")
+
+		select {
+		case <-ctx.Done():
+			c.logger.Info().Msg("change feed listener process stopped")
+			return nil
+		case <-time.After(backoff):
+		}
+	}
+}
+
+// Shutdown has nothing to release, the listener is closed once the context of `Start` is cancelled.
+func (c *ChangeFeed) Shutdown(_ context.Context) error {
+	return nil
+}
+
+// listen receives notifications until the context is cancelled, returns an error if the connection is lost
+func (c *ChangeFeed) listen(ctx context.Context) error {
+	ln := c.pgClient.GetConnection().Listen()
+	defer ln.Close()
+
+	// the LISTEN must be established before backfilling, so no event falls in between
+	if err := ln.Listen(ChangeFeedChannel); err != nil {
+		return err
+	}
+	if err := c.backfill(); err != nil {
+		return err
+	}
+
+	for {
+		if ctx.Err() != nil {
+			return nil
+		}
+
+		if err := c.receive(ln); err != nil {
+			return err
+		}
+	}
+}
+
+// receive waits for a single notification and dispatches it, a receive timeout is not an error
+func (c *ChangeFeed) receive(ln *pg.Listener) error {
+	_, payload, err := ln.ReceiveTimeout(receiveTimeout)
+	if err != nil {
+		if netErr, ok := err.(net.Error); ok && netErr.Timeout() {
+			return nil
+		}
+		return err
+	}
+	if payload == "" {
+		return nil
+	}
+
+	event, truncated, err := decodeNotification(payload)
+	if err != nil {
+		c.logger.Error().Caller().Err(err).Msg("failed to decode change feed notification")
+		return nil
+	}
+	if _, ok := c.backfilled[event.ID]; ok {
+		return nil
+	}
+	if truncated {
+		if err = c.loadPayload(&event); err != nil {
+			return err
+		}
+	}
+
+	c.dispatch(event)
+	return nil
+}
+
+// backfill dispatches the events committed since the last event seen, up to the configured size
+func (c *ChangeFeed) backfill() error {
+	c.backfilled = make(map[int64]struct{})
+	if c.lastID == 0 {
+		return nil
+	}
+
+	var events []models.TodoEvent
+	_, err := c.pgClient.GetConnection().Query(&events, `SELECT id, type, todo_id, list, user_id, payload, created_on
+		FROM outbox WHERE id > ? ORDER BY id LIMIT ?`, c.lastID, c.cfg.BackfillSize)
+	if err != nil {
+		return err
+	}
+
+	for i := 0; i < len(events); i++ {
+		c.backfilled[events[i].ID] = struct{}{}
+		c.dispatch(events[i])
+	}
+	c.logger.Debug().Int("count", len(events)).Msg("backfilled change feed from outbox")
+	return nil
+}
+
+// loadPayload loads the payload of an event which was too large for the notification
+func (c *ChangeFeed) loadPayload(event *models.TodoEvent) error {
+	_, err := c.pgClient.GetConnection().QueryOne(pg.Scan(&event.Payload),
+		`SELECT payload FROM outbox WHERE id = ?`, event.ID)
+	return err
+}
+
+func (c *ChangeFeed) dispatch(event models.TodoEvent) {
+	if event.ID > c.lastID {
+		c.lastID = event.ID
+	}
+	if err := c.handler.Publish(context.Background(), event); err != nil {
+		c.logger.Error().Caller().Err(err).Int64("eventID", event.ID).Msg("failed to dispatch change feed event")
+	}
+}
+
+func nextBackoff(current, max time.Duration) time.Duration {
+	if current == 0 {
+		return 100 * time.Millisecond
+	}
+	if current*2 > max {
+		return max
+	}
+	return current * 2
+}
@@ -0,0 +1,64 @@
+package postgres
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/alexsniffin/go-api-starter/internal/todo-api/models"
+)
+
+func TestNotification(t *testing.T) {
+	t.Run("roundTrip", func(t *testing.T) {
+		event := models.TodoEvent{ID: 1, Type: "created", TodoID: 2, Payload: json.RawMessage(`{"todo":"test"}`)}
+
+		payload, err := encodeNotification(event)
+		if err != nil {
+			t.Fatal(err)
+		}
+		decoded, truncated, err := decodeNotification(payload)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if truncated {
+			t.Error("unexpected truncated notification")
+		}
+		if decoded.ID != event.ID || decoded.TodoID != event.TodoID || string(decoded.Payload) != string(event.Payload) {
+			t.Errorf("unexpected event: got %+v want %+v", decoded, event)
+		}
+	})
+
+	t.Run("payloadTooLarge", func(t *testing.T) {
+		large := `{"todo":"` + strings.Repeat("a", maxNotifyPayload) + `"}`
+		event := models.TodoEvent{ID: 1, Type: "created", TodoID: 2, Payload: json.RawMessage(large)}
+
+		payload, err := encodeNotification(event)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(payload) > maxNotifyPayload {
+			t.Errorf("unexpected notification size: got %v want at most %v", len(payload), maxNotifyPayload)
+		}
+
+		decoded, truncated, err := decodeNotification(payload)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !truncated {
+			t.Error("expected truncated notification")
+		}
+		if decoded.Payload != nil {
+			t.Errorf("unexpected payload: got %s want nil", decoded.Payload)
+		}
+		if decoded.ID != event.ID || decoded.TodoID != event.TodoID {
+			t.Errorf("unexpected event: got %+v want %+v", decoded, event)
+		}
+	})
+
+	t.Run("invalid", func(t *testing.T) {
+		if _, _, err := decodeNotification("{"); err == nil {
+			t.Error("expected error")
+		}
+	})
+}
@@ -0,0 +1,121 @@
+package postgres
+
+import (
+	"strings"
+	"time"
+
+	"github.com/go-pg/pg"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+
+	"github.com/alexsniffin/go-api-starter/internal/todo-api/metrics"
+)
+
+const startKey = "metricsStart"
+
+var (
+	queryDuration = promauto.With(metrics.Registry).NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "db_query_duration_seconds",
+		Help:    "The latency of the database queries.",
+		Buckets: prometheus.DefBuckets,
+	}, []string{"operation"})
+	queryErrors = promauto.With(metrics.Registry).NewCounterVec(prometheus.CounterOpts{
+		Name: "db_query_errors_total",
+		Help: "The number of failed database queries.",
+	}, []string{"operation"})
+)
+
+// operations are the statements labeled by their keyword, anything else is labeled as "other"
+var operations = map[string]struct{}{
+	"SELECT": {}, "INSERT": {}, "UPDATE": {}, "DELETE": {}, "BEGIN": {}, "COMMIT": {}, "ROLLBACK": {}, "LISTEN": {},
+	"UNLISTEN": {}, "NOTIFY": {}, "CREATE": {}, "ALTER": {},
+}
+
+// metricsHook measures the latency of every query by operation and counts the failed ones
+type metricsHook struct{}
+
+func (h metricsHook) BeforeQuery(ev *pg.QueryEvent) {
+	ev.Data[startKey] = time.Now()
+}
+
+func (h metricsHook) AfterQuery(ev *pg.QueryEvent) {
+	start, ok := ev.Data[startKey].(time.Time)
+	if !ok {
+		return
+	}
+
+	operation := operationOf(ev)
+	queryDuration.WithLabelValues(operation).Observe(time.Since(start).Seconds())
+	if ev.Error != nil && ev.Error != pg.ErrNoRows {
+		queryErrors.WithLabelValues(operation).Inc()
+	}
+}
+
+// operationOf returns the leading keyword of the statement of the query
+func operationOf(ev *pg.QueryEvent) string {
+	statement, err := ev.UnformattedQuery()
+	if err != nil {
+		return "other"
+	}
+
+	fields := strings.Fields(statement)
+	if len(fields) == 0 {
+		return "other"
+	}
+	operation := strings.ToUpper(fields[0])
+	if _, ok := operations[operation]; !ok {
+		return "other"
+	}
+	return operation
+}
+
+// poolCollector reports the connection pool statistics of a database on every scrape
+type poolCollector struct {
+	db *pg.DB
+
+	hits     *prometheus.Desc
+	misses   *prometheus.Desc
+	timeouts *prometheus.Desc
+	total    *prometheus.Desc
+	idle     *prometheus.Desc
+	stale    *prometheus.Desc
+}
+
+// NewPoolCollector creates a collector of the connection pool statistics of the client
+func NewPoolCollector(client *Client) prometheus.Collector {
+	return &poolCollector{
+		db: client.db,
+
+		hits: prometheus.NewDesc("db_pool_hits_total",
+			"The number of times a free connection was found in the pool.", nil, nil),
+		misses: prometheus.NewDesc("db_pool_misses_total",
+			"The number of times a free connection was not found in the pool.", nil, nil),
+		timeouts: prometheus.NewDesc("db_pool_timeouts_total",
+			"The number of times waiting for a connection of the pool timed out.", nil, nil),
+		total: prometheus.NewDesc("db_pool_connections",
+			"The number of connections in the pool.", nil, nil),
+		idle: prometheus.NewDesc("db_pool_idle_connections",
+			"The number of idle connections in the pool.", nil, nil),
+		stale: prometheus.NewDesc("db_pool_stale_connections_total",
+			"The number of stale connections removed from the pool.", nil, nil),
+	}
+}
+
+func (c *poolCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.hits
+	ch <- c.misses
+	ch <- c.timeouts
+	ch <- c.total
+	ch <- c.idle
+	ch <- c.stale
+}
+
+func (c *poolCollector) Collect(ch chan<- prometheus.Metric) {
+	stats := c.db.PoolStats()
+	ch <- prometheus.MustNewConstMetric(c.hits, prometheus.CounterValue, float64(stats.Hits))
+	ch <- prometheus.MustNewConstMetric(c.misses, prometheus.CounterValue, float64(stats.Misses))
+	ch <- prometheus.MustNewConstMetric(c.timeouts, prometheus.CounterValue, float64(stats.Timeouts))
+	ch <- prometheus.MustNewConstMetric(c.total, prometheus.GaugeValue, float64(stats.TotalConns))
+	ch <- prometheus.MustNewConstMetric(c.idle, prometheus.GaugeValue, float64(stats.IdleConns))
+	ch <- prometheus.MustNewConstMetric(c.stale, prometheus.CounterValue, float64(stats.StaleConns))
+}
@@ -0,0 +1,101 @@
+package postgres
+
+import (
+	"context"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/go-pg/pg"
+	"github.com/go-pg/pg/orm"
+	"github.com/pkg/errors"
+
+	"github.com/alexsniffin/go-api-starter/internal/todo-api/models"
+)
+
+// migrations are applied in order, every statement must be idempotent. `?TableName` resolves to the todo table.
+var migrations = []string{
+	`CREATE TABLE IF NOT EXISTS outbox (
+		id BIGSERIAL PRIMARY KEY,
+		type TEXT NOT NULL,
+		todo_id BIGINT NOT NULL,
+		payload JSONB NOT NULL,
+		created_on TIMESTAMPTZ NOT NULL,
+		sent_on TIMESTAMPTZ
+	)`,
+	`CREATE INDEX IF NOT EXISTS outbox_unsent_idx ON outbox (id) WHERE sent_on IS NULL`,
+	`ALTER TABLE ?TableName ADD COLUMN IF NOT EXISTS user_id TEXT`,
+	`ALTER TABLE outbox ADD COLUMN IF NOT EXISTS user_id TEXT`,
+	`ALTER TABLE ?TableName ADD COLUMN IF NOT EXISTS list TEXT`,
+	`ALTER TABLE outbox ADD COLUMN IF NOT EXISTS list TEXT`,
+	`ALTER TABLE ?TableName ADD COLUMN IF NOT EXISTS done BOOLEAN NOT NULL DEFAULT false`,
+	`ALTER TABLE ?TableName ADD COLUMN IF NOT EXISTS updated_on TIMESTAMPTZ`,
+	`ALTER TABLE ?TableName ADD COLUMN IF NOT EXISTS idempotency_key TEXT`,
+	`CREATE UNIQUE INDEX IF NOT EXISTS todo_idempotency_key_idx ON ?TableName (COALESCE(user_id, ''), idempotency_key)`,
+	`ALTER TABLE ?TableName ADD COLUMN IF NOT EXISTS external_id TEXT`,
+	`CREATE UNIQUE INDEX IF NOT EXISTS todo_external_id_idx ON ?TableName (COALESCE(user_id, ''), external_id)`,
+}
+
+// migratedColumns are the columns of each table once all migrations are applied, the todo table is keyed by ""
+var migratedColumns = map[string][]string{
+	"":       {"id", "todo", "list", "user_id", "done", "created_on", "updated_on", "idempotency_key", "external_id"},
+	"outbox": {"id", "type", "todo_id", "list", "user_id", "payload", "created_on", "sent_on"},
+}
+
+// Migrate applies the schema migrations to the database
+func Migrate(db *pg.DB) error {
+	for i := 0; i < len(migrations); i++ {
+		_, err := db.Model((*models.TodoItem)(nil)).Exec(migrations[i])
+		if err != nil {
+			return errors.Wrapf(err, "failed to apply migration %d", i)
+		}
+	}
+
+	return nil
+}
+
+// CheckMigrations returns an error listing the columns missing from the database if migrations aren't applied
+func (p *Client) CheckMigrations(ctx context.Context) error {
+	todoTable := orm.GetTable(reflect.TypeOf((*models.TodoItem)(nil)).Elem()).Name
+
+	tables := make([]string, 0, len(migratedColumns))
+	for table := range migratedColumns {
+		if table == "" {
+			table = todoTable
+		}
+		tables = append(tables, table)
+	}
+
+	var columns []struct {
+		TableName  string
+		ColumnName string
+	}
+	_, err := p.db.QueryContext(ctx, &columns, `SELECT table_name, column_name FROM information_schema.columns
+		WHERE table_schema = current_schema() AND table_name IN (?)`, pg.In(tables))
+	if err != nil {
+		return err
+	}
+
+	found := make(map[string]struct{}, len(columns))
+	for i := 0; i < len(columns); i++ {
+		found[columns[i].TableName+"."+columns[i].ColumnName] = struct{}{}
+	}
+
+	var missing []string
+	for table, tableColumns := range migratedColumns {
+		if table == "" {
+			table = todoTable
+		}
+		for i := 0; i < len(tableColumns); i++ {
+			if _, ok := found[table+"."+tableColumns[i]]; !ok {
+				missing = append(missing, table+"."+tableColumns[i])
+			}
+		}
+	}
+	if len(missing) > 0 {
+		sort.Strings(missing)
+		return errors.Errorf("migrations not applied, missing columns: %s", strings.Join(missing, ", "))
+	}
+
+	return nil
+}
@@ -1,6 +1,7 @@
 package postgres
 
 import (
+	"context"
 	"fmt"
 
 	"github.com/go-pg/pg"
@@ -29,18 +30,13 @@ func NewClient(logger zerolog.Logger, cfg models.DatabaseConfig) (Client, error)
 		Database: cfg.DbName,
 		PoolSize: 20,
 	})
+	db.AddQueryHook(newQueryHook())
+	db.AddQueryHook(metricsHook{})
 
 	if cfg.CreateTable {
-		err := db.CreateTable((*models.TodoItem)(nil), &orm.CreateTableOptions{
-			Temp:          false,
-			IfNotExists:   false,
-			Varchar:       0,
-			FKConstraints: false,
-		})
+		err := createSchema(db)
 		if err != nil {
-			if err.Error()[:12] != "ERROR #42P07" {
-				return Client{}, errors.Wrap(err, "failed to create todo table")
-			}
+			return Client{}, err
 		}
 	}
 
@@ -62,6 +58,17 @@ func NewClient(logger zerolog.Logger, cfg models.DatabaseConfig) (Client, error)
 	}, nil
 }
 
+// CreateSchema creates the todo table if it doesn't exist and applies the migrations
+func (p *Client) CreateSchema() error {
+	return createSchema(p.db)
+}
+
+// Ping checks the database is reachable
+func (p *Client) Ping(ctx context.Context) error {
+	_, err := p.db.ExecContext(ctx, `SELECT 1`)
+	return err
+}
+
 // Return the connection
 func (p *Client) GetConnection() *pg.DB {
 	return p.db
@@ -76,3 +83,20 @@ func (p *Client) Shutdown() error {
 
 	return nil
 }
+
+// createSchema creates the todo table, ignoring the error if it already exists, and applies the migrations
+func createSchema(db *pg.DB) error {
+	err := db.CreateTable((*models.TodoItem)(nil), &orm.CreateTableOptions{
+		Temp:          false,
+		IfNotExists:   false,
+		Varchar:       0,
+		FKConstraints: false,
+	})
+	if err != nil {
+		if err.Error()[:12] != "ERROR #42P07" {
+			return errors.Wrap(err, "failed to create todo table")
+		}
+	}
+
+	return Migrate(db)
+}
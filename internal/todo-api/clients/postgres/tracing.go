@@ -0,0 +1,73 @@
+package postgres
+
+import (
+	"github.com/go-pg/pg"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	semconv "go.opentelemetry.io/otel/semconv/v1.4.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const (
+	tracerName = "github.com/alexsniffin/go-api-starter/internal/todo-api/clients/postgres"
+
+	spanKey = "otelSpan"
+
+	// maxStatementLength of the statement attribute of a span
+	maxStatementLength = 2048
+)
+
+// queryHook creates a span for every query made within a trace, queries outside of a trace, e.g. polling the outbox,
+// aren't traced. The statement is recorded without its parameters.
+type queryHook struct {
+	tracer trace.Tracer
+}
+
+func newQueryHook() queryHook {
+	return queryHook{
+		tracer: otel.Tracer(tracerName),
+	}
+}
+
+func (h queryHook) BeforeQuery(ev *pg.QueryEvent) {
+	ctx := ev.Ctx
+	if ctx == nil || !trace.SpanContextFromContext(ctx).IsValid() {
+		return
+	}
+
+	statement, err := ev.UnformattedQuery()
+	if err != nil {
+		statement = ""
+	}
+	if len(statement) > maxStatementLength {
+		statement = statement[:maxStatementLength]
+	}
+
+	_, span := h.tracer.Start(ctx, "postgres.query",
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(
+			semconv.DBSystemPostgreSQL,
+			semconv.DBStatementKey.String(statement),
+			attribute.Int("db.attempt", ev.Attempt),
+		),
+	)
+	ev.Data[spanKey] = span
+}
+
+func (h queryHook) AfterQuery(ev *pg.QueryEvent) {
+	span, ok := ev.Data[spanKey].(trace.Span)
+	if !ok {
+		return
+	}
+	defer span.End()
+
+	if ev.Error != nil && ev.Error != pg.ErrNoRows {
+		span.RecordError(ev.Error)
+		span.SetStatus(codes.Error, ev.Error.Error())
+		return
+	}
+	if ev.Result != nil {
+		span.SetAttributes(attribute.Int("db.rows_affected", ev.Result.RowsAffected()))
+	}
+}
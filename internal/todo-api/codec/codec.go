@@ -0,0 +1,448 @@
+package codec
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime"
+	"strconv"
+	"strings"
+
+	"github.com/fxamacker/cbor/v2"
+	"github.com/pkg/errors"
+	"github.com/vmihailenco/msgpack/v5"
+	"gopkg.in/yaml.v2"
+)
+
+// Media types of the codecs, CSV has no Codec since only lists of todos are written as CSV
+const (
+	JSON        = "application/json"
+	MessagePack = "application/msgpack"
+	CBOR        = "application/cbor"
+	YAML        = "application/yaml"
+	CSV         = "text/csv"
+)
+
+var (
+	// ErrUnsupported is returned for the content type of a request without a Codec
+	ErrUnsupported = errors.New("unsupported content type")
+	// ErrTooLarge is returned by a LimitReader once more than its limit is read
+	ErrTooLarge = errors.New("body too large")
+)
+
+// Codec marshals and unmarshals values as a media type, the field names are those of the json tags for every codec
+type Codec interface {
+	Marshal(v interface{}) ([]byte, error)
+	Unmarshal(data []byte, v interface{}) error
+	// Decode reads a single value from a request body, unknown fields and anything after the value are rejected
+	Decode(r io.Reader, v interface{}) error
+}
+
+// DecodeError is a request body which couldn't be decoded, with the path of the field and the byte offset of the
+// error if they're known
+type DecodeError struct {
+	Field  string
+	Offset int64
+	Err    error
+}
+
+func (e *DecodeError) Error() string {
+	msg := e.Err.Error()
+	if e.Field != "" {
+		msg = fmt.Sprintf("field %s: %s", e.Field, msg)
+	}
+	if e.Offset > 0 {
+		msg = fmt.Sprintf("%s at offset %d", msg, e.Offset)
+	}
+	return msg
+}
+
+func (e *DecodeError) Unwrap() error {
+	return e.Err
+}
+
+var errMultipleValues = errors.New("body must only contain a single value")
+
+var (
+	cborEncMode, _ = cbor.EncOptions{Time: cbor.TimeRFC3339Nano}.EncMode()
+	cborDecMode, _ = cbor.DecOptions{ExtraReturnErrors: cbor.ExtraDecErrorUnknownField}.DecMode()
+)
+
+var codecs = map[string]Codec{
+	JSON:        jsonCodec{},
+	MessagePack: messagePackCodec{},
+	CBOR:        cborCodec{},
+	YAML:        yamlCodec{},
+}
+
+// aliases of the media types which are still common
+var aliases = map[string]string{
+	"application/x-msgpack":   MessagePack,
+	"application/vnd.msgpack": MessagePack,
+	"application/x-yaml":      YAML,
+	"text/yaml":               YAML,
+	"text/x-yaml":             YAML,
+}
+
+// Get returns the Codec of a media type
+func Get(mediaType string) (Codec, bool) {
+	c, ok := codecs[normalize(mediaType)]
+	return c, ok
+}
+
+// ForContentType returns the Codec of the Content-Type of a request, the Content-Type is required
+func ForContentType(contentType string) (Codec, error) {
+	if contentType == "" {
+		return nil, errors.Wrap(ErrUnsupported, "Content-Type is required")
+	}
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return nil, errors.Wrap(ErrUnsupported, err.Error())
+	}
+	c, ok := Get(mediaType)
+	if !ok {
+		return nil, errors.Wrapf(ErrUnsupported, "%s isn't one of %s, %s, %s or %s", mediaType, JSON,
+			MessagePack, CBOR, YAML)
+	}
+	return c, nil
+}
+
+// Negotiate returns the offered media type most preferred by the Accept header of a request, offers of the same
+// quality are preferred in order. The first offer is returned if there's no Accept header and false if no offer is
+// acceptable.
+func Negotiate(accept string, offers []string) (string, bool) {
+	if len(offers) == 0 {
+		return "", false
+	}
+	if strings.TrimSpace(accept) == "" {
+		return offers[0], true
+	}
+
+	ranges := parseAccept(accept)
+	best, bestQ := "", 0.0
+	for _, offer := range offers {
+		q, specificity := 0.0, -1
+		for _, r := range ranges {
+			if s := r.matches(offer); s > specificity {
+				q, specificity = r.q, s
+			}
+		}
+		if q > bestQ {
+			best, bestQ = offer, q
+		}
+	}
+	return best, bestQ > 0
+}
+
+// mediaRange of an Accept header
+type mediaRange struct {
+	mediaType string
+	q         float64
+}
+
+// matches returns how specific the range is for the media type, or -1 if it doesn't match
+func (r mediaRange) matches(mediaType string) int {
+	switch {
+	case r.mediaType == mediaType:
+		return 2
+	case r.mediaType == "*/*":
+		return 0
+	case strings.HasSuffix(r.mediaType, "/*") && strings.HasPrefix(mediaType, strings.TrimSuffix(r.mediaType, "*")):
+		return 1
+	}
+	return -1
+}
+
+func parseAccept(accept string) []mediaRange {
+	var ranges []mediaRange
+	for _, part := range strings.Split(accept, ",") {
+		part = strings.TrimSpace(part)
+		if part == "*" {
+			part = "*/*"
+		}
+		mediaType, params, err := mime.ParseMediaType(part)
+		if err != nil {
+			continue
+		}
+
+		q := 1.0
+		if qStr, ok := params["q"]; ok {
+			if q, err = strconv.ParseFloat(qStr, 64); err != nil || q < 0 || q > 1 {
+				continue
+			}
+		}
+		ranges = append(ranges, mediaRange{mediaType: normalize(mediaType), q: q})
+	}
+	return ranges
+}
+
+func normalize(mediaType string) string {
+	mediaType = strings.ToLower(mediaType)
+	if alias, ok := aliases[mediaType]; ok {
+		return alias
+	}
+	return mediaType
+}
+
+type jsonCodec struct{}
+
+func (jsonCodec) Marshal(v interface{}) ([]byte, error) {
+	return json.Marshal(v)
+}
+
+func (jsonCodec) Unmarshal(data []byte, v interface{}) error {
+	return json.Unmarshal(data, v)
+}
+
+func (jsonCodec) Decode(r io.Reader, v interface{}) error {
+	dec := json.NewDecoder(r)
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(v); err != nil {
+		return jsonDecodeError(dec, err)
+	}
+
+	// the offset is the end of the value, since the token after it is already read
+	offset := dec.InputOffset()
+	if _, err := dec.Token(); err != io.EOF {
+		if errors.Is(err, ErrTooLarge) {
+			return err
+		}
+		return &DecodeError{Offset: offset, Err: errMultipleValues}
+	}
+	return nil
+}
+
+// jsonDecodeError adds the field and offset to an error of a json.Decoder
+func jsonDecodeError(dec *json.Decoder, err error) error {
+	var syntaxErr *json.SyntaxError
+	var typeErr *json.UnmarshalTypeError
+	switch {
+	case errors.Is(err, ErrTooLarge):
+		return err
+	case err == io.EOF:
+		return &DecodeError{Err: errors.New("body must not be empty")}
+	case err == io.ErrUnexpectedEOF:
+		return &DecodeError{Err: errors.New("body ended unexpectedly")}
+	case errors.As(err, &syntaxErr):
+		return &DecodeError{Offset: syntaxErr.Offset, Err: errors.New(strings.TrimPrefix(err.Error(), "json: "))}
+	case errors.As(err, &typeErr):
+		return &DecodeError{Field: typeErr.Field, Offset: typeErr.Offset,
+			Err: errors.Errorf("must be %s, not %s", typeErr.Type, typeErr.Value)}
+	case strings.HasPrefix(err.Error(), "json: unknown field "):
+		field, _ := strconv.Unquote(strings.TrimPrefix(err.Error(), "json: unknown field "))
+		// the decoder only reports unknown fields once the object is read, so the offset wouldn't be of the field
+		return &DecodeError{Field: field, Err: errors.New("unknown field")}
+	}
+	return &DecodeError{Offset: dec.InputOffset(), Err: err}
+}
+
+type messagePackCodec struct{}
+
+func (messagePackCodec) Marshal(v interface{}) ([]byte, error) {
+	var buf bytes.Buffer
+	enc := msgpack.NewEncoder(&buf)
+	enc.SetCustomStructTag("json")
+	if err := enc.Encode(v); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func (messagePackCodec) Unmarshal(data []byte, v interface{}) error {
+	dec := msgpack.NewDecoder(bytes.NewReader(data))
+	dec.SetCustomStructTag("json")
+	return dec.Decode(v)
+}
+
+func (messagePackCodec) Decode(r io.Reader, v interface{}) error {
+	dec := msgpack.NewDecoder(r)
+	dec.SetCustomStructTag("json")
+	dec.DisallowUnknownFields(true)
+	if err := dec.Decode(v); err != nil {
+		return decodeError(err)
+	}
+	if _, err := dec.PeekCode(); err != io.EOF {
+		return decodeError(err)
+	}
+	return nil
+}
+
+// cborCodec uses the json tags since no cbor tags are set
+type cborCodec struct{}
+
+func (cborCodec) Marshal(v interface{}) ([]byte, error) {
+	return cborEncMode.Marshal(v)
+}
+
+func (cborCodec) Unmarshal(data []byte, v interface{}) error {
+	return cbor.Unmarshal(data, v)
+}
+
+func (cborCodec) Decode(r io.Reader, v interface{}) error {
+	dec := cborDecMode.NewDecoder(r)
+	if err := dec.Decode(v); err != nil {
+		return decodeError(err)
+	}
+	var extra cbor.RawMessage
+	if err := dec.Decode(&extra); err != io.EOF {
+		return decodeError(err)
+	}
+	return nil
+}
+
+// yamlCodec converts from and to JSON, so the fields are named and formatted like JSON and keep its order
+type yamlCodec struct{}
+
+func (yamlCodec) Marshal(v interface{}) ([]byte, error) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	doc, err := yamlValue(dec)
+	if err != nil {
+		return nil, err
+	}
+	return yaml.Marshal(doc)
+}
+
+func (yamlCodec) Unmarshal(data []byte, v interface{}) error {
+	var doc interface{}
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return err
+	}
+
+	data, err := json.Marshal(jsonValue(doc))
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, v)
+}
+
+// Decode checks the fields strictly as JSON, the offsets of JSON errors don't apply to the YAML so they're dropped
+func (yamlCodec) Decode(r io.Reader, v interface{}) error {
+	dec := yaml.NewDecoder(r)
+	var doc interface{}
+	if err := dec.Decode(&doc); err != nil {
+		return decodeError(err)
+	}
+	var extra interface{}
+	if err := dec.Decode(&extra); err != io.EOF {
+		return decodeError(err)
+	}
+
+	data, err := json.Marshal(jsonValue(doc))
+	if err != nil {
+		return &DecodeError{Err: err}
+	}
+	if err = (jsonCodec{}).Decode(bytes.NewReader(data), v); err != nil {
+		var decodeErr *DecodeError
+		if errors.As(err, &decodeErr) {
+			decodeErr.Offset = 0
+		}
+		return err
+	}
+	return nil
+}
+
+// decodeError wraps the error of a decoder without offsets, nil is a value after the value of the body
+func decodeError(err error) error {
+	switch {
+	case err == nil:
+		return &DecodeError{Err: errMultipleValues}
+	case errors.Is(err, ErrTooLarge):
+		return err
+	case err == io.EOF:
+		return &DecodeError{Err: errors.New("body must not be empty")}
+	}
+	return &DecodeError{Err: err}
+}
+
+// yamlValue reads the next JSON value as a YAML value, objects are read as a yaml.MapSlice to keep their order
+func yamlValue(dec *json.Decoder) (interface{}, error) {
+	token, err := dec.Token()
+	if err != nil {
+		return nil, err
+	}
+
+	switch t := token.(type) {
+	case json.Delim:
+		if t == '{' {
+			object := yaml.MapSlice{}
+			for dec.More() {
+				key, err := dec.Token()
+				if err != nil {
+					return nil, err
+				}
+				value, err := yamlValue(dec)
+				if err != nil {
+					return nil, err
+				}
+				object = append(object, yaml.MapItem{Key: key, Value: value})
+			}
+			_, err = dec.Token()
+			return object, err
+		}
+
+		array := []interface{}{}
+		for dec.More() {
+			value, err := yamlValue(dec)
+			if err != nil {
+				return nil, err
+			}
+			array = append(array, value)
+		}
+		_, err = dec.Token()
+		return array, err
+	case json.Number:
+		if i, err := t.Int64(); err == nil {
+			return i, nil
+		}
+		return t.Float64()
+	}
+	return token, nil
+}
+
+// jsonValue converts the maps of a YAML value to maps with string keys, which JSON requires
+func jsonValue(v interface{}) interface{} {
+	switch t := v.(type) {
+	case map[interface{}]interface{}:
+		object := make(map[string]interface{}, len(t))
+		for key, value := range t {
+			object[fmt.Sprint(key)] = jsonValue(value)
+		}
+		return object
+	case []interface{}:
+		for i := range t {
+			t[i] = jsonValue(t[i])
+		}
+	}
+	return v
+}
+
+// LimitReader reads from r until more than n bytes are read, then it fails with ErrTooLarge
+func LimitReader(r io.Reader, n int64) io.Reader {
+	return &limitReader{r: r, n: n}
+}
+
+type limitReader struct {
+	r io.Reader
+	n int64
+}
+
+func (l *limitReader) Read(p []byte) (int, error) {
+	// a byte past the limit is read to tell if there's more
+	if int64(len(p)) > l.n+1 {
+		p = p[:l.n+1]
+	}
+	n, err := l.r.Read(p)
+	if int64(n) > l.n {
+		n, l.n = int(l.n), 0
+		return n, ErrTooLarge
+	}
+	l.n -= int64(n)
+	return n, err
+}
@@ -0,0 +1,156 @@
+package codec
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/alexsniffin/go-api-starter/internal/todo-api/models"
+)
+
+func TestNegotiate(t *testing.T) {
+	offers := []string{JSON, MessagePack, YAML}
+	tests := []struct {
+		name   string
+		accept string
+		want   string
+		ok     bool
+	}{
+		{"noAccept", "", JSON, true},
+		{"any", "*/*", JSON, true},
+		{"exact", "application/msgpack", MessagePack, true},
+		{"alias", "application/x-yaml", YAML, true},
+		{"quality", "application/json;q=0.5, application/msgpack", MessagePack, true},
+		{"specificRange", "application/*;q=0.1, application/yaml;q=0.9", YAML, true},
+		{"excluded", "application/json;q=0, application/*", MessagePack, true},
+		{"browser", "text/html,application/xhtml+xml,*/*;q=0.8", JSON, true},
+		{"notAcceptable", "text/html, application/xml", "", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := Negotiate(tt.accept, offers)
+			if got != tt.want || ok != tt.ok {
+				t.Errorf("wrong media type: got %v, %v want %v, %v", got, ok, tt.want, tt.ok)
+			}
+		})
+	}
+}
+
+func TestCodecs(t *testing.T) {
+	list := "home"
+	todo := models.TodoItem{ID: 1, Todo: "test", List: list, Done: true,
+		CreatedOn: time.Date(2020, 1, 2, 3, 4, 5, 6, time.UTC)}
+
+	for _, mediaType := range []string{JSON, MessagePack, CBOR, YAML} {
+		t.Run(mediaType, func(t *testing.T) {
+			c, ok := Get(mediaType)
+			if !ok {
+				t.Fatalf("missing codec of %v", mediaType)
+			}
+			data, err := c.Marshal(models.TodoListResponse{Items: []models.TodoItem{todo}, NextCursor: "a"})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			var got models.TodoListResponse
+			if err = c.Unmarshal(data, &got); err != nil {
+				t.Fatal(err)
+			}
+			if len(got.Items) != 1 || got.NextCursor != "a" || got.Items[0].Todo != todo.Todo ||
+				got.Items[0].List != list || !got.Items[0].Done || !got.Items[0].CreatedOn.Equal(todo.CreatedOn) {
+				t.Errorf("wrong round trip: got %+v want %+v", got, todo)
+			}
+		})
+	}
+
+	t.Run("yamlKeyOrder", func(t *testing.T) {
+		c, _ := Get(YAML)
+		data, err := c.Marshal(models.TodoPostResponse{ID: 1})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(data) != "id: 1\n" {
+			t.Errorf("wrong yaml: got %q want %q", data, "id: 1\n")
+		}
+	})
+}
+
+func TestForContentType(t *testing.T) {
+	if _, err := ForContentType("application/json; charset=utf-8"); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+	if _, err := ForContentType(""); !errors.Is(err, ErrUnsupported) {
+		t.Errorf("wrong error: got %v want %v", err, ErrUnsupported)
+	}
+	if _, err := ForContentType("text/plain"); !errors.Is(err, ErrUnsupported) {
+		t.Errorf("wrong error: got %v want %v", err, ErrUnsupported)
+	}
+}
+
+func TestDecode(t *testing.T) {
+	tests := []struct {
+		name      string
+		mediaType string
+		body      string
+		want      string
+	}{
+		{"valid", JSON, `{"todo":"test"}`, ""},
+		{"empty", JSON, ``, "body must not be empty"},
+		{"unknownField", JSON, `{"todo":"test","owner":"x"}`, "field owner: unknown field"},
+		{"wrongType", JSON, `{"todo":1}`, "field todo: must be string, not number at offset 9"},
+		{"syntax", JSON, `{"todo":}`, "invalid character '}' looking for beginning of value at offset 9"},
+		{"truncated", JSON, `{"todo":"test"`, "body ended unexpectedly"},
+		{"multipleValues", JSON, `{"todo":"a"} {"todo":"b"}`, "body must only contain a single value at offset 12"},
+		{"yamlUnknownField", YAML, "todo: test\nowner: x\n", "field owner: unknown field"},
+		{"yamlMultipleDocuments", YAML, "todo: a\n---\ntodo: b\n", "body must only contain a single value"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, _ := Get(tt.mediaType)
+			var got models.TodoPostRequest
+			err := c.Decode(strings.NewReader(tt.body), &got)
+			if tt.want == "" {
+				if err != nil || got.Todo != "test" {
+					t.Errorf("unexpected result: got %+v, %v", got, err)
+				}
+				return
+			}
+			var decodeErr *DecodeError
+			if !errors.As(err, &decodeErr) || err.Error() != tt.want {
+				t.Errorf("wrong error: got %v want %v", err, tt.want)
+			}
+		})
+	}
+
+	t.Run("binaryUnknownField", func(t *testing.T) {
+		for _, mediaType := range []string{MessagePack, CBOR} {
+			c, _ := Get(mediaType)
+			data, err := c.Marshal(map[string]string{"todo": "test", "owner": "x"})
+			if err != nil {
+				t.Fatal(err)
+			}
+			var got models.TodoPostRequest
+			var decodeErr *DecodeError
+			if err = c.Decode(bytes.NewReader(data), &got); !errors.As(err, &decodeErr) {
+				t.Errorf("wrong error of %v: got %v want a DecodeError", mediaType, err)
+			}
+		}
+	})
+
+	t.Run("tooLarge", func(t *testing.T) {
+		c, _ := Get(JSON)
+		var got models.TodoPostRequest
+		err := c.Decode(LimitReader(strings.NewReader(`{"todo":"test"}`), 8), &got)
+		if !errors.Is(err, ErrTooLarge) {
+			t.Errorf("wrong error: got %v want %v", err, ErrTooLarge)
+		}
+		err = c.Decode(LimitReader(strings.NewReader(`{"todo":"test"}`), 15), &got)
+		if err != nil {
+			t.Errorf("unexpected error: %v", err)
+		}
+	})
+}
@@ -0,0 +1,160 @@
+package events
+
+import (
+	"context"
+	"sync"
+
+	"github.com/alexsniffin/go-api-starter/internal/todo-api/models"
+)
+
+// Hub fans out published TodoEvents to in-process subscribers and keeps a bounded log of recent events so
+// subscribers can resume from the last event they have seen. Publishing never blocks, a subscriber whose buffer is full
+// is disconnected and expected to resume from the log, so a slow consumer can't hold up the feed or other subscribers.
+type Hub struct {
+	cfg models.EventsConfig
+
+	mu     sync.Mutex
+	log    []models.TodoEvent
+	next   int
+	full   bool
+	subs   map[*Subscription]struct{}
+	closed bool
+}
+
+// Reset is sent to subscribers instead of a replay if events may have been missed and their state should be reloaded
+const Reset = "reset"
+
+// Filter selects the events delivered to a subscriber, it's called while publishing so it must not block
+type Filter func(event models.TodoEvent) bool
+
+// Subscription receives the events selected by its Filter from a Hub. The channel is closed if the subscriber falls
+// behind by more than the buffer size or the subscription is closed.
+type Subscription struct {
+	Events <-chan models.TodoEvent
+
+	hub    *Hub
+	filter Filter
+	events chan models.TodoEvent
+	once   sync.Once
+}
+
+// NewHub creates a new Hub
+func NewHub(cfg models.EventsConfig) *Hub {
+	return &Hub{
+		cfg:  cfg,
+		log:  make([]models.TodoEvent, cfg.LogSize),
+		subs: make(map[*Subscription]struct{}),
+	}
+}
+
+// ForUser selects the events of the todos of `userID`
+func ForUser(userID string) Filter {
+	return func(event models.TodoEvent) bool {
+		return event.UserID == userID
+	}
+}
+
+// ForList selects the events of the todos of `list`
+func ForList(list string) Filter {
+	return func(event models.TodoEvent) bool {
+		return event.List == list
+	}
+}
+
+// Publish appends the event to the log and delivers it to the subscribers selecting it
+func (h *Hub) Publish(_ context.Context, event models.TodoEvent) error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if len(h.log) > 0 {
+		h.log[h.next] = event
+		h.next = (h.next + 1) % len(h.log)
+		h.full = h.full || h.next == 0
+	}
+
+	for sub := range h.subs {
+		if !sub.filter(event) {
+			continue
+		}
+		select {
+		case sub.events <- event:
+		default:
+			// drop slow subscribers, they can reconnect and resume from the log
+			delete(h.subs, sub)
+			close(sub.events)
+		}
+	}
+
+	return nil
+}
+
+// Subscribe registers a subscriber for the events selected by `filter`. If `lastEventID` is set, the events after it
+// which are still in the log are returned to be replayed, `complete` is false if the log no longer reaches back to
+// `lastEventID` and events may have been missed.
+func (h *Hub) Subscribe(
+	filter Filter,
+	lastEventID int64,
+) (sub *Subscription, replay []models.TodoEvent, complete bool) {
+	events := make(chan models.TodoEvent, h.cfg.BufferSize)
+	sub = &Subscription{
+		Events: events,
+		hub:    h,
+		filter: filter,
+		events: events,
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if h.closed {
+		close(events)
+		return sub, nil, true
+	}
+
+	complete = true
+	if lastEventID > 0 {
+		logged := h.logged()
+		complete = len(logged) > 0 && logged[0].ID <= lastEventID
+		for i := 0; i < len(logged); i++ {
+			if logged[i].ID > lastEventID && filter(logged[i]) {
+				replay = append(replay, logged[i])
+			}
+		}
+	}
+
+	h.subs[sub] = struct{}{}
+	return sub, replay, complete
+}
+
+// Close closes all subscriptions to end their streams, subscribing afterwards returns closed subscriptions
+func (h *Hub) Close() {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.closed = true
+	for sub := range h.subs {
+		delete(h.subs, sub)
+		close(sub.events)
+	}
+}
+
+// Close unregisters the subscription
+func (s *Subscription) Close() {
+	s.once.Do(func() {
+		s.hub.mu.Lock()
+		defer s.hub.mu.Unlock()
+
+		if _, ok := s.hub.subs[s]; ok {
+			delete(s.hub.subs, s)
+			close(s.events)
+		}
+	})
+}
+
+// logged returns the events in the log from oldest to newest, must be called while holding the lock
+func (h *Hub) logged() []models.TodoEvent {
+	if !h.full {
+		return h.log[:h.next]
+	}
+	return append(append([]models.TodoEvent{}, h.log[h.next:]...), h.log[:h.next]...)
+}
@@ -0,0 +1,91 @@
+package events
+
+import (
+	"context"
+	"testing"
+
+	"github.com/alexsniffin/go-api-starter/internal/todo-api/models"
+)
+
+func publish(t *testing.T, hub *Hub, events ...models.TodoEvent) {
+	for i := 0; i < len(events); i++ {
+		if err := hub.Publish(context.Background(), events[i]); err != nil {
+			t.Errorf("unexpected error: %+v", err)
+			t.FailNow()
+		}
+	}
+}
+
+func TestHub(t *testing.T) {
+	t.Run("scopedToUser", func(t *testing.T) {
+		hub := NewHub(models.EventsConfig{LogSize: 10, BufferSize: 10})
+		sub, _, _ := hub.Subscribe(ForUser("alice"), 0)
+		defer sub.Close()
+
+		publish(t, hub, models.TodoEvent{ID: 1, UserID: "bob"}, models.TodoEvent{ID: 2, UserID: "alice"})
+
+		event := <-sub.Events
+		if event.ID != 2 {
+			t.Errorf("unexpected event: got %v want %v", event.ID, 2)
+		}
+		if len(sub.Events) != 0 {
+			t.Errorf("unexpected buffered events: %v", len(sub.Events))
+		}
+	})
+
+	t.Run("resumeFromLog", func(t *testing.T) {
+		hub := NewHub(models.EventsConfig{LogSize: 10, BufferSize: 10})
+		publish(t, hub, models.TodoEvent{ID: 1}, models.TodoEvent{ID: 2}, models.TodoEvent{ID: 3})
+
+		sub, replay, complete := hub.Subscribe(ForUser(""), 1)
+		defer sub.Close()
+
+		if !complete {
+			t.Error("unexpected incomplete replay")
+		}
+		if len(replay) != 2 || replay[0].ID != 2 || replay[1].ID != 3 {
+			t.Errorf("unexpected replay: %+v", replay)
+		}
+	})
+
+	t.Run("resumeEvicted", func(t *testing.T) {
+		hub := NewHub(models.EventsConfig{LogSize: 2, BufferSize: 10})
+		publish(t, hub, models.TodoEvent{ID: 1}, models.TodoEvent{ID: 2}, models.TodoEvent{ID: 3},
+			models.TodoEvent{ID: 4})
+
+		sub, replay, complete := hub.Subscribe(ForUser(""), 1)
+		defer sub.Close()
+
+		if complete {
+			t.Error("unexpected complete replay")
+		}
+		if len(replay) != 2 || replay[0].ID != 3 || replay[1].ID != 4 {
+			t.Errorf("unexpected replay: %+v", replay)
+		}
+	})
+
+	t.Run("slowSubscriberDropped", func(t *testing.T) {
+		hub := NewHub(models.EventsConfig{LogSize: 10, BufferSize: 1})
+		sub, _, _ := hub.Subscribe(ForUser(""), 0)
+		defer sub.Close()
+
+		publish(t, hub, models.TodoEvent{ID: 1}, models.TodoEvent{ID: 2})
+
+		<-sub.Events
+		if _, ok := <-sub.Events; ok {
+			t.Error("expected subscription to be closed")
+		}
+	})
+
+	t.Run("closeEndsSubscriptions", func(t *testing.T) {
+		hub := NewHub(models.EventsConfig{LogSize: 10, BufferSize: 1})
+		sub, _, _ := hub.Subscribe(ForUser(""), 0)
+
+		hub.Close()
+		sub.Close()
+
+		if _, ok := <-sub.Events; ok {
+			t.Error("expected subscription to be closed")
+		}
+	})
+}
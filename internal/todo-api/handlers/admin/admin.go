@@ -0,0 +1,122 @@
+package admin
+
+import (
+	"encoding/json"
+	"net/http"
+	"runtime"
+	"time"
+
+	"github.com/rs/zerolog"
+	"github.com/unrolled/render"
+
+	"github.com/alexsniffin/go-api-starter/internal/todo-api/models"
+	"github.com/alexsniffin/go-api-starter/internal/todo-api/utils"
+	"github.com/alexsniffin/go-api-starter/pkg/logger"
+	pkgModels "github.com/alexsniffin/go-api-starter/pkg/models"
+	"github.com/alexsniffin/go-api-starter/pkg/version"
+)
+
+type Handler struct {
+	cfg    pkgModels.Logger
+	logger zerolog.Logger
+
+	render *render.Render
+}
+
+// Creates admin handler
+func NewHandler(cfg pkgModels.Logger, logger zerolog.Logger, render *render.Render) Handler {
+	return Handler{
+		cfg:    cfg,
+		logger: logger,
+
+		render: render,
+	}
+}
+
+// Handle HTTP Get for the build details
+func (h *Handler) BuildInfo(w http.ResponseWriter, r *http.Request) {
+	h.writeResponse(w, http.StatusOK, models.BuildInfo{
+		Version:   version.Version,
+		Commit:    version.Commit,
+		BuildDate: version.BuildDate,
+		GoVersion: runtime.Version(),
+	})
+}
+
+// Handle HTTP Get for the log level
+func (h *Handler) GetLogLevel(w http.ResponseWriter, r *http.Request) {
+	level, revertOn := logger.Level()
+	h.writeResponse(w, http.StatusOK, logLevelResponse(level, revertOn))
+}
+
+// Handle HTTP Put for the log level of every logger, reverted to the configured level after the ttl
+func (h *Handler) SetLogLevel(w http.ResponseWriter, r *http.Request) {
+	var logLevelRequest models.LogLevelRequest
+	if err := json.NewDecoder(r.Body).Decode(&logLevelRequest); err != nil {
+		h.logger.Debug().Caller().Err(err).Msg("failed to decode log level body")
+		h.writeResponse(w, http.StatusBadRequest, models.Error{Message: "invalid body"})
+		return
+	}
+	if err := logLevelRequest.IsValid(); err != nil {
+		h.logger.Debug().Caller().Err(err).Msg("invalid log level")
+		h.writeResponse(w, http.StatusBadRequest, models.Error{Message: err.Error()})
+		return
+	}
+
+	ttl := time.Duration(h.cfg.LevelTTLSec) * time.Second
+	if logLevelRequest.TTLSec > 0 {
+		ttl = time.Duration(logLevelRequest.TTLSec) * time.Second
+	}
+
+	level, _ := zerolog.ParseLevel(logLevelRequest.Level)
+	previous, _ := logger.Level()
+	revertOn := logger.SetLevel(level, ttl)
+	h.logger.WithLevel(zerolog.NoLevel).Str("previous", previous.String()).Str("level", level.String()).
+		Time("revertOn", revertOn).Msg("log level changed")
+
+	h.writeResponse(w, http.StatusOK, logLevelResponse(level, revertOn))
+}
+
+// Handle HTTP Post for a debug token, signed with the configured key
+func (h *Handler) PostDebugToken(w http.ResponseWriter, r *http.Request) {
+	if h.cfg.DebugHeader == "" || h.cfg.DebugKey == "" {
+		h.writeResponse(w, http.StatusNotFound, models.Error{Message: "debug tokens aren't enabled"})
+		return
+	}
+
+	var debugTokenRequest models.DebugTokenRequest
+	if err := json.NewDecoder(r.Body).Decode(&debugTokenRequest); err != nil {
+		h.logger.Debug().Caller().Err(err).Msg("failed to decode debug token body")
+		h.writeResponse(w, http.StatusBadRequest, models.Error{Message: "invalid body"})
+		return
+	}
+	if err := debugTokenRequest.IsValid(); err != nil {
+		h.logger.Debug().Caller().Err(err).Msg("invalid debug token request")
+		h.writeResponse(w, http.StatusBadRequest, models.Error{Message: err.Error()})
+		return
+	}
+
+	expiresOn := time.Now().Add(time.Duration(debugTokenRequest.TTLSec) * time.Second).Truncate(time.Second)
+	h.logger.Info().Time("expiresOn", expiresOn).Msg("debug token created")
+
+	h.writeResponse(w, http.StatusOK, models.DebugTokenResponse{
+		Header:    h.cfg.DebugHeader,
+		Token:     utils.SignDebugToken([]byte(h.cfg.DebugKey), expiresOn),
+		ExpiresOn: expiresOn,
+	})
+}
+
+func (h *Handler) writeResponse(w http.ResponseWriter, statusCode int, response interface{}) {
+	if err := h.render.JSON(w, statusCode, response); err != nil {
+		h.logger.Error().Caller().Err(err).Msg("failed to marshal json response")
+		w.WriteHeader(http.StatusInternalServerError)
+	}
+}
+
+func logLevelResponse(level zerolog.Level, revertOn time.Time) models.LogLevelResponse {
+	response := models.LogLevelResponse{Level: level.String()}
+	if !revertOn.IsZero() {
+		response.RevertOn = &revertOn
+	}
+	return response
+}
@@ -0,0 +1,109 @@
+package admin
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/rs/zerolog"
+	"github.com/unrolled/render"
+
+	"github.com/alexsniffin/go-api-starter/internal/todo-api/models"
+	"github.com/alexsniffin/go-api-starter/internal/todo-api/utils"
+	"github.com/alexsniffin/go-api-starter/pkg/logger"
+	pkgModels "github.com/alexsniffin/go-api-starter/pkg/models"
+)
+
+func initAdminHandler(cfg pkgModels.Logger) Handler {
+	return NewHandler(cfg, zerolog.New(os.Stdout), render.New())
+}
+
+func TestAdminHandler(t *testing.T) {
+	t.Run("setLogLevel", func(t *testing.T) {
+		adminHandler := initAdminHandler(pkgModels.Logger{LevelTTLSec: 60})
+		defer logger.SetLevel(zerolog.InfoLevel, 0)
+
+		req := httptest.NewRequest(http.MethodPut, "/loglevel", strings.NewReader(`{"level":"warn"}`))
+		rr := httptest.NewRecorder()
+		http.HandlerFunc(adminHandler.SetLogLevel).ServeHTTP(rr, req)
+
+		if status := rr.Code; status != http.StatusOK {
+			t.Errorf("unexpected status code: got %v want %v", status, http.StatusOK)
+		}
+		level, revertOn := logger.Level()
+		if level != zerolog.WarnLevel {
+			t.Errorf("wrong level: got %v want %v", level, zerolog.WarnLevel)
+		}
+		if until := time.Until(revertOn); until <= 0 || until > time.Minute {
+			t.Errorf("wrong revert time: got %v want within %v", revertOn, time.Minute)
+		}
+	})
+
+	t.Run("setInvalidLogLevel", func(t *testing.T) {
+		adminHandler := initAdminHandler(pkgModels.Logger{LevelTTLSec: 60})
+		logger.SetLevel(zerolog.InfoLevel, 0)
+
+		req := httptest.NewRequest(http.MethodPut, "/loglevel", strings.NewReader(`{"level":"loud"}`))
+		rr := httptest.NewRecorder()
+		http.HandlerFunc(adminHandler.SetLogLevel).ServeHTTP(rr, req)
+
+		if status := rr.Code; status != http.StatusBadRequest {
+			t.Errorf("unexpected status code: got %v want %v", status, http.StatusBadRequest)
+		}
+		if level, _ := logger.Level(); level != zerolog.InfoLevel {
+			t.Errorf("wrong level: got %v want %v", level, zerolog.InfoLevel)
+		}
+	})
+
+	t.Run("getLogLevel", func(t *testing.T) {
+		adminHandler := initAdminHandler(pkgModels.Logger{})
+		logger.SetLevel(zerolog.ErrorLevel, 0)
+		defer logger.SetLevel(zerolog.InfoLevel, 0)
+
+		rr := httptest.NewRecorder()
+		http.HandlerFunc(adminHandler.GetLogLevel).ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/loglevel", nil))
+
+		if body := strings.TrimSpace(rr.Body.String()); body != `{"level":"error"}` {
+			t.Errorf("unexpected body: got %v want %v", body, `{"level":"error"}`)
+		}
+	})
+
+	t.Run("postDebugToken", func(t *testing.T) {
+		adminHandler := initAdminHandler(pkgModels.Logger{DebugHeader: "X-Debug-Log", DebugKey: "secret"})
+
+		req := httptest.NewRequest(http.MethodPost, "/debugtoken", strings.NewReader(`{"ttl_sec":60}`))
+		rr := httptest.NewRecorder()
+		http.HandlerFunc(adminHandler.PostDebugToken).ServeHTTP(rr, req)
+
+		if status := rr.Code; status != http.StatusOK {
+			t.Errorf("unexpected status code: got %v want %v", status, http.StatusOK)
+			t.FailNow()
+		}
+		var response models.DebugTokenResponse
+		if err := json.NewDecoder(rr.Body).Decode(&response); err != nil {
+			t.Fatal(err)
+		}
+		if !utils.VerifyDebugToken([]byte("secret"), response.Token, time.Now()) {
+			t.Errorf("invalid token: %v", response.Token)
+		}
+		if utils.VerifyDebugToken([]byte("secret"), response.Token, time.Now().Add(2*time.Minute)) {
+			t.Errorf("token valid after expiry: %v", response.Token)
+		}
+	})
+
+	t.Run("postDebugTokenDisabled", func(t *testing.T) {
+		adminHandler := initAdminHandler(pkgModels.Logger{DebugHeader: "X-Debug-Log"})
+
+		req := httptest.NewRequest(http.MethodPost, "/debugtoken", strings.NewReader(`{"ttl_sec":60}`))
+		rr := httptest.NewRecorder()
+		http.HandlerFunc(adminHandler.PostDebugToken).ServeHTTP(rr, req)
+
+		if status := rr.Code; status != http.StatusNotFound {
+			t.Errorf("unexpected status code: got %v want %v", status, http.StatusNotFound)
+		}
+	})
+}
@@ -0,0 +1,24 @@
+package auth
+
+import (
+	"net/http"
+
+	"github.com/alexsniffin/go-api-starter/internal/todo-api/utils"
+)
+
+// NewHandlerFunc identifies the caller from the `header` set by the authenticating proxy in front of the service.
+// Requests without the header are handled as anonymous. A caller already identified by a verified client certificate
+// can't be overridden by the header.
+func NewHandlerFunc(header string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if header == "" || utils.UserFromCtx(r.Context()) != "" {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			userID := r.Header.Get(header)
+			next.ServeHTTP(w, r.WithContext(utils.WithUser(r.Context(), userID)))
+		})
+	}
+}
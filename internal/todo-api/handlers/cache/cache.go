@@ -0,0 +1,43 @@
+package cache
+
+import (
+	"net/http"
+	"strings"
+)
+
+// Policy is the caching of the responses of a route
+type Policy struct {
+	// CacheControl is the Cache-Control header of the responses, not set if empty
+	CacheControl string
+	// Vary are the request headers which the responses depend on
+	Vary []string
+}
+
+// Policies of the routes
+var (
+	// NoStore responses are never stored, like responses to mutations
+	NoStore = Policy{CacheControl: "no-store"}
+)
+
+// Private responses of a user are only stored by the client, which has to revalidate them, the responses depend on the
+// user header and the negotiated media type
+func Private(userHeader string) Policy {
+	return Policy{CacheControl: "private, no-cache", Vary: []string{"Accept", userHeader}}
+}
+
+// NewHandlerFunc sets the headers of the policy on the responses, a handler can still override them
+func NewHandlerFunc(policy Policy) func(http.Handler) http.Handler {
+	vary := strings.Join(policy.Vary, ", ")
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if policy.CacheControl != "" {
+				w.Header().Set("Cache-Control", policy.CacheControl)
+			}
+			if vary != "" {
+				w.Header().Add("Vary", vary)
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
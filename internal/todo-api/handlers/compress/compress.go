@@ -0,0 +1,232 @@
+package compress
+
+import (
+	"io"
+	"io/ioutil"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/andybalholm/brotli"
+	"github.com/klauspost/compress/gzip"
+	"github.com/klauspost/compress/zstd"
+
+	"github.com/alexsniffin/go-api-starter/internal/todo-api/models"
+)
+
+// brotliLevel is faster than the default level, which is too slow to compress responses on the fly
+const brotliLevel = 5
+
+// encoder compresses a response, encoders are reset and reused
+type encoder interface {
+	io.WriteCloser
+	Reset(w io.Writer)
+	Flush() error
+}
+
+var encoders = map[string]*sync.Pool{
+	"br": {New: func() interface{} {
+		return brotli.NewWriterLevel(ioutil.Discard, brotliLevel)
+	}},
+	"zstd": {New: func() interface{} {
+		enc, _ := zstd.NewWriter(ioutil.Discard, zstd.WithEncoderConcurrency(1))
+		return enc
+	}},
+	"gzip": {New: func() interface{} {
+		return gzip.NewWriter(ioutil.Discard)
+	}},
+}
+
+// NewHandlerFunc compresses responses of the content types with the first encoding of the config accepted by the
+// client. Responses smaller than the min size are sent uncompressed, unless they're flushed before they end, which
+// streams do. Upgraded connections and HEAD requests are never compressed.
+func NewHandlerFunc(cfg models.CompressionConfig) func(http.Handler) http.Handler {
+	contentTypes := make(map[string]bool, len(cfg.ContentTypes))
+	for _, contentType := range cfg.ContentTypes {
+		contentTypes[strings.ToLower(contentType)] = true
+	}
+
+	return func(next http.Handler) http.Handler {
+		if len(cfg.Encodings) == 0 {
+			return next
+		}
+
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.Method == http.MethodHead || r.Header.Get("Upgrade") != "" {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			w.Header().Add("Vary", "Accept-Encoding")
+			encoding := Negotiate(r.Header.Get("Accept-Encoding"), cfg.Encodings)
+			if encoding == "" {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			cw := &responseWriter{
+				ResponseWriter: w,
+				encoding:       encoding,
+				minSize:        cfg.MinSizeBytes,
+				contentTypes:   contentTypes,
+			}
+			defer cw.close()
+			next.ServeHTTP(cw, r)
+		})
+	}
+}
+
+// Negotiate returns the encoding of the offers most preferred by the Accept-Encoding header of a request, offers of
+// the same quality are preferred in order. An empty string is returned if no offer is acceptable.
+func Negotiate(acceptEncoding string, offers []string) string {
+	qualities := make(map[string]float64)
+	for _, part := range strings.Split(acceptEncoding, ",") {
+		fields := strings.Split(part, ";")
+		coding := strings.ToLower(strings.TrimSpace(fields[0]))
+		if coding == "" {
+			continue
+		}
+
+		q := 1.0
+		for _, param := range fields[1:] {
+			param = strings.TrimSpace(param)
+			if strings.HasPrefix(param, "q=") {
+				var err error
+				if q, err = strconv.ParseFloat(strings.TrimPrefix(param, "q="), 64); err != nil {
+					q = 0
+				}
+			}
+		}
+		qualities[coding] = q
+	}
+
+	best, bestQ := "", 0.0
+	for _, offer := range offers {
+		q, ok := qualities[offer]
+		if !ok {
+			q = qualities["*"]
+		}
+		if q > bestQ {
+			best, bestQ = offer, q
+		}
+	}
+	return best
+}
+
+// responseWriter buffers the start of a response until it's known whether it's compressed
+type responseWriter struct {
+	http.ResponseWriter
+
+	encoding     string
+	minSize      int
+	contentTypes map[string]bool
+
+	status  int
+	buf     []byte
+	decided bool
+	enc     encoder
+}
+
+func (w *responseWriter) WriteHeader(status int) {
+	// informational responses precede the response
+	if status < http.StatusOK {
+		w.ResponseWriter.WriteHeader(status)
+		return
+	}
+	if w.decided || w.status != 0 {
+		return
+	}
+	w.status = status
+	// there's no body to compress
+	if status == http.StatusNoContent || status == http.StatusNotModified {
+		w.decide(false)
+	}
+}
+
+func (w *responseWriter) Write(p []byte) (int, error) {
+	if w.decided {
+		if w.enc != nil {
+			return w.enc.Write(p)
+		}
+		return w.ResponseWriter.Write(p)
+	}
+
+	w.buf = append(w.buf, p...)
+	if len(w.buf) >= w.minSize {
+		if err := w.decide(w.compressible()); err != nil {
+			return 0, err
+		}
+	}
+	return len(p), nil
+}
+
+// Flush compresses the response if it's compressible regardless of its size, since more is expected
+func (w *responseWriter) Flush() {
+	if !w.decided {
+		if err := w.decide(w.compressible()); err != nil {
+			return
+		}
+	}
+	if w.enc != nil {
+		if err := w.enc.Flush(); err != nil {
+			return
+		}
+	}
+	if flusher, ok := w.ResponseWriter.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
+
+// close writes the rest of the response, a response which is still buffered is too small to compress
+func (w *responseWriter) close() {
+	if !w.decided {
+		_ = w.decide(false)
+	}
+	if w.enc != nil {
+		_ = w.enc.Close()
+		w.enc.Reset(ioutil.Discard)
+		encoders[w.encoding].Put(w.enc)
+		w.enc = nil
+	}
+}
+
+// compressible checks the content type of the response, which is detected like net/http if it isn't set
+func (w *responseWriter) compressible() bool {
+	header := w.Header()
+	if header.Get("Content-Encoding") != "" {
+		return false
+	}
+	if header.Get("Content-Type") == "" {
+		header.Set("Content-Type", http.DetectContentType(w.buf))
+	}
+	mediaType, _, err := mime.ParseMediaType(header.Get("Content-Type"))
+	return err == nil && w.contentTypes[mediaType]
+}
+
+// decide writes the header and the buffered start of the response, compressed or not
+func (w *responseWriter) decide(compress bool) error {
+	w.decided = true
+	if compress {
+		w.Header().Set("Content-Encoding", w.encoding)
+		w.Header().Del("Content-Length")
+		w.enc = encoders[w.encoding].Get().(encoder)
+		w.enc.Reset(w.ResponseWriter)
+	}
+	if w.status != 0 {
+		w.ResponseWriter.WriteHeader(w.status)
+	}
+	if len(w.buf) == 0 {
+		return nil
+	}
+
+	buf := w.buf
+	w.buf = nil
+	if w.enc != nil {
+		_, err := w.enc.Write(buf)
+		return err
+	}
+	_, err := w.ResponseWriter.Write(buf)
+	return err
+}
@@ -0,0 +1,143 @@
+package compress
+
+import (
+	"bytes"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/andybalholm/brotli"
+	"github.com/klauspost/compress/gzip"
+	"github.com/klauspost/compress/zstd"
+
+	"github.com/alexsniffin/go-api-starter/internal/todo-api/models"
+)
+
+func TestNegotiate(t *testing.T) {
+	offers := []string{"br", "zstd", "gzip"}
+	tests := []struct {
+		acceptEncoding string
+		want           string
+	}{
+		{"", ""},
+		{"gzip, deflate", "gzip"},
+		{"gzip, br", "br"},
+		{"br;q=0.5, gzip", "gzip"},
+		{"*", "br"},
+		{"*, br;q=0", "zstd"},
+		{"identity", ""},
+	}
+
+	for _, test := range tests {
+		t.Run(test.acceptEncoding, func(t *testing.T) {
+			if got := Negotiate(test.acceptEncoding, offers); got != test.want {
+				t.Errorf("wrong encoding: got %v want %v", got, test.want)
+			}
+		})
+	}
+}
+
+func TestNewHandlerFunc(t *testing.T) {
+	cfg := models.CompressionConfig{
+		Encodings:    []string{"br", "zstd", "gzip"},
+		MinSizeBytes: 100,
+		ContentTypes: []string{"application/json"},
+	}
+	large := `{"todo":"` + strings.Repeat("a", 200) + `"}`
+
+	tests := []struct {
+		name           string
+		acceptEncoding string
+		contentType    string
+		body           string
+		flush          bool
+		wantEncoding   string
+	}{
+		{"largeBody", "gzip", "application/json", large, false, "gzip"},
+		{"brotliPreferred", "gzip, br", "application/json; charset=UTF-8", large, false, "br"},
+		{"zstd", "zstd", "application/json", large, false, "zstd"},
+		{"smallBody", "gzip", "application/json", `{}`, false, ""},
+		{"flushedSmallBody", "gzip", "application/json", `{}`, true, "gzip"},
+		{"otherContentType", "gzip", "image/png", large, false, ""},
+		{"unacceptedEncoding", "deflate", "application/json", large, false, ""},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			handler := NewHandlerFunc(cfg)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Content-Type", test.contentType)
+				w.WriteHeader(http.StatusCreated)
+				// the body is written in parts to buffer the start
+				_, _ = io.WriteString(w, test.body[:len(test.body)/2])
+				if test.flush {
+					w.(http.Flusher).Flush()
+				}
+				_, _ = io.WriteString(w, test.body[len(test.body)/2:])
+			}))
+
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			req.Header.Set("Accept-Encoding", test.acceptEncoding)
+			rr := httptest.NewRecorder()
+			handler.ServeHTTP(rr, req)
+
+			if rr.Code != http.StatusCreated {
+				t.Errorf("wrong status code: got %v want %v", rr.Code, http.StatusCreated)
+			}
+			if encoding := rr.Header().Get("Content-Encoding"); encoding != test.wantEncoding {
+				t.Errorf("wrong encoding: got %v want %v", encoding, test.wantEncoding)
+			}
+			if vary := rr.Header().Get("Vary"); vary != "Accept-Encoding" {
+				t.Errorf("wrong vary: got %v want %v", vary, "Accept-Encoding")
+			}
+			if body := decompress(t, test.wantEncoding, rr.Body); body != test.body {
+				t.Errorf("wrong body: got %v want %v", body, test.body)
+			}
+		})
+	}
+
+	t.Run("noContent", func(t *testing.T) {
+		handler := NewHandlerFunc(cfg)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusNoContent)
+		}))
+
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req.Header.Set("Accept-Encoding", "gzip")
+		rr := httptest.NewRecorder()
+		handler.ServeHTTP(rr, req)
+
+		if rr.Code != http.StatusNoContent || rr.Header().Get("Content-Encoding") != "" || rr.Body.Len() != 0 {
+			t.Errorf("wrong response: got %v, %v, %q", rr.Code, rr.Header(), rr.Body.String())
+		}
+	})
+}
+
+func decompress(t *testing.T, encoding string, body *bytes.Buffer) string {
+	var r io.Reader
+	var err error
+	switch encoding {
+	case "":
+		return body.String()
+	case "br":
+		r = brotli.NewReader(body)
+	case "zstd":
+		var dec *zstd.Decoder
+		dec, err = zstd.NewReader(body)
+		if err == nil {
+			defer dec.Close()
+		}
+		r = dec
+	case "gzip":
+		r, err = gzip.NewReader(body)
+	}
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	data, err := ioutil.ReadAll(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(data)
+}
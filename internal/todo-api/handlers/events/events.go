@@ -0,0 +1,108 @@
+package events
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/rs/zerolog"
+	"github.com/rs/zerolog/log"
+
+	"github.com/alexsniffin/go-api-starter/internal/todo-api/events"
+	"github.com/alexsniffin/go-api-starter/internal/todo-api/models"
+	"github.com/alexsniffin/go-api-starter/internal/todo-api/utils"
+)
+
+type Handler struct {
+	cfg    models.EventsConfig
+	logger zerolog.Logger
+
+	hub *events.Hub
+}
+
+// Creates Server-Sent Events handler
+func NewHandler(cfg models.EventsConfig, logger zerolog.Logger, hub *events.Hub) Handler {
+	return Handler{
+		cfg:    cfg,
+		logger: logger,
+
+		hub: hub,
+	}
+}
+
+// Handle HTTP Get streaming the TodoEvents of the caller as Server-Sent Events
+func (h *Handler) Stream(w http.ResponseWriter, r *http.Request) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		h.logger.Error().Caller().Msg("response writer does not support flushing")
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	var lastEventID int64
+	if lastEventIDStr := r.Header.Get("Last-Event-ID"); lastEventIDStr != "" {
+		var err error
+		lastEventID, err = strconv.ParseInt(lastEventIDStr, 10, 64)
+		if err != nil {
+			h.logger.Debug().Caller().Err(err).Msg("invalid Last-Event-ID, streaming without resume")
+			lastEventID = 0
+		}
+	}
+
+	logCtx := utils.GetSubLoggerCtx(h.logger, r.Context())
+
+	sub, replay, complete := h.hub.Subscribe(events.ForUser(utils.UserFromCtx(r.Context())), lastEventID)
+	defer sub.Close()
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.Header().Set("X-Accel-Buffering", "no")
+	w.WriteHeader(http.StatusOK)
+
+	fmt.Fprintf(w, "retry: %d\n\n", h.cfg.RetryMs)
+	if !complete {
+		fmt.Fprintf(w, "event: %s\ndata: {}\n\n", events.Reset)
+	}
+	for i := 0; i < len(replay); i++ {
+		if err := writeEvent(w, replay[i]); err != nil {
+			log.Ctx(logCtx).Error().Caller().Err(err).Msg("failed to write replayed event")
+			return
+		}
+	}
+	flusher.Flush()
+
+	heartbeat := time.NewTicker(time.Duration(h.cfg.HeartbeatSec) * time.Second)
+	defer heartbeat.Stop()
+
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case event, ok := <-sub.Events:
+			if !ok {
+				log.Ctx(logCtx).Debug().Caller().Msg("event subscriber fell behind, closing stream")
+				return
+			}
+			if err := writeEvent(w, event); err != nil {
+				log.Ctx(logCtx).Error().Caller().Err(err).Msg("failed to write event")
+				return
+			}
+		case <-heartbeat.C:
+			fmt.Fprint(w, ": heartbeat\n\n")
+		}
+		flusher.Flush()
+	}
+}
+
+func writeEvent(w http.ResponseWriter, event models.TodoEvent) error {
+	data, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+
+	_, err = fmt.Fprintf(w, "id: %d\nevent: %s\ndata: %s\n\n", event.ID, event.Type, data)
+	return err
+}
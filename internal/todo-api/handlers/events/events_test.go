@@ -0,0 +1,103 @@
+package events
+
+import (
+	"bufio"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/rs/zerolog"
+
+	"github.com/alexsniffin/go-api-starter/internal/todo-api/events"
+	"github.com/alexsniffin/go-api-starter/internal/todo-api/models"
+	"github.com/alexsniffin/go-api-starter/internal/todo-api/utils"
+)
+
+func initEventsHandler(t *testing.T, lastEventID string) (*events.Hub, *bufio.Reader) {
+	hub := events.NewHub(models.EventsConfig{LogSize: 10, BufferSize: 10})
+	eventsHandler := NewHandler(models.EventsConfig{HeartbeatSec: 1, RetryMs: 500}, zerolog.New(os.Stdout), hub)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		eventsHandler.Stream(w, r.WithContext(utils.WithUser(r.Context(), "alice")))
+	}))
+	t.Cleanup(server.Close)
+
+	for _, event := range []models.TodoEvent{{ID: 1, Type: "created", UserID: "alice"},
+		{ID: 2, Type: "created", UserID: "bob"}, {ID: 3, Type: "updated", UserID: "alice"}} {
+		if err := hub.Publish(context.Background(), event); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	t.Cleanup(cancel)
+	req, _ := http.NewRequestWithContext(ctx, http.MethodGet, server.URL, nil)
+	if lastEventID != "" {
+		req.Header.Set("Last-Event-ID", lastEventID)
+	}
+	res, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { res.Body.Close() })
+
+	if res.StatusCode != http.StatusOK {
+		t.Fatalf("unexpected status: got %v want %v", res.StatusCode, http.StatusOK)
+	}
+	if contentType := res.Header.Get("Content-Type"); contentType != "text/event-stream" {
+		t.Errorf("unexpected content type: got %v want %v", contentType, "text/event-stream")
+	}
+
+	return hub, bufio.NewReader(res.Body)
+}
+
+// readMessage reads the lines of the next message of the stream
+func readMessage(t *testing.T, reader *bufio.Reader) []string {
+	var lines []string
+	for {
+		line, err := reader.ReadString('\n')
+		if err != nil {
+			t.Fatal(err)
+		}
+		line = strings.TrimSuffix(line, "\n")
+		if line == "" {
+			return lines
+		}
+		lines = append(lines, line)
+	}
+}
+
+func TestEventsHandler(t *testing.T) {
+	t.Run("replayAfterLastEventID", func(t *testing.T) {
+		hub, reader := initEventsHandler(t, "1")
+
+		if msg := readMessage(t, reader); len(msg) != 1 || msg[0] != "retry: 500" {
+			t.Errorf("unexpected message: got %q want %q", msg, "retry: 500")
+		}
+		msg := readMessage(t, reader)
+		if len(msg) != 3 || msg[0] != "id: 3" || msg[1] != "event: updated" {
+			t.Errorf("unexpected replayed message: %q", msg)
+		}
+
+		if err := hub.Publish(context.Background(), models.TodoEvent{ID: 4, Type: "deleted", UserID: "alice"}); err != nil {
+			t.Fatal(err)
+		}
+		msg = readMessage(t, reader)
+		if len(msg) != 3 || msg[0] != "id: 4" || msg[1] != "event: deleted" {
+			t.Errorf("unexpected live message: %q", msg)
+		}
+	})
+
+	t.Run("heartbeatWithoutReplay", func(t *testing.T) {
+		_, reader := initEventsHandler(t, "not-an-id")
+
+		readMessage(t, reader)
+		if msg := readMessage(t, reader); len(msg) != 1 || msg[0] != ": heartbeat" {
+			t.Errorf("unexpected message: got %q want %q", msg, ": heartbeat")
+		}
+	})
+}
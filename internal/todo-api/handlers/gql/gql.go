@@ -0,0 +1,206 @@
+package gql
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/graphql-go/graphql"
+	"github.com/graphql-go/graphql/gqlerrors"
+	"github.com/graphql-go/graphql/language/ast"
+	"github.com/graphql-go/graphql/language/parser"
+	"github.com/rs/zerolog"
+	"github.com/rs/zerolog/log"
+	"github.com/unrolled/render"
+
+	"github.com/alexsniffin/go-api-starter/internal/todo-api/events"
+	"github.com/alexsniffin/go-api-starter/internal/todo-api/models"
+	"github.com/alexsniffin/go-api-starter/internal/todo-api/store/todo"
+	"github.com/alexsniffin/go-api-starter/internal/todo-api/utils"
+)
+
+type Handler struct {
+	cfg    models.GraphQLConfig
+	logger zerolog.Logger
+
+	render *render.Render
+	store  todo.TodoStore
+	hub    *events.Hub
+	schema graphql.Schema
+}
+
+// Creates GraphQL handler
+func NewHandler(
+	cfg models.GraphQLConfig,
+	logger zerolog.Logger,
+	render *render.Render,
+	store todo.Store,
+	hub *events.Hub,
+) (Handler, error) {
+	h := Handler{
+		cfg:    cfg,
+		logger: logger,
+
+		render: render,
+		store:  &store,
+		hub:    hub,
+	}
+
+	schema, err := newSchema(&h)
+	if err != nil {
+		return Handler{}, err
+	}
+	h.schema = schema
+
+	return h, nil
+}
+
+// Handle HTTP Post executing a GraphQL query or mutation
+func (h *Handler) Query(w http.ResponseWriter, r *http.Request) {
+	var request models.GraphQLRequest
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		h.logger.Debug().Caller().Err(err).Msg("failed to decode graphql body")
+		h.writeErrorResponse(r.Context(), w, http.StatusBadRequest, errors.New("invalid body"))
+		return
+	}
+
+	operation, err := h.prepare(request)
+	if err != nil {
+		h.writeErrorResponse(r.Context(), w, http.StatusBadRequest, err)
+		return
+	}
+	if operation.Operation == ast.OperationTypeSubscription {
+		h.writeErrorResponse(r.Context(), w, http.StatusBadRequest,
+			errors.New("subscriptions are served over HTTP Get as Server-Sent Events"))
+		return
+	}
+
+	logCtx := utils.GetSubLoggerCtx(h.logger, r.Context())
+
+	result := graphql.Do(graphql.Params{
+		Schema:         h.schema,
+		RequestString:  request.Query,
+		VariableValues: request.Variables,
+		OperationName:  request.OperationName,
+		Context:        withLoader(logCtx, newHistoryLoader(h.store)),
+	})
+
+	if err = h.render.JSON(w, http.StatusOK, result); err != nil {
+		log.Ctx(logCtx).Error().Caller().Err(err).Msg("failed to marshal json response")
+		w.WriteHeader(http.StatusInternalServerError)
+	}
+}
+
+// Handle HTTP Get streaming the results of a GraphQL subscription as Server-Sent Events
+func (h *Handler) Subscribe(w http.ResponseWriter, r *http.Request) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		h.logger.Error().Caller().Msg("response writer does not support flushing")
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	request := models.GraphQLRequest{
+		Query:         r.URL.Query().Get("query"),
+		OperationName: r.URL.Query().Get("operationName"),
+	}
+	if variables := r.URL.Query().Get("variables"); variables != "" {
+		if err := json.Unmarshal([]byte(variables), &request.Variables); err != nil {
+			h.writeErrorResponse(r.Context(), w, http.StatusBadRequest, errors.New("invalid variables"))
+			return
+		}
+	}
+
+	operation, err := h.prepare(request)
+	if err != nil {
+		h.writeErrorResponse(r.Context(), w, http.StatusBadRequest, err)
+		return
+	}
+	if operation.Operation != ast.OperationTypeSubscription {
+		h.writeErrorResponse(r.Context(), w, http.StatusBadRequest,
+			errors.New("queries and mutations are served over HTTP Post"))
+		return
+	}
+
+	ctx, cancel := context.WithCancel(utils.GetSubLoggerCtx(h.logger, r.Context()))
+	defer cancel()
+
+	results := graphql.Subscribe(graphql.Params{
+		Schema:         h.schema,
+		RequestString:  request.Query,
+		VariableValues: request.Variables,
+		OperationName:  request.OperationName,
+		Context:        withLoader(ctx, newHistoryLoader(h.store)),
+	})
+	// graphql-go blocks on delivering a result, drain whatever is in flight once the stream is done
+	defer func() {
+		go func() {
+			for range results {
+			}
+		}()
+	}()
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.Header().Set("X-Accel-Buffering", "no")
+	w.WriteHeader(http.StatusOK)
+	flusher.Flush()
+
+	heartbeat := time.NewTicker(time.Duration(h.cfg.HeartbeatSec) * time.Second)
+	defer heartbeat.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case result, ok := <-results:
+			if !ok {
+				fmt.Fprint(w, "event: complete\ndata: {}\n\n")
+				flusher.Flush()
+				return
+			}
+			data, err := json.Marshal(result)
+			if err != nil {
+				log.Ctx(ctx).Error().Caller().Err(err).Msg("failed to marshal subscription result")
+				return
+			}
+			fmt.Fprintf(w, "event: next\ndata: %s\n\n", data)
+		case <-heartbeat.C:
+			fmt.Fprint(w, ": heartbeat\n\n")
+		}
+		flusher.Flush()
+	}
+}
+
+// prepare parses the request and checks its operation against the depth and complexity limits
+func (h *Handler) prepare(request models.GraphQLRequest) (*ast.OperationDefinition, error) {
+	doc, err := parser.Parse(parser.ParseParams{Source: request.Query})
+	if err != nil {
+		return nil, err
+	}
+
+	operation, fragments, err := operationOf(doc, request.OperationName)
+	if err != nil {
+		return nil, err
+	}
+
+	l := limiter{fragments: fragments, variables: request.Variables}
+	if err = l.check(operation, h.cfg.MaxDepth, h.cfg.MaxComplexity); err != nil {
+		return nil, err
+	}
+
+	return operation, nil
+}
+
+func (h *Handler) writeErrorResponse(ctx context.Context, w http.ResponseWriter, statusCode int, err error) {
+	if rErr := h.render.JSON(w, statusCode, graphql.Result{
+		Errors: gqlerrors.FormatErrors(err),
+	}); rErr != nil {
+		log.Ctx(ctx).Error().Caller().Err(rErr).Msg("failed to marshal json response")
+		w.WriteHeader(http.StatusInternalServerError)
+	}
+}
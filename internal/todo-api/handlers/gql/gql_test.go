@@ -0,0 +1,202 @@
+package gql
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/mock"
+	"github.com/unrolled/render"
+
+	"github.com/alexsniffin/go-api-starter/internal/todo-api/events"
+	"github.com/alexsniffin/go-api-starter/internal/todo-api/models"
+	"github.com/alexsniffin/go-api-starter/mocks"
+)
+
+func initGqlHandler(t *testing.T) (*Handler, *mocks.TodoStore, *events.Hub) {
+	todoStoreMock := mocks.TodoStore{}
+	hub := events.NewHub(models.EventsConfig{LogSize: 10, BufferSize: 10})
+	gqlHandler := &Handler{
+		cfg: models.GraphQLConfig{
+			MaxDepth:      6,
+			MaxComplexity: 5000,
+			HeartbeatSec:  15,
+		},
+		logger: zerolog.New(os.Stdout),
+		render: render.New(),
+		store:  &todoStoreMock,
+		hub:    hub,
+	}
+
+	schema, err := newSchema(gqlHandler)
+	if err != nil {
+		t.Fatal(err)
+	}
+	gqlHandler.schema = schema
+
+	return gqlHandler, &todoStoreMock, hub
+}
+
+func TestQuery(t *testing.T) {
+	t.Run("batchesHistory", func(t *testing.T) {
+		gqlHandler, todoStoreMock, _ := initGqlHandler(t)
+		todoStoreMock.On("ListTodos", mock.Anything, mock.Anything).Return([]models.TodoItem{
+			{ID: 1, Todo: "milk"},
+			{ID: 2, Todo: "eggs"},
+		}, nil)
+		todoStoreMock.On("ListTodoEvents", mock.Anything, []int{1, 2}).Return([]models.TodoEvent{
+			{ID: 10, Type: models.TodoCreated, TodoID: 1},
+			{ID: 11, Type: models.TodoCreated, TodoID: 2},
+			{ID: 12, Type: models.TodoUpdated, TodoID: 2},
+		}, nil)
+
+		body := `{"query":"{ todos { items { id history { id type } } } }"}`
+		rr := httptest.NewRecorder()
+		gqlHandler.Query(rr, httptest.NewRequest(http.MethodPost, "/api/graphql", strings.NewReader(body)))
+
+		if rr.Code != http.StatusOK {
+			t.Errorf("handler returned wrong status code: got %v want %v", rr.Code, http.StatusOK)
+		}
+		expected := `{"data":{"todos":{"items":[{"history":[{"id":10,"type":"todo.created"}],"id":1},` +
+			`{"history":[{"id":11,"type":"todo.created"},{"id":12,"type":"todo.updated"}],"id":2}]}}}`
+		if strings.TrimSpace(rr.Body.String()) != expected {
+			t.Errorf("handler returned unexpected body: got %v want %v", rr.Body.String(), expected)
+		}
+		todoStoreMock.AssertNumberOfCalls(t, "ListTodoEvents", 1)
+	})
+
+	t.Run("depthLimit", func(t *testing.T) {
+		gqlHandler, todoStoreMock, _ := initGqlHandler(t)
+
+		body := `{"query":"{ todos { items { history { todo { history { todo { id } } } } } } }"}`
+		rr := httptest.NewRecorder()
+		gqlHandler.Query(rr, httptest.NewRequest(http.MethodPost, "/api/graphql", strings.NewReader(body)))
+
+		if rr.Code != http.StatusBadRequest {
+			t.Errorf("handler returned wrong status code: got %v want %v", rr.Code, http.StatusBadRequest)
+		}
+		todoStoreMock.AssertNotCalled(t, "ListTodos", mock.Anything, mock.Anything)
+	})
+
+	t.Run("complexityLimit", func(t *testing.T) {
+		gqlHandler, todoStoreMock, _ := initGqlHandler(t)
+
+		body := `{"query":"query($n: Int) { todos(first: $n) { items { id todo done history { id type } } } }",` +
+			`"variables":{"n":500}}`
+		gqlHandler.cfg.MaxComplexity = 1000
+		rr := httptest.NewRecorder()
+		gqlHandler.Query(rr, httptest.NewRequest(http.MethodPost, "/api/graphql", strings.NewReader(body)))
+
+		if rr.Code != http.StatusBadRequest {
+			t.Errorf("handler returned wrong status code: got %v want %v", rr.Code, http.StatusBadRequest)
+		}
+		todoStoreMock.AssertNotCalled(t, "ListTodos", mock.Anything, mock.Anything)
+	})
+
+	t.Run("doublingFragments", func(t *testing.T) {
+		gqlHandler, todoStoreMock, _ := initGqlHandler(t)
+
+		// every fragment spreads the next one twice, so the fragments expand to 2^28 fields
+		var query strings.Builder
+		query.WriteString("{ todos { items { ...F0 } } }")
+		for i := 0; i < 28; i++ {
+			fmt.Fprintf(&query, " fragment F%d on Todo { ...F%d ...F%d }", i, i+1, i+1)
+		}
+		query.WriteString(" fragment F28 on Todo { id }")
+		body, _ := json.Marshal(models.GraphQLRequest{Query: query.String()})
+
+		start := time.Now()
+		rr := httptest.NewRecorder()
+		gqlHandler.Query(rr, httptest.NewRequest(http.MethodPost, "/api/graphql", bytes.NewReader(body)))
+
+		if elapsed := time.Since(start); elapsed > 100*time.Millisecond {
+			t.Errorf("handler took too long to reject the query: %v", elapsed)
+		}
+		if rr.Code != http.StatusBadRequest || !strings.Contains(rr.Body.String(), "complexity") {
+			t.Errorf("handler returned wrong response: got %v %v want %v", rr.Code, rr.Body.String(),
+				http.StatusBadRequest)
+		}
+		todoStoreMock.AssertNotCalled(t, "ListTodos", mock.Anything, mock.Anything)
+	})
+
+	t.Run("fragmentSpreadLimit", func(t *testing.T) {
+		gqlHandler, todoStoreMock, _ := initGqlHandler(t)
+
+		query := "{ todos { items {" + strings.Repeat(" ...F", maxFragmentSpreads+1) + " } } } fragment F on Todo { id }"
+		body, _ := json.Marshal(models.GraphQLRequest{Query: query})
+		rr := httptest.NewRecorder()
+		gqlHandler.Query(rr, httptest.NewRequest(http.MethodPost, "/api/graphql", bytes.NewReader(body)))
+
+		if rr.Code != http.StatusBadRequest || !strings.Contains(rr.Body.String(), "fragment spreads") {
+			t.Errorf("handler returned wrong response: got %v %v want %v", rr.Code, rr.Body.String(),
+				http.StatusBadRequest)
+		}
+		todoStoreMock.AssertNotCalled(t, "ListTodos", mock.Anything, mock.Anything)
+	})
+}
+
+func TestSubscribe(t *testing.T) {
+	t.Run("streamsListEvents", func(t *testing.T) {
+		gqlHandler, _, hub := initGqlHandler(t)
+		server := httptest.NewServer(http.HandlerFunc(gqlHandler.Subscribe))
+		t.Cleanup(server.Close)
+
+		query := url.QueryEscape(`subscription { todoEvents(list: "chores") { id todoId } }`)
+		resp, err := http.Get(server.URL + "?query=" + query)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer resp.Body.Close()
+		if resp.Header.Get("Content-Type") != "text/event-stream" {
+			t.Fatalf("handler returned wrong content type: got %v", resp.Header.Get("Content-Type"))
+		}
+
+		// the subscription is registered asynchronously, so publish until the event is received
+		done := make(chan struct{})
+		defer close(done)
+		go func() {
+			for {
+				_ = hub.Publish(context.Background(), models.TodoEvent{ID: 1, TodoID: 7, List: "groceries"})
+				_ = hub.Publish(context.Background(), models.TodoEvent{ID: 2, TodoID: 8, List: "chores"})
+				select {
+				case <-done:
+					return
+				case <-time.After(10 * time.Millisecond):
+				}
+			}
+		}()
+
+		scanner := bufio.NewScanner(resp.Body)
+		for scanner.Scan() {
+			if !strings.HasPrefix(scanner.Text(), "data: ") {
+				continue
+			}
+
+			var result struct {
+				Data struct {
+					TodoEvents struct {
+						TodoID int `json:"todoId"`
+					} `json:"todoEvents"`
+				} `json:"data"`
+			}
+			if err = json.Unmarshal([]byte(strings.TrimPrefix(scanner.Text(), "data: ")), &result); err != nil {
+				t.Fatal(err)
+			}
+			if result.Data.TodoEvents.TodoID != 8 {
+				t.Errorf("subscription returned wrong todo: got %v want %v", result.Data.TodoEvents.TodoID, 8)
+			}
+			return
+		}
+		t.Errorf("stream ended without an event: %v", scanner.Err())
+	})
+}
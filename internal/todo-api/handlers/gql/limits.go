@@ -0,0 +1,213 @@
+package gql
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/graphql-go/graphql/language/ast"
+)
+
+// paginatedFields are the list fields costed at their default page size when `first` isn't given
+var paginatedFields = map[string]struct{}{
+	"todos": {},
+}
+
+// Limits of the fragments of a document, so a document can't be made expensive to measure or validate
+const (
+	maxFragmentDefinitions = 100
+	maxFragmentSpreads     = 500
+)
+
+// limiter measures the depth and complexity of an operation before anything is resolved. Complexity is the number of
+// selected fields, the selections of a paginated field are multiplied by its page size.
+type limiter struct {
+	fragments map[string]*ast.FragmentDefinition
+	variables map[string]interface{}
+
+	maxComplexity int
+	// measured are the depth and complexity of each fragment, so a fragment is measured once however often it's spread
+	measured map[string]measurement
+}
+
+type measurement struct {
+	depth      int
+	complexity int
+}
+
+// operationOf finds the operation to execute in the document
+func operationOf(
+	doc *ast.Document,
+	operationName string,
+) (*ast.OperationDefinition, map[string]*ast.FragmentDefinition, error) {
+	var operation *ast.OperationDefinition
+	fragments := make(map[string]*ast.FragmentDefinition)
+	spreads := 0
+	for _, definition := range doc.Definitions {
+		switch d := definition.(type) {
+		case *ast.OperationDefinition:
+			spreads += countSpreads(d.SelectionSet)
+			if operationName == "" && operation != nil {
+				return nil, nil, fmt.Errorf("operationName is required for documents with multiple operations")
+			}
+			if operationName == "" || (d.Name != nil && d.Name.Value == operationName) {
+				operation = d
+			}
+		case *ast.FragmentDefinition:
+			spreads += countSpreads(d.SelectionSet)
+			fragments[d.Name.Value] = d
+		}
+	}
+	if operation == nil {
+		return nil, nil, fmt.Errorf("unknown operation %q", operationName)
+	}
+	if len(fragments) > maxFragmentDefinitions {
+		return nil, nil, fmt.Errorf("query has more than %d fragments", maxFragmentDefinitions)
+	}
+	if spreads > maxFragmentSpreads {
+		return nil, nil, fmt.Errorf("query has more than %d fragment spreads", maxFragmentSpreads)
+	}
+
+	return operation, fragments, nil
+}
+
+// check returns an error if the operation is deeper than maxDepth or more complex than maxComplexity
+func (l *limiter) check(operation *ast.OperationDefinition, maxDepth, maxComplexity int) error {
+	l.maxComplexity = maxComplexity
+	l.measured = make(map[string]measurement, len(l.fragments))
+	depth, complexity := l.measure(operation.SelectionSet, make(map[string]struct{}))
+	if depth > maxDepth {
+		return fmt.Errorf("query depth %d exceeds the limit of %d", depth, maxDepth)
+	}
+	if complexity > maxComplexity {
+		return fmt.Errorf("query complexity %d exceeds the limit of %d", complexity, maxComplexity)
+	}
+	return nil
+}
+
+// measure the depth and complexity of the selections. The complexity saturates just above the limit and measuring
+// stops once it's reached, the depth is only complete if the complexity is within the limit.
+func (l *limiter) measure(set *ast.SelectionSet, visiting map[string]struct{}) (depth, complexity int) {
+	if set == nil {
+		return 0, 0
+	}
+
+	for _, selection := range set.Selections {
+		if complexity > l.maxComplexity {
+			break
+		}
+
+		switch s := selection.(type) {
+		case *ast.Field:
+			childDepth, childComplexity := l.measure(s.SelectionSet, visiting)
+			depth = max(depth, childDepth+1)
+			complexity = l.add(complexity, l.add(1, l.multiply(l.pageSize(s), childComplexity)))
+		case *ast.InlineFragment:
+			childDepth, childComplexity := l.measure(s.SelectionSet, visiting)
+			depth = max(depth, childDepth)
+			complexity = l.add(complexity, childComplexity)
+		case *ast.FragmentSpread:
+			m, ok := l.measureFragment(s.Name.Value, visiting)
+			if !ok {
+				continue
+			}
+			depth = max(depth, m.depth)
+			complexity = l.add(complexity, m.complexity)
+		}
+	}
+
+	return depth, complexity
+}
+
+// measureFragment measures the fragment once, returns false for unknown or cyclic spreads, which are rejected by
+// validation and only skipped here
+func (l *limiter) measureFragment(name string, visiting map[string]struct{}) (measurement, bool) {
+	if m, ok := l.measured[name]; ok {
+		return m, true
+	}
+	fragment, ok := l.fragments[name]
+	if _, cyclic := visiting[name]; !ok || cyclic {
+		return measurement{}, false
+	}
+
+	visiting[name] = struct{}{}
+	depth, complexity := l.measure(fragment.SelectionSet, visiting)
+	delete(visiting, name)
+
+	m := measurement{depth: depth, complexity: complexity}
+	l.measured[name] = m
+	return m, true
+}
+
+// add complexities, saturating just above the limit
+func (l *limiter) add(a, b int) int {
+	if a+b > l.maxComplexity {
+		return l.maxComplexity + 1
+	}
+	return a + b
+}
+
+// multiply complexities, saturating just above the limit
+func (l *limiter) multiply(a, b int) int {
+	if a != 0 && b > l.maxComplexity/a {
+		return l.maxComplexity + 1
+	}
+	return a * b
+}
+
+// countSpreads counts the fragment spreads of the selections, nested ones included
+func countSpreads(set *ast.SelectionSet) int {
+	if set == nil {
+		return 0
+	}
+
+	count := 0
+	for _, selection := range set.Selections {
+		switch s := selection.(type) {
+		case *ast.Field:
+			count += countSpreads(s.SelectionSet)
+		case *ast.InlineFragment:
+			count += countSpreads(s.SelectionSet)
+		case *ast.FragmentSpread:
+			count++
+		}
+	}
+	return count
+}
+
+// pageSize of the field from its `first` argument, given as a literal or a variable
+func (l *limiter) pageSize(field *ast.Field) int {
+	for _, argument := range field.Arguments {
+		if argument.Name.Value != "first" {
+			continue
+		}
+		switch v := argument.Value.(type) {
+		case *ast.IntValue:
+			if first, err := strconv.Atoi(v.Value); err == nil && first > 0 {
+				return first
+			}
+		case *ast.Variable:
+			switch first := l.variables[v.Name.Value].(type) {
+			case float64:
+				if first > 0 {
+					return int(first)
+				}
+			case int:
+				if first > 0 {
+					return first
+				}
+			}
+		}
+	}
+
+	if _, ok := paginatedFields[field.Name.Value]; ok {
+		return defaultPageSize
+	}
+	return 1
+}
+
+func max(a, b int) int {
+	if a > b {
+		return a
+	}
+	return b
+}
@@ -0,0 +1,75 @@
+package gql
+
+import (
+	"context"
+	"sync"
+
+	"github.com/rs/zerolog/log"
+
+	"github.com/alexsniffin/go-api-starter/internal/todo-api/models"
+	"github.com/alexsniffin/go-api-starter/internal/todo-api/store/todo"
+)
+
+type loaderCtxKey struct{}
+
+// historyLoader batches the history lookups of a request. Resolvers queue their todo and return a thunk, graphql-go
+// calls the thunks once the whole level is resolved, so the first thunk loads every queued todo in a single query.
+type historyLoader struct {
+	store todo.TodoStore
+
+	mu      sync.Mutex
+	pending []int
+	loaded  map[int][]models.TodoEvent
+}
+
+func newHistoryLoader(store todo.TodoStore) *historyLoader {
+	return &historyLoader{
+		store:  store,
+		loaded: make(map[int][]models.TodoEvent),
+	}
+}
+
+func withLoader(ctx context.Context, loader *historyLoader) context.Context {
+	return context.WithValue(ctx, loaderCtxKey{}, loader)
+}
+
+func loaderFromCtx(ctx context.Context) *historyLoader {
+	return ctx.Value(loaderCtxKey{}).(*historyLoader)
+}
+
+// load queues the todo and returns a thunk resolving its history
+func (l *historyLoader) load(ctx context.Context, todoID int) func() (interface{}, error) {
+	l.mu.Lock()
+	if _, ok := l.loaded[todoID]; !ok {
+		l.pending = append(l.pending, todoID)
+	}
+	l.mu.Unlock()
+
+	return func() (interface{}, error) {
+		l.mu.Lock()
+		defer l.mu.Unlock()
+
+		if len(l.pending) > 0 {
+			todoIDs := l.pending
+			l.pending = nil
+
+			results, err := l.store.ListTodoEvents(ctx, todoIDs)
+			if err != nil {
+				log.Ctx(ctx).Error().Caller().Err(err).Msg("failed to load todo history")
+				return nil, errInternal
+			}
+			for i := 0; i < len(todoIDs); i++ {
+				l.loaded[todoIDs[i]] = []models.TodoEvent{}
+			}
+			for i := 0; i < len(results); i++ {
+				l.loaded[results[i].TodoID] = append(l.loaded[results[i].TodoID], results[i])
+			}
+		}
+
+		history, ok := l.loaded[todoID]
+		if !ok {
+			return nil, errInternal
+		}
+		return history, nil
+	}
+}
@@ -0,0 +1,344 @@
+package gql
+
+import (
+	"encoding/json"
+	"errors"
+
+	validation "github.com/go-ozzo/ozzo-validation/v4"
+	"github.com/graphql-go/graphql"
+	"github.com/rs/zerolog/log"
+
+	"github.com/alexsniffin/go-api-starter/internal/todo-api/events"
+	"github.com/alexsniffin/go-api-starter/internal/todo-api/models"
+	"github.com/alexsniffin/go-api-starter/internal/todo-api/utils"
+)
+
+const (
+	defaultPageSize = 50
+	maxPageSize     = 500
+)
+
+var errInternal = errors.New("internal server error with request")
+
+// newSchema builds the schema over the TodoStore and event hub of the handler
+func newSchema(h *Handler) (graphql.Schema, error) {
+	eventType := graphql.NewObject(graphql.ObjectConfig{
+		Name: "TodoEvent",
+		Fields: graphql.Fields{
+			"id":     eventField(graphql.NewNonNull(graphql.Int), func(e models.TodoEvent) interface{} { return e.ID }),
+			"type":   eventField(graphql.NewNonNull(graphql.String), func(e models.TodoEvent) interface{} { return e.Type }),
+			"todoId": eventField(graphql.NewNonNull(graphql.Int), func(e models.TodoEvent) interface{} { return e.TodoID }),
+			"list":   eventField(graphql.String, func(e models.TodoEvent) interface{} { return e.List }),
+			"createdOn": eventField(graphql.NewNonNull(graphql.DateTime),
+				func(e models.TodoEvent) interface{} { return e.CreatedOn }),
+		},
+	})
+
+	todoType := graphql.NewObject(graphql.ObjectConfig{
+		Name: "Todo",
+		Fields: graphql.Fields{
+			"id":     todoField(graphql.NewNonNull(graphql.Int), func(t models.TodoItem) interface{} { return t.ID }),
+			"todo":   todoField(graphql.NewNonNull(graphql.String), func(t models.TodoItem) interface{} { return t.Todo }),
+			"list":   todoField(graphql.String, func(t models.TodoItem) interface{} { return t.List }),
+			"userId": todoField(graphql.String, func(t models.TodoItem) interface{} { return t.UserID }),
+			"done":   todoField(graphql.NewNonNull(graphql.Boolean), func(t models.TodoItem) interface{} { return t.Done }),
+			"createdOn": todoField(graphql.NewNonNull(graphql.DateTime),
+				func(t models.TodoItem) interface{} { return t.CreatedOn }),
+			"updatedOn": todoField(graphql.NewNonNull(graphql.DateTime),
+				func(t models.TodoItem) interface{} { return t.UpdatedOn }),
+			"history": &graphql.Field{
+				Type:        graphql.NewNonNull(graphql.NewList(graphql.NewNonNull(eventType))),
+				Description: "Domain events of the todo, oldest first",
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					todoItem, _ := p.Source.(models.TodoItem)
+					return loaderFromCtx(p.Context).load(p.Context, todoItem.ID), nil
+				},
+			},
+		},
+	})
+
+	// the todo of an event is decoded from its payload, deleted todos are still returned as they were
+	eventType.AddFieldConfig("todo", &graphql.Field{
+		Type: todoType,
+		Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+			event, _ := p.Source.(models.TodoEvent)
+			if len(event.Payload) == 0 {
+				return nil, nil
+			}
+
+			var todoItem models.TodoItem
+			if err := json.Unmarshal(event.Payload, &todoItem); err != nil {
+				log.Ctx(p.Context).Error().Caller().Err(err).Int64("eventID", event.ID).Msg("failed to decode event")
+				return nil, errInternal
+			}
+			return todoItem, nil
+		},
+	})
+
+	connectionType := graphql.NewObject(graphql.ObjectConfig{
+		Name: "TodoConnection",
+		Fields: graphql.Fields{
+			"items":      &graphql.Field{Type: graphql.NewNonNull(graphql.NewList(graphql.NewNonNull(todoType)))},
+			"nextCursor": &graphql.Field{Type: graphql.String},
+		},
+	})
+
+	query := graphql.NewObject(graphql.ObjectConfig{
+		Name: "Query",
+		Fields: graphql.Fields{
+			"todo": &graphql.Field{
+				Type: todoType,
+				Args: graphql.FieldConfigArgument{
+					"id": &graphql.ArgumentConfig{Type: graphql.NewNonNull(graphql.Int)},
+				},
+				Resolve: h.resolveTodo,
+			},
+			"todos": &graphql.Field{
+				Type:        graphql.NewNonNull(connectionType),
+				Description: "Page of the todos of a list, or of the caller if no list is given",
+				Args: graphql.FieldConfigArgument{
+					"list":  &graphql.ArgumentConfig{Type: graphql.String},
+					"done":  &graphql.ArgumentConfig{Type: graphql.Boolean},
+					"first": &graphql.ArgumentConfig{Type: graphql.Int},
+					"after": &graphql.ArgumentConfig{Type: graphql.String},
+				},
+				Resolve: h.resolveTodos,
+			},
+		},
+	})
+
+	mutation := graphql.NewObject(graphql.ObjectConfig{
+		Name: "Mutation",
+		Fields: graphql.Fields{
+			"createTodo": &graphql.Field{
+				Type: graphql.NewNonNull(todoType),
+				Args: graphql.FieldConfigArgument{
+					"todo": &graphql.ArgumentConfig{Type: graphql.NewNonNull(graphql.String)},
+					"list": &graphql.ArgumentConfig{Type: graphql.String},
+				},
+				Resolve: h.resolveCreateTodo,
+			},
+			"updateTodo": &graphql.Field{
+				Type: todoType,
+				Args: graphql.FieldConfigArgument{
+					"id":   &graphql.ArgumentConfig{Type: graphql.NewNonNull(graphql.Int)},
+					"todo": &graphql.ArgumentConfig{Type: graphql.NewNonNull(graphql.String)},
+					"list": &graphql.ArgumentConfig{Type: graphql.String},
+					"done": &graphql.ArgumentConfig{Type: graphql.NewNonNull(graphql.Boolean)},
+				},
+				Resolve: h.resolveUpdateTodo,
+			},
+			"deleteTodo": &graphql.Field{
+				Type: graphql.NewNonNull(graphql.Boolean),
+				Args: graphql.FieldConfigArgument{
+					"id": &graphql.ArgumentConfig{Type: graphql.NewNonNull(graphql.Int)},
+				},
+				Resolve: h.resolveDeleteTodo,
+			},
+		},
+	})
+
+	subscription := graphql.NewObject(graphql.ObjectConfig{
+		Name: "Subscription",
+		Fields: graphql.Fields{
+			"todoEvents": &graphql.Field{
+				Type:        graphql.NewNonNull(eventType),
+				Description: "Live TodoEvents of a list, or of the caller if no list is given",
+				Args: graphql.FieldConfigArgument{
+					"list": &graphql.ArgumentConfig{Type: graphql.String},
+				},
+				Subscribe: h.subscribeTodoEvents,
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					return p.Source, nil
+				},
+			},
+		},
+	})
+
+	return graphql.NewSchema(graphql.SchemaConfig{
+		Query:        query,
+		Mutation:     mutation,
+		Subscription: subscription,
+	})
+}
+
+func (h *Handler) resolveTodo(p graphql.ResolveParams) (interface{}, error) {
+	id, _ := p.Args["id"].(int)
+	if err := validateID(id); err != nil {
+		return nil, err
+	}
+
+	todoResult, found, err := h.store.GetTodo(p.Context, id)
+	if err != nil {
+		log.Ctx(p.Context).Error().Caller().Err(err).Msg("failed to get todoItem")
+		return nil, errInternal
+	}
+	if !found {
+		return nil, nil
+	}
+
+	return todoResult, nil
+}
+
+func (h *Handler) resolveTodos(p graphql.ResolveParams) (interface{}, error) {
+	pageSize := defaultPageSize
+	if first, ok := p.Args["first"].(int); ok {
+		if err := validation.Validate(first, validation.Min(1), validation.Max(maxPageSize)); err != nil {
+			return nil, errors.New("first: " + err.Error())
+		}
+		pageSize = first
+	}
+
+	after, _ := p.Args["after"].(string)
+	afterID, err := utils.DecodePageToken(after)
+	if err != nil {
+		return nil, errors.New("after: invalid cursor")
+	}
+
+	filter := models.TodoFilter{
+		UserID:  utils.UserFromCtx(p.Context),
+		AfterID: afterID,
+		Limit:   pageSize,
+	}
+	filter.List, _ = p.Args["list"].(string)
+	if done, ok := p.Args["done"].(bool); ok {
+		filter.Done = &done
+	}
+
+	todos, err := h.store.ListTodos(p.Context, filter)
+	if err != nil {
+		log.Ctx(p.Context).Error().Caller().Err(err).Msg("failed to list todos")
+		return nil, errInternal
+	}
+
+	if todos == nil {
+		todos = []models.TodoItem{}
+	}
+	connection := map[string]interface{}{
+		"items": todos,
+	}
+	if len(todos) == pageSize {
+		connection["nextCursor"] = utils.EncodePageToken(todos[len(todos)-1].ID)
+	}
+
+	return connection, nil
+}
+
+func (h *Handler) resolveCreateTodo(p graphql.ResolveParams) (interface{}, error) {
+	todoRequest := models.TodoPostRequest{}
+	todoRequest.Todo, _ = p.Args["todo"].(string)
+	todoRequest.List, _ = p.Args["list"].(string)
+	if err := todoRequest.IsValid(); err != nil {
+		return nil, err
+	}
+
+	todoItem := todoRequest.TodoItem(utils.UserFromCtx(p.Context))
+	id, err := h.store.PostTodo(p.Context, todoItem)
+	if err != nil {
+		log.Ctx(p.Context).Error().Caller().Err(err).Msg("failed to insert todo record")
+		return nil, errInternal
+	}
+	todoItem.ID = id
+
+	return todoItem, nil
+}
+
+func (h *Handler) resolveUpdateTodo(p graphql.ResolveParams) (interface{}, error) {
+	id, _ := p.Args["id"].(int)
+	if err := validateID(id); err != nil {
+		return nil, err
+	}
+	todoRequest := models.TodoPutRequest{}
+	todoRequest.Todo, _ = p.Args["todo"].(string)
+	todoRequest.List, _ = p.Args["list"].(string)
+	todoRequest.Done, _ = p.Args["done"].(bool)
+	if err := todoRequest.IsValid(); err != nil {
+		return nil, err
+	}
+
+	todoResult, found, err := h.store.UpdateTodo(p.Context, todoRequest.TodoItem(id))
+	if err != nil {
+		log.Ctx(p.Context).Error().Caller().Err(err).Msg("failed to update todo record")
+		return nil, errInternal
+	}
+	if !found {
+		return nil, nil
+	}
+
+	return todoResult, nil
+}
+
+func (h *Handler) resolveDeleteTodo(p graphql.ResolveParams) (interface{}, error) {
+	id, _ := p.Args["id"].(int)
+	if err := validateID(id); err != nil {
+		return nil, err
+	}
+
+	count, err := h.store.DeleteTodo(p.Context, id)
+	if err != nil {
+		log.Ctx(p.Context).Error().Caller().Err(err).Msg("failed to delete todo")
+		return nil, errInternal
+	}
+
+	return count > 0, nil
+}
+
+// subscribeTodoEvents forwards the events of the hub until the request is done or the subscriber falls behind
+func (h *Handler) subscribeTodoEvents(p graphql.ResolveParams) (interface{}, error) {
+	filter := events.ForUser(utils.UserFromCtx(p.Context))
+	if list, _ := p.Args["list"].(string); list != "" {
+		filter = events.ForList(list)
+	}
+
+	sub, _, _ := h.hub.Subscribe(filter, 0)
+	source := make(chan interface{})
+	go func() {
+		defer close(source)
+		defer sub.Close()
+
+		for {
+			select {
+			case <-p.Context.Done():
+				return
+			case event, ok := <-sub.Events:
+				if !ok {
+					return
+				}
+				select {
+				case source <- event:
+				case <-p.Context.Done():
+					return
+				}
+			}
+		}
+	}()
+
+	return source, nil
+}
+
+func validateID(id int) error {
+	if err := validation.Validate(id, validation.Required, validation.Min(1)); err != nil {
+		return errors.New("id: " + err.Error())
+	}
+	return nil
+}
+
+func todoField(fieldType graphql.Output, value func(models.TodoItem) interface{}) *graphql.Field {
+	return &graphql.Field{
+		Type: fieldType,
+		Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+			todoItem, _ := p.Source.(models.TodoItem)
+			return value(todoItem), nil
+		},
+	}
+}
+
+func eventField(fieldType graphql.Output, value func(models.TodoEvent) interface{}) *graphql.Field {
+	return &graphql.Field{
+		Type: fieldType,
+		Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+			event, _ := p.Source.(models.TodoEvent)
+			return value(event), nil
+		},
+	}
+}
@@ -0,0 +1,84 @@
+package health
+
+import (
+	"net/http"
+	"sync/atomic"
+
+	"github.com/rs/zerolog"
+	"github.com/rs/zerolog/log"
+	"github.com/unrolled/render"
+
+	"github.com/alexsniffin/go-api-starter/internal/todo-api/health"
+	"github.com/alexsniffin/go-api-starter/internal/todo-api/models"
+	"github.com/alexsniffin/go-api-starter/internal/todo-api/utils"
+)
+
+type Handler struct {
+	logger zerolog.Logger
+
+	render *render.Render
+	checks *health.Checks
+	ready  *int32
+}
+
+// Creates health handler, not ready until `SetReady` is called
+func NewHandler(logger zerolog.Logger, render *render.Render, checks *health.Checks) Handler {
+	return Handler{
+		logger: logger,
+
+		render: render,
+		checks: checks,
+		ready:  new(int32),
+	}
+}
+
+// SetReady flips whether the server accepts traffic, it's set to false before shutdown so load balancers stop routing
+// to the instance before its listeners are closed
+func (h *Handler) SetReady(ready bool) {
+	var value int32
+	if ready {
+		value = 1
+	}
+	atomic.StoreInt32(h.ready, value)
+}
+
+// IsReady reports whether the server accepts traffic
+func (h *Handler) IsReady() bool {
+	return atomic.LoadInt32(h.ready) == 1
+}
+
+// Handle HTTP Get for liveness
+func (h *Handler) Livez(w http.ResponseWriter, r *http.Request) {
+	h.writeReport(w, r, h.checks.Run(r.Context(), health.Liveness))
+}
+
+// Handle HTTP Get for readiness, unavailable before start and during shutdown regardless of the checks
+func (h *Handler) Readyz(w http.ResponseWriter, r *http.Request) {
+	report := h.checks.Run(r.Context(), health.Readiness)
+	if h.IsReady() {
+		report.Checks["ready"] = models.HealthCheckResult{Status: models.HealthOK}
+	} else {
+		report.Status = models.HealthFail
+		report.Checks["ready"] = models.HealthCheckResult{Status: models.HealthFail, Error: "not accepting traffic"}
+	}
+
+	h.writeReport(w, r, report)
+}
+
+// Handle HTTP Get for startup
+func (h *Handler) Startupz(w http.ResponseWriter, r *http.Request) {
+	h.writeReport(w, r, h.checks.Run(r.Context(), health.Startup))
+}
+
+func (h *Handler) writeReport(w http.ResponseWriter, r *http.Request, report models.HealthReport) {
+	statusCode := http.StatusOK
+	if report.Status != models.HealthOK {
+		statusCode = http.StatusServiceUnavailable
+	}
+
+	if err := h.render.JSON(w, statusCode, report); err != nil {
+		log.Ctx(utils.GetSubLoggerCtx(h.logger, r.Context())).Error().Caller().Err(err).
+			Msg("failed to marshal json response")
+		w.WriteHeader(http.StatusInternalServerError)
+	}
+}
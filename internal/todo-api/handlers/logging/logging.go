@@ -7,11 +7,15 @@ import (
 	"github.com/justinas/alice"
 	"github.com/rs/zerolog"
 	"github.com/rs/zerolog/hlog"
+
+	"github.com/alexsniffin/go-api-starter/internal/todo-api/utils"
+	"github.com/alexsniffin/go-api-starter/pkg/models"
 )
 
-func NewHandlerFunc(logger zerolog.Logger) func(http.Handler) http.Handler {
+func NewHandlerFunc(logger zerolog.Logger, cfg models.Logger) func(http.Handler) http.Handler {
 	c := alice.New()
 	c = c.Append(hlog.NewHandler(logger))
+	c = c.Append(debugHandler(cfg.DebugHeader, []byte(cfg.DebugKey)))
 	c = c.Append(hlog.RemoteAddrHandler("ip"))
 	c = c.Append(hlog.UserAgentHandler("agent"))
 	c = c.Append(hlog.RefererHandler("referer"))
@@ -28,3 +32,19 @@ func NewHandlerFunc(logger zerolog.Logger) func(http.Handler) http.Handler {
 
 	return c.Then
 }
+
+// debugHandler forces debug logging of requests sending a valid debug token in the `header`
+func debugHandler(header string, key []byte) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			token := r.Header.Get(header)
+			if header == "" || token == "" || !utils.VerifyDebugToken(key, token, time.Now()) {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			debugLogger := utils.DebugLogger(*zerolog.Ctx(r.Context()))
+			next.ServeHTTP(w, r.WithContext(debugLogger.WithContext(utils.WithDebug(r.Context()))))
+		})
+	}
+}
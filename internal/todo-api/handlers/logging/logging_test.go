@@ -0,0 +1,48 @@
+package logging
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/rs/zerolog"
+	"github.com/rs/zerolog/log"
+
+	"github.com/alexsniffin/go-api-starter/internal/todo-api/utils"
+	"github.com/alexsniffin/go-api-starter/pkg/models"
+)
+
+func TestNewHandlerFunc(t *testing.T) {
+	cfg := models.Logger{DebugHeader: "X-Debug-Log", DebugKey: "secret"}
+
+	var buf bytes.Buffer
+	logger := zerolog.New(&buf).Level(zerolog.InfoLevel)
+	handler := NewHandlerFunc(logger, cfg)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		log.Ctx(utils.GetSubLoggerCtx(logger, r.Context())).Debug().Msg("handler debug")
+	}))
+
+	tests := []struct {
+		name  string
+		token string
+		debug bool
+	}{
+		{"validToken", utils.SignDebugToken([]byte("secret"), time.Now().Add(time.Minute)), true},
+		{"expiredToken", utils.SignDebugToken([]byte("secret"), time.Now().Add(-time.Minute)), false},
+		{"otherKeyToken", utils.SignDebugToken([]byte("other"), time.Now().Add(time.Minute)), false},
+		{"missingToken", "", false},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			buf.Reset()
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			req.Header.Set(cfg.DebugHeader, test.token)
+			handler.ServeHTTP(httptest.NewRecorder(), req)
+
+			if debug := bytes.Contains(buf.Bytes(), []byte("handler debug")); debug != test.debug {
+				t.Errorf("wrong debug logging: got %v want %v", debug, test.debug)
+			}
+		})
+	}
+}
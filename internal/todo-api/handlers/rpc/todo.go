@@ -0,0 +1,238 @@
+package rpc
+
+import (
+	"context"
+	"encoding/json"
+
+	validation "github.com/go-ozzo/ozzo-validation/v4"
+	"github.com/rs/zerolog/log"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	"github.com/alexsniffin/go-api-starter/internal/todo-api/events"
+	"github.com/alexsniffin/go-api-starter/internal/todo-api/models"
+	"github.com/alexsniffin/go-api-starter/internal/todo-api/store/todo"
+	"github.com/alexsniffin/go-api-starter/internal/todo-api/utils"
+	todov1 "github.com/alexsniffin/go-api-starter/pkg/api/todo/v1"
+)
+
+const (
+	defaultPageSize = 50
+	maxPageSize     = 500
+)
+
+var errInternal = status.Error(codes.Internal, "Internal server error with request")
+
+// TodoService implements the gRPC TodoService over the TodoStore
+type TodoService struct {
+	todov1.UnimplementedTodoServiceServer
+
+	store todo.TodoStore
+	hub   *events.Hub
+}
+
+// NewTodoService creates a new TodoService
+func NewTodoService(store todo.Store, hub *events.Hub) *TodoService {
+	return &TodoService{
+		store: &store,
+		hub:   hub,
+	}
+}
+
+// GetTodo gets a TodoItem by ID
+func (s *TodoService) GetTodo(ctx context.Context, req *todov1.GetTodoRequest) (*todov1.Todo, error) {
+	if err := validateID(req.GetId()); err != nil {
+		return nil, err
+	}
+
+	todoResult, found, err := s.store.GetTodo(ctx, int(req.GetId()))
+	if err != nil {
+		log.Ctx(ctx).Error().Caller().Err(err).Msg("failed to get todoItem")
+		return nil, errInternal
+	}
+	if !found {
+		return nil, status.Error(codes.NotFound, "todo not found")
+	}
+
+	return toProto(todoResult), nil
+}
+
+// ListTodos lists a page of the todos of a list, or of the caller if no list is given
+func (s *TodoService) ListTodos(ctx context.Context, req *todov1.ListTodosRequest) (*todov1.ListTodosResponse, error) {
+	pageSize := int(req.GetPageSize())
+	if err := validation.Validate(pageSize, validation.Min(0), validation.Max(maxPageSize)); err != nil {
+		return nil, status.Error(codes.InvalidArgument, "page_size: "+err.Error())
+	}
+	if pageSize == 0 {
+		pageSize = defaultPageSize
+	}
+
+	afterID, err := utils.DecodePageToken(req.GetPageToken())
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, "page_token: invalid token")
+	}
+
+	todos, err := s.store.ListTodos(ctx, models.TodoFilter{
+		UserID:  utils.UserFromCtx(ctx),
+		List:    req.GetList(),
+		AfterID: afterID,
+		Limit:   pageSize,
+	})
+	if err != nil {
+		log.Ctx(ctx).Error().Caller().Err(err).Msg("failed to list todos")
+		return nil, errInternal
+	}
+
+	resp := &todov1.ListTodosResponse{
+		Todos: make([]*todov1.Todo, 0, len(todos)),
+	}
+	for i := 0; i < len(todos); i++ {
+		resp.Todos = append(resp.Todos, toProto(todos[i]))
+	}
+	if len(todos) == pageSize {
+		resp.NextPageToken = utils.EncodePageToken(todos[len(todos)-1].ID)
+	}
+
+	return resp, nil
+}
+
+// CreateTodo creates a TodoItem for the caller
+func (s *TodoService) CreateTodo(ctx context.Context, req *todov1.CreateTodoRequest) (*todov1.Todo, error) {
+	todoRequest := models.TodoPostRequest{
+		Todo: req.GetTodo(),
+		List: req.GetList(),
+	}
+	if err := todoRequest.IsValid(); err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+
+	todoItem := todoRequest.TodoItem(utils.UserFromCtx(ctx))
+	id, err := s.store.PostTodo(ctx, todoItem)
+	if err != nil {
+		log.Ctx(ctx).Error().Caller().Err(err).Msg("failed to insert todo record")
+		return nil, errInternal
+	}
+	todoItem.ID = id
+
+	return toProto(todoItem), nil
+}
+
+// UpdateTodo updates a TodoItem by ID
+func (s *TodoService) UpdateTodo(ctx context.Context, req *todov1.UpdateTodoRequest) (*todov1.Todo, error) {
+	if err := validateID(req.GetId()); err != nil {
+		return nil, err
+	}
+	todoRequest := models.TodoPutRequest{
+		Todo: req.GetTodo(),
+		List: req.GetList(),
+		Done: req.GetDone(),
+	}
+	if err := todoRequest.IsValid(); err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+
+	todoResult, found, err := s.store.UpdateTodo(ctx, todoRequest.TodoItem(int(req.GetId())))
+	if err != nil {
+		log.Ctx(ctx).Error().Caller().Err(err).Msg("failed to update todo record")
+		return nil, errInternal
+	}
+	if !found {
+		return nil, status.Error(codes.NotFound, "todo not found")
+	}
+
+	return toProto(todoResult), nil
+}
+
+// DeleteTodo deletes a TodoItem by ID
+func (s *TodoService) DeleteTodo(
+	ctx context.Context,
+	req *todov1.DeleteTodoRequest,
+) (*todov1.DeleteTodoResponse, error) {
+	if err := validateID(req.GetId()); err != nil {
+		return nil, err
+	}
+
+	count, err := s.store.DeleteTodo(ctx, int(req.GetId()))
+	if err != nil {
+		log.Ctx(ctx).Error().Caller().Err(err).Msg("failed to delete todo")
+		return nil, errInternal
+	}
+	if count == 0 {
+		return nil, status.Error(codes.NotFound, "todo not found")
+	}
+
+	return &todov1.DeleteTodoResponse{}, nil
+}
+
+// Watch streams the TodoEvents of a list, or of the caller if no list is given, resuming after `last_event_id`
+func (s *TodoService) Watch(req *todov1.WatchRequest, stream todov1.TodoService_WatchServer) error {
+	filter := events.ForUser(utils.UserFromCtx(stream.Context()))
+	if req.GetList() != "" {
+		filter = events.ForList(req.GetList())
+	}
+
+	sub, replay, complete := s.hub.Subscribe(filter, req.GetLastEventId())
+	defer sub.Close()
+
+	if !complete {
+		if err := stream.Send(&todov1.TodoEvent{Type: events.Reset}); err != nil {
+			return err
+		}
+	}
+	for i := 0; i < len(replay); i++ {
+		if err := sendEvent(stream, replay[i]); err != nil {
+			return err
+		}
+	}
+
+	for {
+		select {
+		case <-stream.Context().Done():
+			return nil
+		case event, ok := <-sub.Events:
+			if !ok {
+				return status.Error(codes.Unavailable, "event stream ended, resume with last_event_id")
+			}
+			if err := sendEvent(stream, event); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+func sendEvent(stream todov1.TodoService_WatchServer, event models.TodoEvent) error {
+	var todoItem models.TodoItem
+	if err := json.Unmarshal(event.Payload, &todoItem); err != nil {
+		log.Ctx(stream.Context()).Error().Caller().Err(err).Int64("eventID", event.ID).Msg("failed to decode event")
+		return errInternal
+	}
+
+	return stream.Send(&todov1.TodoEvent{
+		Id:        event.ID,
+		Type:      event.Type,
+		TodoId:    int64(event.TodoID),
+		List:      event.List,
+		Todo:      toProto(todoItem),
+		CreatedOn: timestamppb.New(event.CreatedOn),
+	})
+}
+
+func validateID(id int64) error {
+	if err := validation.Validate(id, validation.Required, validation.Min(int64(1))); err != nil {
+		return status.Error(codes.InvalidArgument, "id: "+err.Error())
+	}
+	return nil
+}
+
+func toProto(todoItem models.TodoItem) *todov1.Todo {
+	return &todov1.Todo{
+		Id:        int64(todoItem.ID),
+		Todo:      todoItem.Todo,
+		List:      todoItem.List,
+		UserId:    todoItem.UserID,
+		Done:      todoItem.Done,
+		CreatedOn: timestamppb.New(todoItem.CreatedOn),
+		UpdatedOn: timestamppb.New(todoItem.UpdatedOn),
+	}
+}
@@ -0,0 +1,78 @@
+package rpc
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/mock"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/alexsniffin/go-api-starter/internal/todo-api/events"
+	"github.com/alexsniffin/go-api-starter/internal/todo-api/models"
+	"github.com/alexsniffin/go-api-starter/internal/todo-api/utils"
+	"github.com/alexsniffin/go-api-starter/mocks"
+	todov1 "github.com/alexsniffin/go-api-starter/pkg/api/todo/v1"
+)
+
+func initTodoService() (*TodoService, *mocks.TodoStore) {
+	todoStoreMock := mocks.TodoStore{}
+	todoService := &TodoService{
+		store: &todoStoreMock,
+		hub:   events.NewHub(models.EventsConfig{LogSize: 10, BufferSize: 10}),
+	}
+	return todoService, &todoStoreMock
+}
+
+func expectCode(t *testing.T, err error, code codes.Code) {
+	if status.Code(err) != code {
+		t.Errorf("unexpected status code: got %v want %v", status.Code(err), code)
+		t.FailNow()
+	}
+}
+
+func TestTodoService(t *testing.T) {
+	t.Run("foundTodo", func(t *testing.T) {
+		todoService, todoStoreMock := initTodoService()
+		todoStoreMock.On("GetTodo", mock.Anything, 1).Return(models.TodoItem{ID: 1, Todo: "test"}, true, nil)
+
+		resp, err := todoService.GetTodo(context.Background(), &todov1.GetTodoRequest{Id: 1})
+		expectCode(t, err, codes.OK)
+
+		if resp.GetId() != 1 || resp.GetTodo() != "test" {
+			t.Errorf("unexpected response: %v", resp)
+		}
+		todoStoreMock.AssertExpectations(t)
+	})
+
+	t.Run("notFound", func(t *testing.T) {
+		todoService, todoStoreMock := initTodoService()
+		todoStoreMock.On("GetTodo", mock.Anything, 1).Return(models.TodoItem{}, false, nil)
+
+		_, err := todoService.GetTodo(context.Background(), &todov1.GetTodoRequest{Id: 1})
+		expectCode(t, err, codes.NotFound)
+	})
+
+	t.Run("invalidCreate", func(t *testing.T) {
+		todoService, todoStoreMock := initTodoService()
+
+		_, err := todoService.CreateTodo(context.Background(), &todov1.CreateTodoRequest{})
+		expectCode(t, err, codes.InvalidArgument)
+		todoStoreMock.AssertNotCalled(t, "PostTodo", mock.Anything, mock.Anything)
+	})
+
+	t.Run("listCallerTodos", func(t *testing.T) {
+		todoService, todoStoreMock := initTodoService()
+		todoStoreMock.On("ListTodos", mock.Anything, models.TodoFilter{UserID: "alice", Limit: 2}).
+			Return([]models.TodoItem{{ID: 1}, {ID: 2}}, nil)
+
+		ctx := utils.WithUser(context.Background(), "alice")
+		resp, err := todoService.ListTodos(ctx, &todov1.ListTodosRequest{PageSize: 2})
+		expectCode(t, err, codes.OK)
+
+		if len(resp.GetTodos()) != 2 || resp.GetNextPageToken() != utils.EncodePageToken(2) {
+			t.Errorf("unexpected response: %v", resp)
+		}
+		todoStoreMock.AssertExpectations(t)
+	})
+}
@@ -2,12 +2,12 @@ package todo
 
 import (
 	"context"
-	"encoding/json"
 	"errors"
 	"fmt"
-	"io/ioutil"
 	"net/http"
+	"net/url"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/go-chi/chi"
@@ -17,12 +17,26 @@ import (
 	"github.com/rs/zerolog/log"
 	"github.com/unrolled/render"
 
+	"github.com/alexsniffin/go-api-starter/internal/todo-api/codec"
 	"github.com/alexsniffin/go-api-starter/internal/todo-api/models"
 	"github.com/alexsniffin/go-api-starter/internal/todo-api/store/todo"
+	"github.com/alexsniffin/go-api-starter/internal/todo-api/transfer"
 	"github.com/alexsniffin/go-api-starter/internal/todo-api/utils"
 )
 
+const (
+	defaultPageSize = 50
+	maxPageSize     = 500
+)
+
+// responseTypes are the media types of the responses in order of preference, a list can also be written as CSV
+var (
+	responseTypes     = []string{codec.JSON, codec.MessagePack, codec.CBOR, codec.YAML}
+	listResponseTypes = append(responseTypes[:len(responseTypes):len(responseTypes)], codec.CSV)
+)
+
 type Handler struct {
+	cfg    models.RESTConfig
 	logger zerolog.Logger
 
 	render *render.Render
@@ -30,17 +44,23 @@ type Handler struct {
 }
 
 // Creates TodoItem handler
-func NewHandler(logger zerolog.Logger, render *render.Render, store todo.Store) Handler {
+func NewHandler(cfg models.RESTConfig, logger zerolog.Logger, render *render.Render, store todo.TodoStore) Handler {
 	return Handler{
+		cfg:    cfg,
 		logger: logger,
 
 		render: render,
-		store:  &store,
+		store:  store,
 	}
 }
 
 // Handle HTTP Get for TodoItem
 func (h *Handler) Get(w http.ResponseWriter, r *http.Request) {
+	mediaType, ok := h.negotiate(w, r, responseTypes)
+	if !ok {
+		return
+	}
+
 	todoIDStr := chi.URLParam(r, "id")
 	err := validation.Validate(todoIDStr, validation.Required, is.Int.Error("id must be an integer"))
 	if err != nil {
@@ -69,12 +89,81 @@ func (h *Handler) Get(w http.ResponseWriter, r *http.Request) {
 		w.WriteHeader(http.StatusNoContent)
 		return
 	}
+	if notModified(w, r, todoResult) {
+		return
+	}
+
+	h.writeResponse(logCtx, w, mediaType, http.StatusOK, todoResult)
+}
+
+// Handle HTTP Get for a page of TodoItems of a list, or of the user if no list is given. The page is continued after
+// the cursor of the previous page.
+func (h *Handler) List(w http.ResponseWriter, r *http.Request) {
+	mediaType, ok := h.negotiate(w, r, listResponseTypes)
+	if !ok {
+		return
+	}
 
-	err = h.render.JSON(w, http.StatusOK, todoResult)
+	query := r.URL.Query()
+	pageSize := defaultPageSize
+	if first := query.Get("first"); first != "" {
+		err := validation.Validate(first, is.Int.Error("first must be an integer"))
+		if err == nil {
+			pageSize, _ = strconv.Atoi(first)
+			err = validation.Validate(pageSize, validation.Min(1), validation.Max(maxPageSize))
+		}
+		if err != nil {
+			h.writeErrorResponse(r.Context(), w, http.StatusBadRequest, "first: "+err.Error())
+			return
+		}
+	}
+
+	afterID, err := utils.DecodePageToken(query.Get("after"))
 	if err != nil {
-		log.Error().Caller().Err(err).Msg("failed to marshal json todo get response")
-		w.WriteHeader(http.StatusInternalServerError)
+		h.writeErrorResponse(r.Context(), w, http.StatusBadRequest, "after: invalid cursor")
+		return
+	}
+
+	filter := models.TodoFilter{
+		UserID:  utils.UserFromCtx(r.Context()),
+		List:    query.Get("list"),
+		AfterID: afterID,
+		Limit:   pageSize,
+	}
+	if doneStr := query.Get("done"); doneStr != "" {
+		done, err := strconv.ParseBool(doneStr)
+		if err != nil {
+			h.writeErrorResponse(r.Context(), w, http.StatusBadRequest, "done must be a boolean")
+			return
+		}
+		filter.Done = &done
+	}
+
+	logCtx := utils.GetSubLoggerCtx(h.logger, r.Context())
+
+	todos, err := h.store.ListTodos(logCtx, filter)
+	if err != nil {
+		log.Ctx(logCtx).Error().Caller().Err(err).Msg("failed to list todos")
+		h.writeErrorResponse(logCtx, w, http.StatusInternalServerError, "Internal server error with request")
+		return
 	}
+
+	response := models.TodoListResponse{Items: todos}
+	if response.Items == nil {
+		response.Items = []models.TodoItem{}
+	}
+	if len(todos) == pageSize {
+		response.NextCursor = utils.EncodePageToken(todos[len(todos)-1].ID)
+		query.Set("after", response.NextCursor)
+		next := url.URL{Path: r.URL.Path, RawQuery: query.Encode()}
+		w.Header().Set("Link", fmt.Sprintf(`<%s>; rel="next"`, next.String()))
+	}
+
+	if mediaType == codec.CSV {
+		h.writeCSVResponse(logCtx, w, todos)
+		return
+	}
+	h.writeResponse(logCtx, w, mediaType, http.StatusOK, response)
 }
 
 // Handle HTTP Delete for TodoItem
@@ -114,10 +203,15 @@ func (h *Handler) Delete(w http.ResponseWriter, r *http.Request) {
 
 // Handle HTTP Post for TodoItem
 func (h *Handler) Post(w http.ResponseWriter, r *http.Request) {
+	mediaType, ok := h.negotiate(w, r, responseTypes)
+	if !ok {
+		return
+	}
+
 	var todoRequest models.TodoPostRequest
-	if err := unmarshalRequestBody(r, &todoRequest); err != nil {
-		h.logger.Error().Caller().Err(err).Msgf("failed to decode todo body: %v", todoRequest)
-		h.writeErrorResponse(r.Context(), w, http.StatusBadRequest, "invalid body")
+	if err := unmarshalRequestBody(r, h.cfg.MaxBodyBytes, &todoRequest); err != nil {
+		h.logger.Debug().Caller().Err(err).Msg("failed to decode todo body")
+		h.writeBodyErrorResponse(r.Context(), w, err)
 		return
 	}
 
@@ -127,48 +221,206 @@ func (h *Handler) Post(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	idempotencyKey := r.Header.Get(models.IdempotencyKeyHeader)
+	if err := validation.Validate(idempotencyKey, validation.Length(0, 255)); err != nil {
+		h.writeErrorResponse(r.Context(), w, http.StatusBadRequest, models.IdempotencyKeyHeader+": "+err.Error())
+		return
+	}
+
 	logCtx := utils.GetSubLoggerCtx(h.logger, r.Context())
 
-	id, err := h.store.PostTodo(logCtx, models.TodoItem{
-		Todo:      todoRequest.Todo,
-		CreatedOn: time.Now(),
-	})
+	todoItem := todoRequest.TodoItem(utils.UserFromCtx(r.Context()))
+	todoItem.IdempotencyKey = idempotencyKey
+	id, err := h.store.PostTodo(logCtx, todoItem)
 	if err != nil {
-		log.Ctx(logCtx).Error().Caller().Err(err).Msgf("failed to insert todo record: %v", todoRequest)
+		log.Ctx(logCtx).Error().Caller().Err(err).Msg("failed to insert todo record")
 		h.writeErrorResponse(logCtx, w, http.StatusInternalServerError, "Internal server error with request")
 		return
 	}
 
-	if err = h.render.JSON(w, http.StatusOK, models.TodoPostResponse{ID: id}); err != nil {
-		log.Ctx(logCtx).Error().Caller().Err(err).Msg("failed to marshal json response")
+	h.writeResponse(logCtx, w, mediaType, http.StatusOK, models.TodoPostResponse{ID: id})
+}
+
+// Handle HTTP Put for TodoItem, a missing TodoItem is answered with no content like Get and Delete
+func (h *Handler) Put(w http.ResponseWriter, r *http.Request) {
+	mediaType, ok := h.negotiate(w, r, responseTypes)
+	if !ok {
+		return
+	}
+
+	todoIDStr := chi.URLParam(r, "id")
+	err := validation.Validate(todoIDStr, validation.Required, is.Int.Error("id must be an integer"))
+	if err != nil {
+		h.logger.Debug().Caller().Msg("missing id in request")
+		h.writeErrorResponse(r.Context(), w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	todoID, err := strconv.Atoi(todoIDStr)
+	if err != nil {
+		h.logger.Error().Caller().Err(err).Msg("failed to decode todoID")
+		h.writeErrorResponse(r.Context(), w, http.StatusInternalServerError, "Error decoding id value")
+		return
+	}
+
+	var todoRequest models.TodoPutRequest
+	if err = unmarshalRequestBody(r, h.cfg.MaxBodyBytes, &todoRequest); err != nil {
+		h.logger.Debug().Caller().Err(err).Msg("failed to decode todo body")
+		h.writeBodyErrorResponse(r.Context(), w, err)
+		return
+	}
+
+	if err = todoRequest.IsValid(); err != nil {
+		h.logger.Debug().Caller().Err(err).Msg("invalid put")
+		h.writeErrorResponse(r.Context(), w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	ctx := context.WithValue(r.Context(), "id", todoID)
+	logCtx := utils.GetSubLoggerCtx(h.logger, ctx)
+
+	todoResult, found, err := h.store.UpdateTodo(logCtx, todoRequest.TodoItem(todoID))
+	if err != nil {
+		log.Ctx(logCtx).Error().Caller().Err(err).Msg("failed to update todo record")
+		h.writeErrorResponse(logCtx, w, http.StatusInternalServerError, "Internal server error with request")
+		return
+	}
+	if !found {
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+
+	h.writeResponse(logCtx, w, mediaType, http.StatusOK, todoResult)
+}
+
+// notModified sets the ETag and Last-Modified headers of the TodoItem, from when it was updated or created, and writes
+// a 304 if the ETag matches the If-None-Match header or, without one, it wasn't modified since the If-Modified-Since
+// header
+func notModified(w http.ResponseWriter, r *http.Request, todoItem models.TodoItem) bool {
+	lastModified := todoItem.UpdatedOn
+	if lastModified.IsZero() {
+		lastModified = todoItem.CreatedOn
+	}
+	if lastModified.IsZero() {
+		return false
+	}
+	// the ETag has the full precision of the time, so updates within the same second are told apart. It's weak since
+	// the responses of the media types differ.
+	etag := `W/"` + strconv.FormatInt(lastModified.UnixNano(), 16) + `"`
+	w.Header().Set("ETag", etag)
+	// the header only has a precision of seconds
+	lastModified = lastModified.UTC().Truncate(time.Second)
+	w.Header().Set("Last-Modified", lastModified.Format(http.TimeFormat))
+
+	if ifNoneMatch := r.Header.Get("If-None-Match"); ifNoneMatch != "" {
+		if !etagMatches(ifNoneMatch, etag) {
+			return false
+		}
+	} else {
+		ifModifiedSince, err := http.ParseTime(r.Header.Get("If-Modified-Since"))
+		if err != nil || lastModified.After(ifModifiedSince) {
+			return false
+		}
+	}
+	w.WriteHeader(http.StatusNotModified)
+	return true
+}
+
+// etagMatches returns whether one of the entity tags of an If-None-Match header is the etag, compared weakly
+func etagMatches(ifNoneMatch, etag string) bool {
+	for _, tag := range strings.Split(ifNoneMatch, ",") {
+		tag = strings.TrimSpace(tag)
+		if tag == "*" || strings.TrimPrefix(tag, "W/") == strings.TrimPrefix(etag, "W/") {
+			return true
+		}
+	}
+	return false
+}
+
+// negotiate returns the media type of the offers acceptable to the client, or writes a 406 problem and returns false.
+// The responses vary by Accept, which the cache policy of the route declares.
+func (h *Handler) negotiate(w http.ResponseWriter, r *http.Request, offers []string) (string, bool) {
+	mediaType, ok := codec.Negotiate(r.Header.Get("Accept"), offers)
+	if !ok {
+		h.writeErrorResponse(r.Context(), w, http.StatusNotAcceptable,
+			"Accept must allow one of "+strings.Join(offers, ", "))
+	}
+	return mediaType, ok
+}
+
+// writeResponse writes the response as the negotiated media type
+func (h *Handler) writeResponse(ctx context.Context, w http.ResponseWriter, mediaType string, statusCode int,
+	response interface{}) {
+	var err error
+	if mediaType == codec.JSON {
+		err = h.render.JSON(w, statusCode, response)
+	} else {
+		c, _ := codec.Get(mediaType)
+		var data []byte
+		if data, err = c.Marshal(response); err == nil {
+			err = h.render.Render(w, render.Data{
+				Head: render.Head{ContentType: mediaType, Status: statusCode},
+			}, data)
+		}
+	}
+	if err != nil {
+		log.Ctx(ctx).Error().Caller().Err(err).Str("mediaType", mediaType).Msg("failed to marshal response")
 		w.WriteHeader(http.StatusInternalServerError)
 	}
 }
 
+// writeCSVResponse writes TodoItems as CSV with the columns of an export
+func (h *Handler) writeCSVResponse(ctx context.Context, w http.ResponseWriter, todos []models.TodoItem) {
+	w.Header().Set("Content-Type", transfer.ContentType(transfer.FormatCSV))
+	enc, _ := transfer.NewEncoder(w, transfer.FormatCSV)
+	for i := 0; i < len(todos); i++ {
+		if err := enc.Encode(models.NewTodoRecord(todos[i])); err != nil {
+			log.Ctx(ctx).Error().Caller().Err(err).Msg("failed to write csv response")
+			return
+		}
+	}
+	if err := enc.Flush(); err != nil {
+		log.Ctx(ctx).Error().Caller().Err(err).Msg("failed to write csv response")
+	}
+}
+
+// writeBodyErrorResponse writes the problem of a request body which couldn't be decoded
+func (h *Handler) writeBodyErrorResponse(ctx context.Context, w http.ResponseWriter, err error) {
+	var decodeErr *codec.DecodeError
+	switch {
+	case errors.Is(err, codec.ErrUnsupported):
+		h.writeErrorResponse(ctx, w, http.StatusUnsupportedMediaType, err.Error())
+	case errors.Is(err, codec.ErrTooLarge):
+		h.writeErrorResponse(ctx, w, http.StatusRequestEntityTooLarge,
+			fmt.Sprintf("body must not be larger than %d bytes", h.cfg.MaxBodyBytes))
+	case errors.As(err, &decodeErr):
+		h.writeErrorResponse(ctx, w, http.StatusBadRequest, "invalid body: "+decodeErr.Error())
+	default:
+		h.writeErrorResponse(ctx, w, http.StatusBadRequest, "invalid body")
+	}
+}
+
+// writeErrorResponse writes an RFC 7807 problem of the status code with the message as detail
 func (h *Handler) writeErrorResponse(ctx context.Context, w http.ResponseWriter, statusCode int, responseMessage string) {
-	if rErr := h.render.JSON(w, statusCode, models.Error{
-		Message: responseMessage,
-	}); rErr != nil {
+	if rErr := h.render.Render(w, render.JSON{
+		Head: render.Head{ContentType: models.ProblemContentType, Status: statusCode},
+	}, models.NewProblem(statusCode, responseMessage)); rErr != nil {
 		log.Ctx(ctx).Error().Caller().Err(rErr).Msg("failed to marshal json response")
 		w.WriteHeader(http.StatusInternalServerError)
 	}
 }
 
-func unmarshalRequestBody(req *http.Request, output interface{}) error {
-	if req.Body == nil {
-		return errors.New("invalid body in request")
-	}
-
-	body, err := ioutil.ReadAll(req.Body)
+// unmarshalRequestBody decodes a single value from the body of a request, of at most maxBytes, with the codec of its
+// Content-Type. Unknown fields are rejected.
+func unmarshalRequestBody(req *http.Request, maxBytes int64, output interface{}) error {
+	c, err := codec.ForContentType(req.Header.Get("Content-Type"))
 	if err != nil {
 		return err
 	}
-	if err = req.Body.Close(); err != nil {
-		return err
-	}
-	if err = json.Unmarshal(body, &output); err != nil {
-		return err
+	if req.Body == nil {
+		return errors.New("invalid body in request")
 	}
+	defer req.Body.Close()
 
-	return nil
+	return c.Decode(codec.LimitReader(req.Body, maxBytes), output)
 }
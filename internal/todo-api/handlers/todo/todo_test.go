@@ -1,20 +1,26 @@
 package todo
 
 import (
+	"bytes"
 	"context"
+	"encoding/json"
 	"fmt"
 	"net/http"
 	"net/http/httptest"
 	"os"
 	"strconv"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/go-chi/chi"
 	"github.com/rs/zerolog"
 	"github.com/stretchr/testify/mock"
 	"github.com/unrolled/render"
 
+	"github.com/alexsniffin/go-api-starter/internal/todo-api/codec"
 	"github.com/alexsniffin/go-api-starter/internal/todo-api/models"
+	"github.com/alexsniffin/go-api-starter/internal/todo-api/utils"
 	"github.com/alexsniffin/go-api-starter/mocks"
 )
 
@@ -22,6 +28,7 @@ func initTodoHandler() (Handler, *mocks.TodoStore) {
 	todoStoreMock := mocks.TodoStore{}
 	logger := zerolog.New(os.Stdout)
 	todoHandler := Handler{
+		cfg:    models.RESTConfig{MaxBodyBytes: 1024, MaxImportBytes: 1024},
 		logger: logger,
 		render: render.New(),
 		store:  &todoStoreMock,
@@ -58,7 +65,8 @@ func TestTodoHandler(t *testing.T) {
 			t.FailNow()
 		}
 
-		expected := `{"id":1,"todo":"test","created_on":"0001-01-01T00:00:00Z"}`
+		expected := `{"id":1,"todo":"test","done":false,"created_on":"0001-01-01T00:00:00Z",` +
+			`"updated_on":"0001-01-01T00:00:00Z"}`
 		if rr.Body.String() != expected {
 			t.Errorf("unexpected body: got %v want %v", rr.Body.String(), expected)
 			t.FailNow()
@@ -127,10 +135,274 @@ func TestTodoHandler(t *testing.T) {
 			t.FailNow()
 		}
 
-		expected := `{"message":"id must be an integer"}`
+		if contentType := rr.Header().Get("Content-Type"); contentType != "application/problem+json" {
+			t.Errorf("unexpected content type: got %v want %v", contentType, "application/problem+json")
+		}
+		expected := `{"type":"about:blank","title":"Bad Request","status":400,"detail":"id must be an integer",` +
+			`"message":"id must be an integer"}`
 		if rr.Body.String() != expected {
 			t.Errorf("unexpected body: got %v want %v", rr.Body.String(), expected)
 			t.Fail()
 		}
 	})
+
+	t.Run("listPage", func(t *testing.T) {
+		todoHandler, todoStoreMock := initTodoHandler()
+		done := false
+		todoStoreMock.On("ListTodos", mock.Anything, models.TodoFilter{Done: &done, AfterID: 1, Limit: 2}).
+			Return([]models.TodoItem{{ID: 2, Todo: "a"}, {ID: 3, Todo: "b"}}, nil)
+
+		req, err := http.NewRequest("GET", "/todo?first=2&done=false&after="+utils.EncodePageToken(1), nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		rr := httptest.NewRecorder()
+		http.HandlerFunc(todoHandler.List).ServeHTTP(rr, req)
+
+		if status := rr.Code; status != http.StatusOK {
+			t.Errorf("unexpected status code: got %v want %v", status, http.StatusOK)
+			t.FailNow()
+		}
+		var response models.TodoListResponse
+		if err = json.Unmarshal(rr.Body.Bytes(), &response); err != nil {
+			t.Fatal(err)
+		}
+		if len(response.Items) != 2 || response.NextCursor != utils.EncodePageToken(3) {
+			t.Errorf("unexpected page: got %+v", response)
+		}
+
+		todoStoreMock.AssertExpectations(t)
+	})
+
+	t.Run("putNotFound", func(t *testing.T) {
+		todoHandler, todoStoreMock := initTodoHandler()
+		todoStoreMock.On("UpdateTodo", mock.Anything, mock.Anything).Return(models.TodoItem{}, false, nil)
+
+		req, err := http.NewRequest("PUT", "/todo/1", strings.NewReader(`{"todo":"test","done":true}`))
+		if err != nil {
+			t.Fatal(err)
+		}
+		req.Header.Set("Content-Type", "application/json")
+
+		rCtx := chi.NewRouteContext()
+		rCtx.URLParams.Add("id", "1")
+		req = req.WithContext(context.WithValue(req.Context(), chi.RouteCtxKey, rCtx))
+
+		rr := httptest.NewRecorder()
+		http.HandlerFunc(todoHandler.Put).ServeHTTP(rr, req)
+
+		if status := rr.Code; status != http.StatusNoContent {
+			t.Errorf("unexpected status code: got %v want %v", status, http.StatusNoContent)
+		}
+
+		todoStoreMock.AssertNumberOfCalls(t, "UpdateTodo", 1)
+	})
+	t.Run("listCSV", func(t *testing.T) {
+		todoHandler, todoStoreMock := initTodoHandler()
+		todoStoreMock.On("ListTodos", mock.Anything, models.TodoFilter{Limit: 1}).
+			Return([]models.TodoItem{{ID: 2, Todo: "a"}}, nil)
+
+		req, err := http.NewRequest("GET", "/todo?first=1", nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		req.Header.Set("Accept", "text/csv, application/json;q=0.5")
+
+		rr := httptest.NewRecorder()
+		http.HandlerFunc(todoHandler.List).ServeHTTP(rr, req)
+
+		expected := "id,external_id,todo,list,done,created_on,updated_on\n2,,a,,false,,\n"
+		if rr.Body.String() != expected {
+			t.Errorf("unexpected body: got %v want %v", rr.Body.String(), expected)
+		}
+		expectedLink := fmt.Sprintf(`</todo?after=%s&first=1>; rel="next"`, utils.EncodePageToken(2))
+		if link := rr.Header().Get("Link"); link != expectedLink {
+			t.Errorf("unexpected link: got %v want %v", link, expectedLink)
+		}
+	})
+
+	t.Run("notAcceptable", func(t *testing.T) {
+		todoHandler, todoStoreMock := initTodoHandler()
+
+		req, err := http.NewRequest("GET", "/todo/1", nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		req.Header.Set("Accept", "text/csv")
+
+		rr := httptest.NewRecorder()
+		http.HandlerFunc(todoHandler.Get).ServeHTTP(rr, req)
+
+		if status := rr.Code; status != http.StatusNotAcceptable {
+			t.Errorf("unexpected status code: got %v want %v", status, http.StatusNotAcceptable)
+		}
+
+		todoStoreMock.AssertNumberOfCalls(t, "GetTodo", 0)
+	})
+
+	t.Run("postMessagePack", func(t *testing.T) {
+		todoHandler, todoStoreMock := initTodoHandler()
+		todoStoreMock.On("PostTodo", mock.Anything, mock.MatchedBy(func(todo models.TodoItem) bool {
+			return todo.Todo == "test"
+		})).Return(1, nil)
+
+		c, _ := codec.Get(codec.MessagePack)
+		body, err := c.Marshal(models.TodoPostRequest{Todo: "test"})
+		if err != nil {
+			t.Fatal(err)
+		}
+		req, err := http.NewRequest("POST", "/todo", bytes.NewReader(body))
+		if err != nil {
+			t.Fatal(err)
+		}
+		req.Header.Set("Content-Type", codec.MessagePack)
+		req.Header.Set("Accept", codec.MessagePack)
+
+		rr := httptest.NewRecorder()
+		http.HandlerFunc(todoHandler.Post).ServeHTTP(rr, req)
+
+		if contentType := rr.Header().Get("Content-Type"); contentType != codec.MessagePack {
+			t.Errorf("unexpected content type: got %v want %v", contentType, codec.MessagePack)
+		}
+		var response models.TodoPostResponse
+		if err = c.Unmarshal(rr.Body.Bytes(), &response); err != nil {
+			t.Fatal(err)
+		}
+		if response.ID != 1 {
+			t.Errorf("unexpected id: got %v want %v", response.ID, 1)
+		}
+	})
+
+	t.Run("unsupportedMediaType", func(t *testing.T) {
+		todoHandler, todoStoreMock := initTodoHandler()
+
+		req, err := http.NewRequest("POST", "/todo", strings.NewReader("todo=test"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+		rr := httptest.NewRecorder()
+		http.HandlerFunc(todoHandler.Post).ServeHTTP(rr, req)
+
+		if status := rr.Code; status != http.StatusUnsupportedMediaType {
+			t.Errorf("unexpected status code: got %v want %v", status, http.StatusUnsupportedMediaType)
+		}
+
+		todoStoreMock.AssertNumberOfCalls(t, "PostTodo", 0)
+	})
+	t.Run("invalidBodies", func(t *testing.T) {
+		tests := []struct {
+			name        string
+			contentType string
+			body        string
+			status      int
+			detail      string
+		}{
+			{"missingContentType", "", `{"todo":"test"}`, http.StatusUnsupportedMediaType,
+				"Content-Type is required: unsupported content type"},
+			{"tooLarge", "application/json", `{"todo":"` + strings.Repeat("a", 1024) + `"}`,
+				http.StatusRequestEntityTooLarge, "body must not be larger than 1024 bytes"},
+			{"unknownField", "application/json", `{"todo":"test","owner":"x"}`, http.StatusBadRequest,
+				"invalid body: field owner: unknown field"},
+			{"trailingData", "application/json", `{"todo":"test"}{}`, http.StatusBadRequest,
+				"invalid body: body must only contain a single value at offset 15"},
+		}
+
+		for _, tt := range tests {
+			t.Run(tt.name, func(t *testing.T) {
+				todoHandler, todoStoreMock := initTodoHandler()
+
+				req, err := http.NewRequest("POST", "/todo", strings.NewReader(tt.body))
+				if err != nil {
+					t.Fatal(err)
+				}
+				if tt.contentType != "" {
+					req.Header.Set("Content-Type", tt.contentType)
+				}
+
+				rr := httptest.NewRecorder()
+				http.HandlerFunc(todoHandler.Post).ServeHTTP(rr, req)
+
+				if status := rr.Code; status != tt.status {
+					t.Errorf("unexpected status code: got %v want %v", status, tt.status)
+				}
+				var problem models.Problem
+				if err = json.Unmarshal(rr.Body.Bytes(), &problem); err != nil {
+					t.Fatal(err)
+				}
+				if problem.Detail != tt.detail {
+					t.Errorf("unexpected detail: got %v want %v", problem.Detail, tt.detail)
+				}
+
+				todoStoreMock.AssertNumberOfCalls(t, "PostTodo", 0)
+			})
+		}
+	})
+	t.Run("notModified", func(t *testing.T) {
+		updatedOn := time.Date(2020, 1, 2, 3, 4, 5, 600, time.UTC)
+		etag := `W/"` + strconv.FormatInt(updatedOn.UnixNano(), 16) + `"`
+		// an update earlier in the same second has the same Last-Modified but another ETag
+		previousETag := `W/"` + strconv.FormatInt(updatedOn.Add(-time.Millisecond).UnixNano(), 16) + `"`
+		tests := []struct {
+			name            string
+			ifModifiedSince string
+			ifNoneMatch     string
+			status          int
+		}{
+			{"unconditional", "", "", http.StatusOK},
+			{"sameTime", updatedOn.Format(http.TimeFormat), "", http.StatusNotModified},
+			{"later", updatedOn.Add(time.Hour).Format(http.TimeFormat), "", http.StatusNotModified},
+			{"modified", updatedOn.Add(-time.Second).Format(http.TimeFormat), "", http.StatusOK},
+			{"sameETag", "", etag, http.StatusNotModified},
+			{"strongETag", "", strings.TrimPrefix(etag, "W/"), http.StatusNotModified},
+			{"oneOfETags", "", previousETag + ", " + etag, http.StatusNotModified},
+			{"updatedInSameSecond", updatedOn.Format(http.TimeFormat), previousETag, http.StatusOK},
+		}
+
+		for _, tt := range tests {
+			t.Run(tt.name, func(t *testing.T) {
+				todoHandler, todoStoreMock := initTodoHandler()
+				todoStoreMock.On("GetTodo", mock.Anything, 1).Return(models.TodoItem{
+					ID:        1,
+					Todo:      "test",
+					CreatedOn: updatedOn.Add(-time.Hour),
+					UpdatedOn: updatedOn,
+				}, true, nil)
+
+				req, err := http.NewRequest("GET", "/todo/1", nil)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if tt.ifModifiedSince != "" {
+					req.Header.Set("If-Modified-Since", tt.ifModifiedSince)
+				}
+				if tt.ifNoneMatch != "" {
+					req.Header.Set("If-None-Match", tt.ifNoneMatch)
+				}
+
+				rCtx := chi.NewRouteContext()
+				rCtx.URLParams.Add("id", "1")
+				req = req.WithContext(context.WithValue(req.Context(), chi.RouteCtxKey, rCtx))
+
+				rr := httptest.NewRecorder()
+				http.HandlerFunc(todoHandler.Get).ServeHTTP(rr, req)
+
+				if status := rr.Code; status != tt.status {
+					t.Errorf("unexpected status code: got %v want %v", status, tt.status)
+				}
+				expected := updatedOn.Format(http.TimeFormat)
+				if lastModified := rr.Header().Get("Last-Modified"); lastModified != expected {
+					t.Errorf("unexpected last modified: got %v want %v", lastModified, expected)
+				}
+				if actual := rr.Header().Get("ETag"); actual != etag {
+					t.Errorf("unexpected etag: got %v want %v", actual, etag)
+				}
+				if tt.status == http.StatusNotModified && rr.Body.Len() != 0 {
+					t.Errorf("unexpected body: got %v", rr.Body.String())
+				}
+			})
+		}
+	})
 }
@@ -0,0 +1,207 @@
+package todo
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+
+	"github.com/rs/zerolog/log"
+
+	"github.com/alexsniffin/go-api-starter/internal/todo-api/codec"
+	"github.com/alexsniffin/go-api-starter/internal/todo-api/models"
+	"github.com/alexsniffin/go-api-starter/internal/todo-api/store/todo"
+	"github.com/alexsniffin/go-api-starter/internal/todo-api/transfer"
+	"github.com/alexsniffin/go-api-starter/internal/todo-api/utils"
+)
+
+const (
+	exportPageSize  = 500
+	maxImportErrors = 100
+
+	importModeInsert = "insert"
+	importModeUpsert = "upsert"
+)
+
+// Handle HTTP Get of an export of the TodoItems of the user, or of a list, as JSON lines or CSV. The TodoItems are
+// streamed a page at a time, so an export isn't held in memory.
+func (h *Handler) Export(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	format := query.Get("format")
+	if format == "" {
+		format = transfer.FormatJSONL
+	}
+	contentType := transfer.ContentType(format)
+	if contentType == "" {
+		h.writeErrorResponse(r.Context(), w, http.StatusBadRequest, "format must be jsonl or csv")
+		return
+	}
+
+	logCtx := utils.GetSubLoggerCtx(h.logger, r.Context())
+
+	filter := models.TodoFilter{
+		UserID: utils.UserFromCtx(r.Context()),
+		List:   query.Get("list"),
+		Limit:  exportPageSize,
+	}
+	enc, _ := transfer.NewEncoder(w, format)
+	flusher, _ := w.(http.Flusher)
+	count := 0
+	for {
+		todos, err := h.store.ListTodos(logCtx, filter)
+		if err != nil {
+			log.Ctx(logCtx).Error().Caller().Err(err).Int("count", count).Msg("failed to export todos")
+			// the response is truncated once todos are written, the client sees the connection close early
+			if count == 0 {
+				h.writeErrorResponse(logCtx, w, http.StatusInternalServerError, "Internal server error with request")
+			}
+			return
+		}
+
+		if count == 0 {
+			w.Header().Set("Content-Type", contentType)
+			w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="todos.%s"`, format))
+		}
+		for i := 0; i < len(todos); i++ {
+			if err = enc.Encode(models.NewTodoRecord(todos[i])); err != nil {
+				log.Ctx(logCtx).Error().Caller().Err(err).Msg("failed to write todo export")
+				return
+			}
+		}
+		count += len(todos)
+		if len(todos) < filter.Limit {
+			break
+		}
+
+		if err = enc.Flush(); err != nil {
+			log.Ctx(logCtx).Error().Caller().Err(err).Msg("failed to write todo export")
+			return
+		}
+		if flusher != nil {
+			flusher.Flush()
+		}
+		filter.AfterID = todos[len(todos)-1].ID
+	}
+
+	if err := enc.Flush(); err != nil {
+		log.Ctx(logCtx).Error().Caller().Err(err).Msg("failed to write todo export")
+		return
+	}
+	log.Ctx(logCtx).Debug().Caller().Int("count", count).Msg("todos exported")
+}
+
+// Handle HTTP Post of an import of TodoItems of the user as JSON lines or CSV. Every record is validated, invalid
+// records are skipped and reported by line. Records are created, or with `mode=upsert` the TodoItem of the user with
+// the external ID of a record is updated. `dry_run=true` only validates the records.
+func (h *Handler) Import(w http.ResponseWriter, r *http.Request) {
+	mediaType, ok := h.negotiate(w, r, responseTypes)
+	if !ok {
+		return
+	}
+
+	query := r.URL.Query()
+	format := query.Get("format")
+	if format == "" {
+		format = transfer.FormatJSONL
+	}
+	dec, err := transfer.NewDecoder(codec.LimitReader(r.Body, h.cfg.MaxImportBytes), format)
+	if err != nil {
+		h.writeErrorResponse(r.Context(), w, http.StatusBadRequest, "format must be jsonl or csv")
+		return
+	}
+
+	mode := query.Get("mode")
+	if mode == "" {
+		mode = importModeInsert
+	}
+	if mode != importModeInsert && mode != importModeUpsert {
+		h.writeErrorResponse(r.Context(), w, http.StatusBadRequest, "mode must be insert or upsert")
+		return
+	}
+
+	response := models.TodoImportResponse{Errors: []models.TodoImportError{}}
+	if dryRun := query.Get("dry_run"); dryRun != "" {
+		if response.DryRun, err = strconv.ParseBool(dryRun); err != nil {
+			h.writeErrorResponse(r.Context(), w, http.StatusBadRequest, "dry_run must be a boolean")
+			return
+		}
+	}
+
+	logCtx := utils.GetSubLoggerCtx(h.logger, r.Context())
+	userID := utils.UserFromCtx(r.Context())
+
+	fail := func(line int, message string) {
+		response.Failed++
+		if len(response.Errors) < maxImportErrors {
+			response.Errors = append(response.Errors, models.TodoImportError{Line: line, Message: message})
+		}
+	}
+	for {
+		record, line, err := dec.Decode()
+		if err == io.EOF {
+			break
+		}
+		var recordErr *transfer.RecordError
+		if errors.As(err, &recordErr) {
+			fail(line, recordErr.Err.Error())
+			continue
+		}
+		if errors.Is(err, codec.ErrTooLarge) {
+			h.writeErrorResponse(logCtx, w, http.StatusRequestEntityTooLarge, fmt.Sprintf(
+				"import must not be larger than %d bytes, %d todos were created and %d updated before",
+				h.cfg.MaxImportBytes, response.Created, response.Updated))
+			return
+		}
+		if err != nil {
+			log.Ctx(logCtx).Debug().Caller().Err(err).Msg("failed to read todo import")
+			h.writeErrorResponse(logCtx, w, http.StatusBadRequest, "invalid body: "+err.Error())
+			return
+		}
+
+		if err = record.IsValid(); err != nil {
+			fail(line, err.Error())
+			continue
+		}
+		if mode == importModeUpsert && record.ExternalID == "" {
+			fail(line, "external_id: cannot be blank to upsert.")
+			continue
+		}
+		response.Valid++
+		if response.DryRun {
+			continue
+		}
+
+		if mode == importModeUpsert {
+			var created bool
+			if _, created, err = h.store.UpsertTodo(logCtx, record.TodoItem(userID)); err == nil {
+				if created {
+					response.Created++
+				} else {
+					response.Updated++
+				}
+			}
+		} else {
+			_, err = h.store.PostTodo(logCtx, record.TodoItem(userID))
+			if err == todo.ErrExternalIDExists {
+				response.Valid--
+				fail(line, "external_id: a todo with the external id already exists, upsert to update it.")
+				continue
+			}
+			if err == nil {
+				response.Created++
+			}
+		}
+		if err != nil {
+			log.Ctx(logCtx).Error().Caller().Err(err).Int("line", line).Msg("failed to import todo")
+			h.writeErrorResponse(logCtx, w, http.StatusInternalServerError, fmt.Sprintf(
+				"Internal server error importing line %d, %d todos were created and %d updated before", line,
+				response.Created, response.Updated))
+			return
+		}
+	}
+
+	log.Ctx(logCtx).Debug().Caller().Int("created", response.Created).Int("updated", response.Updated).
+		Int("failed", response.Failed).Msg("todos imported")
+	h.writeResponse(logCtx, w, mediaType, http.StatusOK, response)
+}
@@ -0,0 +1,150 @@
+package todo
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/mock"
+
+	"github.com/alexsniffin/go-api-starter/internal/todo-api/models"
+	"github.com/alexsniffin/go-api-starter/internal/todo-api/store/todo"
+)
+
+func TestTransferHandler(t *testing.T) {
+	t.Run("exportCSV", func(t *testing.T) {
+		todoHandler, todoStoreMock := initTodoHandler()
+		todoStoreMock.On("ListTodos", mock.Anything, models.TodoFilter{List: "home", Limit: exportPageSize}).
+			Return([]models.TodoItem{{ID: 2, ExternalID: "a", Todo: "test", List: "home"}}, nil)
+
+		req, err := http.NewRequest("GET", "/todo/export?format=csv&list=home", nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		rr := httptest.NewRecorder()
+		http.HandlerFunc(todoHandler.Export).ServeHTTP(rr, req)
+
+		if status := rr.Code; status != http.StatusOK {
+			t.Errorf("unexpected status code: got %v want %v", status, http.StatusOK)
+			t.FailNow()
+		}
+		if contentType := rr.Header().Get("Content-Type"); contentType != "text/csv; charset=utf-8" {
+			t.Errorf("unexpected content type: got %v want %v", contentType, "text/csv; charset=utf-8")
+		}
+		expected := "id,external_id,todo,list,done,created_on,updated_on\n" +
+			"2,a,test,home,false,,\n"
+		if rr.Body.String() != expected {
+			t.Errorf("unexpected body: got %v want %v", rr.Body.String(), expected)
+		}
+
+		todoStoreMock.AssertNumberOfCalls(t, "ListTodos", 1)
+	})
+
+	t.Run("exportBadFormat", func(t *testing.T) {
+		todoHandler, todoStoreMock := initTodoHandler()
+
+		req, err := http.NewRequest("GET", "/todo/export?format=xml", nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		rr := httptest.NewRecorder()
+		http.HandlerFunc(todoHandler.Export).ServeHTTP(rr, req)
+
+		if status := rr.Code; status != http.StatusBadRequest {
+			t.Errorf("unexpected status code: got %v want %v", status, http.StatusBadRequest)
+		}
+
+		todoStoreMock.AssertNumberOfCalls(t, "ListTodos", 0)
+	})
+
+	t.Run("importWithErrors", func(t *testing.T) {
+		todoHandler, todoStoreMock := initTodoHandler()
+		todoStoreMock.On("PostTodo", mock.Anything, mock.MatchedBy(func(todo models.TodoItem) bool {
+			return todo.ExternalID == "b"
+		})).Return(0, todo.ErrExternalIDExists)
+		todoStoreMock.On("PostTodo", mock.Anything, mock.Anything).Return(1, nil)
+
+		body := `{"todo":"a"}` + "\n" + `{"todo":""}` + "\n" + `not json` + "\n" + `{"todo":"b","external_id":"b"}` + "\n"
+		req, err := http.NewRequest("POST", "/todo/import", strings.NewReader(body))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		rr := httptest.NewRecorder()
+		http.HandlerFunc(todoHandler.Import).ServeHTTP(rr, req)
+
+		if status := rr.Code; status != http.StatusOK {
+			t.Errorf("unexpected status code: got %v want %v", status, http.StatusOK)
+			t.FailNow()
+		}
+		var response models.TodoImportResponse
+		if err = json.Unmarshal(rr.Body.Bytes(), &response); err != nil {
+			t.Fatal(err)
+		}
+		if response.Valid != 1 || response.Created != 1 || response.Failed != 3 || len(response.Errors) != 3 {
+			t.Errorf("unexpected response: got %+v", response)
+			t.FailNow()
+		}
+		for i, line := range []int{2, 3, 4} {
+			if response.Errors[i].Line != line {
+				t.Errorf("unexpected error line: got %v want %v", response.Errors[i].Line, line)
+			}
+		}
+
+		todoStoreMock.AssertNumberOfCalls(t, "PostTodo", 2)
+	})
+
+	t.Run("importDryRun", func(t *testing.T) {
+		todoHandler, todoStoreMock := initTodoHandler()
+
+		req, err := http.NewRequest("POST", "/todo/import?format=csv&mode=upsert&dry_run=true",
+			strings.NewReader("external_id,todo\na,test\n,test\n"))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		rr := httptest.NewRecorder()
+		http.HandlerFunc(todoHandler.Import).ServeHTTP(rr, req)
+
+		var response models.TodoImportResponse
+		if err = json.Unmarshal(rr.Body.Bytes(), &response); err != nil {
+			t.Fatal(err)
+		}
+		if !response.DryRun || response.Valid != 1 || response.Failed != 1 || response.Created != 0 {
+			t.Errorf("unexpected response: got %+v", response)
+		}
+
+		todoStoreMock.AssertNumberOfCalls(t, "UpsertTodo", 0)
+	})
+
+	t.Run("importUpsert", func(t *testing.T) {
+		todoHandler, todoStoreMock := initTodoHandler()
+		todoStoreMock.On("UpsertTodo", mock.Anything, mock.MatchedBy(func(todo models.TodoItem) bool {
+			return todo.ExternalID == "a"
+		})).Return(models.TodoItem{ID: 1}, false, nil)
+		todoStoreMock.On("UpsertTodo", mock.Anything, mock.Anything).Return(models.TodoItem{ID: 2}, true, nil)
+
+		req, err := http.NewRequest("POST", "/todo/import?mode=upsert",
+			strings.NewReader(`{"external_id":"a","todo":"test"}`+"\n"+`{"external_id":"b","todo":"test"}`))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		rr := httptest.NewRecorder()
+		http.HandlerFunc(todoHandler.Import).ServeHTTP(rr, req)
+
+		var response models.TodoImportResponse
+		if err = json.Unmarshal(rr.Body.Bytes(), &response); err != nil {
+			t.Fatal(err)
+		}
+		if response.Created != 1 || response.Updated != 1 || response.Failed != 0 {
+			t.Errorf("unexpected response: got %+v", response)
+		}
+
+		todoStoreMock.AssertNumberOfCalls(t, "UpsertTodo", 2)
+	})
+}
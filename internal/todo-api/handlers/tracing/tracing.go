@@ -0,0 +1,53 @@
+package tracing
+
+import (
+	"net/http"
+
+	"github.com/go-chi/chi"
+	"github.com/go-chi/chi/middleware"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
+	semconv "go.opentelemetry.io/otel/semconv/v1.4.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const tracerName = "github.com/alexsniffin/go-api-starter/internal/todo-api/handlers/tracing"
+
+// NewHandlerFunc starts a server span for every request, continuing the trace of the W3C `traceparent` header if
+// present. Spans are named after the matched route so they can be aggregated.
+func NewHandlerFunc(
+	provider trace.TracerProvider,
+	propagator propagation.TextMapPropagator,
+) func(http.Handler) http.Handler {
+	tracer := provider.Tracer(tracerName)
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			ctx := propagator.Extract(r.Context(), propagation.HeaderCarrier(r.Header))
+			ctx, span := tracer.Start(ctx, "HTTP "+r.Method,
+				trace.WithSpanKind(trace.SpanKindServer),
+				trace.WithAttributes(semconv.HTTPServerAttributesFromHTTPRequest("todo-api", "", r)...),
+			)
+			defer span.End()
+
+			ww := middleware.NewWrapResponseWriter(w, r.ProtoMajor)
+			next.ServeHTTP(ww, r.WithContext(ctx))
+
+			if routePattern := chi.RouteContext(r.Context()).RoutePattern(); routePattern != "" {
+				span.SetName(r.Method + " " + routePattern)
+				span.SetAttributes(semconv.HTTPRouteKey.String(routePattern))
+			}
+			status := ww.Status()
+			if status == 0 {
+				status = http.StatusOK
+			}
+			span.SetAttributes(semconv.HTTPStatusCodeKey.Int(status))
+			span.SetStatus(semconv.SpanStatusFromHTTPStatusCode(status))
+		})
+	}
+}
+
+// NewDefaultHandlerFunc traces with the global tracer provider and propagator
+func NewDefaultHandlerFunc() func(http.Handler) http.Handler {
+	return NewHandlerFunc(otel.GetTracerProvider(), otel.GetTextMapPropagator())
+}
@@ -0,0 +1,60 @@
+package tracing
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/go-chi/chi"
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+)
+
+type exportedSpan struct {
+	Name        string
+	SpanContext struct {
+		TraceID string
+	}
+	Parent struct {
+		SpanID string
+	}
+}
+
+func TestNewHandlerFunc(t *testing.T) {
+	var buf bytes.Buffer
+	exporter, err := stdouttrace.New(stdouttrace.WithWriter(&buf))
+	if err != nil {
+		t.Fatal(err)
+	}
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSyncer(exporter))
+
+	r := chi.NewRouter()
+	r.Use(NewHandlerFunc(provider, propagation.TraceContext{}))
+	r.Get("/api/todo/{id}", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+	})
+
+	t.Run("continuesTrace", func(t *testing.T) {
+		buf.Reset()
+		req := httptest.NewRequest(http.MethodGet, "/api/todo/1", nil)
+		req.Header.Set("traceparent", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+		r.ServeHTTP(httptest.NewRecorder(), req)
+
+		var span exportedSpan
+		if err := json.NewDecoder(&buf).Decode(&span); err != nil {
+			t.Fatalf("failed to decode exported span: %v", err)
+		}
+		if span.Name != "GET /api/todo/{id}" {
+			t.Errorf("wrong span name: got %v want %v", span.Name, "GET /api/todo/{id}")
+		}
+		if span.SpanContext.TraceID != "4bf92f3577b34da6a3ce929d0e0e4736" {
+			t.Errorf("wrong trace id: got %v want %v", span.SpanContext.TraceID, "4bf92f3577b34da6a3ce929d0e0e4736")
+		}
+		if span.Parent.SpanID != "00f067aa0ba902b7" {
+			t.Errorf("wrong parent span id: got %v want %v", span.Parent.SpanID, "00f067aa0ba902b7")
+		}
+	})
+}
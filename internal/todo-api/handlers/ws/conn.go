@@ -0,0 +1,224 @@
+package ws
+
+import (
+	"context"
+	"encoding/json"
+	"sync"
+	"time"
+
+	validation "github.com/go-ozzo/ozzo-validation/v4"
+	"github.com/gorilla/websocket"
+	"github.com/rs/zerolog/log"
+
+	"github.com/alexsniffin/go-api-starter/internal/todo-api/events"
+	"github.com/alexsniffin/go-api-starter/internal/todo-api/models"
+)
+
+// conn is a single WebSocket connection. Reads and mutations happen on the handler goroutine, writes on a separate
+// goroutine draining the send buffer, so a slow client never blocks the event hub.
+type conn struct {
+	h      *Handler
+	ws     *websocket.Conn
+	logCtx context.Context
+	userID string
+
+	send chan models.SocketMessage
+	sub  *events.Subscription
+
+	mu    sync.RWMutex
+	lists map[string]struct{}
+
+	closeOnce   sync.Once
+	closeCode   int
+	closeReason string
+	done        chan struct{}
+}
+
+func newConn(h *Handler, ws *websocket.Conn, logCtx context.Context, userID string) *conn {
+	c := &conn{
+		h:      h,
+		ws:     ws,
+		logCtx: logCtx,
+		userID: userID,
+		send:   make(chan models.SocketMessage, h.cfg.SendBufferSize),
+		lists:  make(map[string]struct{}),
+		done:   make(chan struct{}),
+	}
+	c.sub, _, _ = h.hub.Subscribe(c.subscribed, 0)
+
+	return c
+}
+
+// readPump reads messages until the connection is closed by either side
+func (c *conn) readPump() {
+	defer c.close(websocket.CloseNormalClosure, "")
+	go c.forwardEvents()
+
+	pongWait := time.Duration(c.h.cfg.PongWaitSec) * time.Second
+	c.ws.SetReadLimit(c.h.cfg.MaxMessageBytes)
+	_ = c.ws.SetReadDeadline(time.Now().Add(pongWait))
+	c.ws.SetPongHandler(func(string) error {
+		return c.ws.SetReadDeadline(time.Now().Add(pongWait))
+	})
+
+	for {
+		_, data, err := c.ws.ReadMessage()
+		if err != nil {
+			if websocket.IsUnexpectedCloseError(err, websocket.CloseNormalClosure, websocket.CloseGoingAway) {
+				log.Ctx(c.logCtx).Debug().Caller().Err(err).Msg("websocket connection closed unexpectedly")
+			}
+			return
+		}
+
+		var msg models.SocketMessage
+		if err = json.Unmarshal(data, &msg); err != nil {
+			c.enqueue(models.SocketMessage{Type: models.SocketError, Message: "invalid message"})
+			continue
+		}
+		c.handle(msg)
+	}
+}
+
+// writePump writes queued messages and pings until the connection is closed
+func (c *conn) writePump() {
+	writeWait := time.Duration(c.h.cfg.WriteWaitSec) * time.Second
+	ticker := time.NewTicker(time.Duration(c.h.cfg.PingPeriodSec) * time.Second)
+	defer func() {
+		ticker.Stop()
+		_ = c.ws.Close()
+	}()
+
+	for {
+		select {
+		case msg := <-c.send:
+			_ = c.ws.SetWriteDeadline(time.Now().Add(writeWait))
+			if err := c.ws.WriteJSON(msg); err != nil {
+				log.Ctx(c.logCtx).Debug().Caller().Err(err).Msg("failed to write websocket message")
+				c.close(websocket.CloseAbnormalClosure, "")
+				return
+			}
+		case <-ticker.C:
+			_ = c.ws.SetWriteDeadline(time.Now().Add(writeWait))
+			if err := c.ws.WriteMessage(websocket.PingMessage, nil); err != nil {
+				c.close(websocket.CloseAbnormalClosure, "")
+				return
+			}
+		case <-c.done:
+			_ = c.ws.WriteControl(websocket.CloseMessage, websocket.FormatCloseMessage(c.closeCode, c.closeReason),
+				time.Now().Add(writeWait))
+			return
+		}
+	}
+}
+
+// forwardEvents queues the events of the subscribed lists, the hub ends the subscription if the client falls behind
+func (c *conn) forwardEvents() {
+	for event := range c.sub.Events {
+		event := event
+		c.enqueue(models.SocketMessage{Type: models.SocketEvent, Event: &event})
+	}
+	c.close(websocket.CloseTryAgainLater, "event stream ended")
+}
+
+// handle a message from the client, mutations go through the same validation and store as the REST API
+func (c *conn) handle(msg models.SocketMessage) {
+	switch msg.Type {
+	case models.SocketSubscribe, models.SocketUnsubscribe:
+		if err := validation.Validate(msg.List, validation.Required, validation.Length(0, 100)); err != nil {
+			c.reply(msg, 0, err)
+			return
+		}
+
+		c.mu.Lock()
+		if msg.Type == models.SocketSubscribe {
+			c.lists[msg.List] = struct{}{}
+		} else {
+			delete(c.lists, msg.List)
+		}
+		c.mu.Unlock()
+		c.reply(msg, 0, nil)
+	case models.SocketCreate:
+		if msg.Todo == nil {
+			msg.Todo = &models.TodoPostRequest{}
+		}
+		if err := msg.Todo.IsValid(); err != nil {
+			c.reply(msg, 0, err)
+			return
+		}
+
+		ctx, cancel := c.mutationCtx()
+		defer cancel()
+
+		id, err := c.h.store.PostTodo(ctx, msg.Todo.TodoItem(c.userID))
+		if err != nil {
+			log.Ctx(c.logCtx).Error().Caller().Err(err).Msg("failed to insert todo record")
+			c.reply(msg, 0, errInternal)
+			return
+		}
+		c.reply(msg, id, nil)
+	case models.SocketDelete:
+		if err := validation.Validate(msg.ID, validation.Required, validation.Min(1)); err != nil {
+			c.reply(msg, 0, err)
+			return
+		}
+
+		ctx, cancel := c.mutationCtx()
+		defer cancel()
+
+		count, err := c.h.store.DeleteTodo(ctx, msg.ID)
+		if err != nil {
+			log.Ctx(c.logCtx).Error().Caller().Err(err).Msg("failed to delete todo")
+			c.reply(msg, 0, errInternal)
+			return
+		}
+		if count == 0 {
+			c.reply(msg, 0, errNotFound)
+			return
+		}
+		c.reply(msg, msg.ID, nil)
+	default:
+		c.reply(msg, 0, errUnknownType)
+	}
+}
+
+func (c *conn) reply(msg models.SocketMessage, id int, err error) {
+	if err != nil {
+		c.enqueue(models.SocketMessage{Type: models.SocketError, RequestID: msg.RequestID, Message: err.Error()})
+		return
+	}
+	c.enqueue(models.SocketMessage{Type: models.SocketAck, RequestID: msg.RequestID, ID: id, List: msg.List})
+}
+
+// enqueue a message without blocking, the connection is closed if its send buffer is full
+func (c *conn) enqueue(msg models.SocketMessage) {
+	select {
+	case <-c.done:
+	case c.send <- msg:
+	default:
+		log.Ctx(c.logCtx).Debug().Caller().Msg("websocket send buffer full, closing connection")
+		c.close(websocket.CloseTryAgainLater, "send buffer full")
+	}
+}
+
+// subscribed is the hub Filter of the connection
+func (c *conn) subscribed(event models.TodoEvent) bool {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	_, ok := c.lists[event.List]
+	return ok
+}
+
+func (c *conn) mutationCtx() (context.Context, context.CancelFunc) {
+	return context.WithTimeout(c.logCtx, time.Duration(c.h.cfg.MutationTimeoutSec)*time.Second)
+}
+
+// close signals the write pump to send a close frame and close the connection
+func (c *conn) close(code int, reason string) {
+	c.closeOnce.Do(func() {
+		c.closeCode = code
+		c.closeReason = reason
+		close(c.done)
+		c.sub.Close()
+	})
+}
@@ -0,0 +1,147 @@
+package ws
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+	"sync"
+
+	"github.com/gorilla/websocket"
+	"github.com/rs/zerolog"
+
+	"github.com/alexsniffin/go-api-starter/internal/todo-api/events"
+	"github.com/alexsniffin/go-api-starter/internal/todo-api/models"
+	"github.com/alexsniffin/go-api-starter/internal/todo-api/store/todo"
+	"github.com/alexsniffin/go-api-starter/internal/todo-api/utils"
+)
+
+var (
+	errInternal    = errors.New("internal server error with request")
+	errNotFound    = errors.New("todo not found")
+	errUnknownType = errors.New("unknown message type")
+)
+
+type Handler struct {
+	cfg    models.WebSocketConfig
+	logger zerolog.Logger
+
+	upgrader *websocket.Upgrader
+	store    todo.TodoStore
+	hub      *events.Hub
+	conns    *connections
+}
+
+// connections tracks the open connections, hijacked connections aren't closed by the HTTP server on shutdown
+type connections struct {
+	mu     sync.Mutex
+	set    map[*conn]struct{}
+	closed bool
+	wg     sync.WaitGroup
+}
+
+// Creates WebSocket handler, the allowed origins are read on every upgrade so they can be reloaded
+func NewHandler(
+	cfg models.WebSocketConfig,
+	allowedOrigins func() []string,
+	logger zerolog.Logger,
+	store todo.Store,
+	hub *events.Hub,
+) Handler {
+	return Handler{
+		cfg:    cfg,
+		logger: logger,
+
+		upgrader: &websocket.Upgrader{
+			CheckOrigin: func(r *http.Request) bool {
+				return isAllowedOrigin(r.Header.Get("Origin"), allowedOrigins())
+			},
+		},
+		store: &store,
+		hub:   hub,
+		conns: &connections{
+			set: make(map[*conn]struct{}),
+		},
+	}
+}
+
+// Handle HTTP Get upgrading to a WebSocket connection for subscribing to and mutating todo lists
+func (h *Handler) Connect(w http.ResponseWriter, r *http.Request) {
+	ws, err := h.upgrader.Upgrade(w, r, nil)
+	if err != nil {
+		h.logger.Debug().Caller().Err(err).Msg("failed to upgrade websocket connection")
+		return
+	}
+
+	c := newConn(h, ws, utils.GetSubLoggerCtx(h.logger, r.Context()), utils.UserFromCtx(r.Context()))
+	if !h.conns.add(c) {
+		c.close(websocket.CloseGoingAway, "server shutting down")
+		c.writePump()
+		return
+	}
+	defer h.conns.remove(c)
+
+	writeDone := make(chan struct{})
+	go func() {
+		c.writePump()
+		close(writeDone)
+	}()
+
+	c.readPump()
+	<-writeDone
+}
+
+// Shutdown closes all connections and waits for them to finish or the context to be done.
+func (h *Handler) Shutdown(ctx context.Context) error {
+	h.conns.mu.Lock()
+	h.conns.closed = true
+	for c := range h.conns.set {
+		c.close(websocket.CloseGoingAway, "server shutting down")
+	}
+	h.conns.mu.Unlock()
+
+	done := make(chan struct{})
+	go func() {
+		h.conns.wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (cs *connections) add(c *conn) bool {
+	cs.mu.Lock()
+	defer cs.mu.Unlock()
+
+	if cs.closed {
+		return false
+	}
+	cs.set[c] = struct{}{}
+	cs.wg.Add(1)
+	return true
+}
+
+func (cs *connections) remove(c *conn) {
+	cs.mu.Lock()
+	defer cs.mu.Unlock()
+
+	delete(cs.set, c)
+	cs.wg.Done()
+}
+
+func isAllowedOrigin(origin string, allowedOrigins []string) bool {
+	if origin == "" {
+		return true
+	}
+	for i := 0; i < len(allowedOrigins); i++ {
+		if allowedOrigins[i] == "*" || strings.EqualFold(allowedOrigins[i], origin) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,131 @@
+package ws
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/mock"
+
+	"github.com/alexsniffin/go-api-starter/internal/todo-api/events"
+	"github.com/alexsniffin/go-api-starter/internal/todo-api/models"
+	"github.com/alexsniffin/go-api-starter/mocks"
+)
+
+func initWsHandler(t *testing.T) (*Handler, *mocks.TodoStore, *events.Hub, *websocket.Conn) {
+	todoStoreMock := mocks.TodoStore{}
+	hub := events.NewHub(models.EventsConfig{LogSize: 10, BufferSize: 10})
+	wsHandler := &Handler{
+		cfg: models.WebSocketConfig{
+			SendBufferSize:     10,
+			MaxMessageBytes:    1024,
+			PingPeriodSec:      30,
+			PongWaitSec:        60,
+			WriteWaitSec:       10,
+			MutationTimeoutSec: 10,
+		},
+		logger:   zerolog.New(os.Stdout),
+		upgrader: &websocket.Upgrader{},
+		store:    &todoStoreMock,
+		hub:      hub,
+		conns:    &connections{set: make(map[*conn]struct{})},
+	}
+
+	server := httptest.NewServer(http.HandlerFunc(wsHandler.Connect))
+	t.Cleanup(server.Close)
+
+	client, _, err := websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(server.URL, "http"), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { client.Close() })
+
+	return wsHandler, &todoStoreMock, hub, client
+}
+
+func roundTrip(t *testing.T, client *websocket.Conn, msg models.SocketMessage) models.SocketMessage {
+	if err := client.WriteJSON(msg); err != nil {
+		t.Fatal(err)
+	}
+	return read(t, client)
+}
+
+func read(t *testing.T, client *websocket.Conn) models.SocketMessage {
+	var reply models.SocketMessage
+	_ = client.SetReadDeadline(time.Now().Add(5 * time.Second))
+	if err := client.ReadJSON(&reply); err != nil {
+		t.Fatal(err)
+	}
+	return reply
+}
+
+func TestWsHandler(t *testing.T) {
+	t.Run("createTodo", func(t *testing.T) {
+		_, todoStoreMock, _, client := initWsHandler(t)
+		todoStoreMock.On("PostTodo", mock.Anything, mock.MatchedBy(func(todo models.TodoItem) bool {
+			return todo.Todo == "test" && todo.List == "groceries"
+		})).Return(1, nil)
+
+		reply := roundTrip(t, client, models.SocketMessage{
+			Type:      models.SocketCreate,
+			RequestID: "r1",
+			Todo:      &models.TodoPostRequest{Todo: "test", List: "groceries"},
+		})
+
+		if reply.Type != models.SocketAck || reply.RequestID != "r1" || reply.ID != 1 {
+			t.Errorf("unexpected reply: %+v", reply)
+		}
+		todoStoreMock.AssertExpectations(t)
+	})
+
+	t.Run("invalidTodo", func(t *testing.T) {
+		_, todoStoreMock, _, client := initWsHandler(t)
+
+		reply := roundTrip(t, client, models.SocketMessage{Type: models.SocketCreate, RequestID: "r1"})
+
+		expected := "todo: cannot be blank."
+		if reply.Type != models.SocketError || reply.Message != expected {
+			t.Errorf("unexpected reply: %+v", reply)
+		}
+		todoStoreMock.AssertNotCalled(t, "PostTodo", mock.Anything, mock.Anything)
+	})
+
+	t.Run("subscribedEvents", func(t *testing.T) {
+		_, _, hub, client := initWsHandler(t)
+
+		reply := roundTrip(t, client, models.SocketMessage{Type: models.SocketSubscribe, List: "groceries"})
+		if reply.Type != models.SocketAck {
+			t.Fatalf("unexpected reply: %+v", reply)
+		}
+
+		_ = hub.Publish(context.Background(), models.TodoEvent{ID: 1, List: "chores"})
+		_ = hub.Publish(context.Background(), models.TodoEvent{ID: 2, List: "groceries"})
+
+		event := read(t, client)
+		if event.Type != models.SocketEvent || event.Event == nil || event.Event.ID != 2 {
+			t.Errorf("unexpected event: %+v", event)
+		}
+	})
+
+	t.Run("shutdownClosesConnections", func(t *testing.T) {
+		wsHandler, _, _, client := initWsHandler(t)
+		roundTrip(t, client, models.SocketMessage{Type: models.SocketSubscribe, List: "groceries"})
+
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		if err := wsHandler.Shutdown(ctx); err != nil {
+			t.Fatal(err)
+		}
+
+		_, _, err := client.ReadMessage()
+		if !websocket.IsCloseError(err, websocket.CloseGoingAway) {
+			t.Errorf("unexpected error: %v", err)
+		}
+	})
+}
@@ -0,0 +1,158 @@
+package health
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/alexsniffin/go-api-starter/internal/todo-api/models"
+)
+
+// Probe is a set of checks answering one question about the server
+type Probe string
+
+const (
+	// Liveness fails if the server is broken beyond recovering by itself and should be restarted
+	Liveness Probe = "livez"
+	// Readiness fails if the server can't serve traffic right now
+	Readiness Probe = "readyz"
+	// Startup fails until the server finished starting
+	Startup Probe = "startupz"
+)
+
+// CheckFunc returns an error if the checked dependency is unhealthy
+type CheckFunc func(ctx context.Context) error
+
+// Checks is the registry of health checks. Checks run concurrently, each bounded by `TimeoutMs`, and their results are
+// cached for `CacheTTLMs` so probes don't put load on the dependencies.
+type Checks struct {
+	cfg models.HealthConfig
+
+	mu     sync.RWMutex
+	checks []*check
+}
+
+type check struct {
+	name   string
+	fn     CheckFunc
+	probes map[Probe]struct{}
+	warn   bool
+
+	mu     sync.Mutex
+	result models.HealthCheckResult
+}
+
+// NewChecks creates an empty registry of health checks
+func NewChecks(cfg models.HealthConfig) *Checks {
+	return &Checks{
+		cfg: cfg,
+	}
+}
+
+// Register a check to the probes it's part of
+func (c *Checks) Register(name string, fn CheckFunc, probes ...Probe) {
+	c.register(name, fn, false, probes)
+}
+
+// RegisterWarning registers a check which is reported as a warning if it fails, without failing the probes, e.g. for
+// conditions shared by all replicas which restarting or removing one of them doesn't fix
+func (c *Checks) RegisterWarning(name string, fn CheckFunc, probes ...Probe) {
+	c.register(name, fn, true, probes)
+}
+
+func (c *Checks) register(name string, fn CheckFunc, warn bool, probes []Probe) {
+	newCheck := &check{
+		name:   name,
+		fn:     fn,
+		probes: make(map[Probe]struct{}, len(probes)),
+		warn:   warn,
+	}
+	for i := 0; i < len(probes); i++ {
+		newCheck.probes[probes[i]] = struct{}{}
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.checks = append(c.checks, newCheck)
+}
+
+// Run the checks of the probe, or return their cached results
+func (c *Checks) Run(ctx context.Context, probe Probe) models.HealthReport {
+	c.mu.RLock()
+	var checks []*check
+	for i := 0; i < len(c.checks); i++ {
+		if _, ok := c.checks[i].probes[probe]; ok {
+			checks = append(checks, c.checks[i])
+		}
+	}
+	c.mu.RUnlock()
+
+	results := make([]models.HealthCheckResult, len(checks))
+	var wg sync.WaitGroup
+	for i := 0; i < len(checks); i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			results[i] = c.run(ctx, checks[i])
+		}(i)
+	}
+	wg.Wait()
+
+	report := models.HealthReport{
+		Status: models.HealthOK,
+		Checks: make(map[string]models.HealthCheckResult, len(checks)),
+	}
+	for i := 0; i < len(checks); i++ {
+		report.Checks[checks[i].name] = results[i]
+		if results[i].Status == models.HealthFail {
+			report.Status = models.HealthFail
+		}
+	}
+	return report
+}
+
+// run the check unless its cached result is still fresh, concurrent probes wait for the same run
+func (c *Checks) run(ctx context.Context, chk *check) models.HealthCheckResult {
+	chk.mu.Lock()
+	defer chk.mu.Unlock()
+
+	if !chk.result.CheckedOn.IsZero() &&
+		time.Since(chk.result.CheckedOn) < time.Duration(c.cfg.CacheTTLMs)*time.Millisecond {
+		return chk.result
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, time.Duration(c.cfg.TimeoutMs)*time.Millisecond)
+	defer cancel()
+
+	start := time.Now()
+	errCh := make(chan error, 1)
+	go func() {
+		errCh <- chk.fn(ctx)
+	}()
+
+	var err error
+	select {
+	case err = <-errCh:
+	case <-ctx.Done():
+		err = ctx.Err()
+	}
+
+	result := models.HealthCheckResult{
+		Status:     models.HealthOK,
+		DurationMs: time.Since(start).Milliseconds(),
+		CheckedOn:  start,
+	}
+	if err != nil {
+		result.Status = models.HealthFail
+		if chk.warn {
+			result.Status = models.HealthWarn
+		}
+		result.Error = err.Error()
+	}
+
+	// a cancelled probe says nothing about the dependency, so its result isn't cached
+	if ctx.Err() == nil || ctx.Err() == context.DeadlineExceeded {
+		chk.result = result
+	}
+	return result
+}
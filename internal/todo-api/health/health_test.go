@@ -0,0 +1,74 @@
+package health
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/alexsniffin/go-api-starter/internal/todo-api/models"
+)
+
+func TestChecks(t *testing.T) {
+	t.Run("failedCheck", func(t *testing.T) {
+		checks := NewChecks(models.HealthConfig{TimeoutMs: 1000})
+		checks.Register("postgres", func(context.Context) error { return errors.New("connection refused") }, Readiness)
+		checks.Register("processes", func(context.Context) error { return nil }, Liveness)
+
+		report := checks.Run(context.Background(), Readiness)
+		if report.Status != models.HealthFail || report.Checks["postgres"].Error != "connection refused" {
+			t.Errorf("wrong readiness report: %+v", report)
+		}
+		if _, ok := report.Checks["processes"]; ok {
+			t.Errorf("readiness report contains liveness check: %+v", report)
+		}
+
+		report = checks.Run(context.Background(), Liveness)
+		if report.Status != models.HealthOK {
+			t.Errorf("wrong liveness report: %+v", report)
+		}
+	})
+
+	t.Run("warning", func(t *testing.T) {
+		checks := NewChecks(models.HealthConfig{TimeoutMs: 1000})
+		checks.RegisterWarning("outbox_lag", func(context.Context) error { return errors.New("lagging") }, Readiness)
+
+		report := checks.Run(context.Background(), Readiness)
+		if report.Status != models.HealthOK || report.Checks["outbox_lag"].Status != models.HealthWarn {
+			t.Errorf("wrong readiness report: %+v", report)
+		}
+	})
+
+	t.Run("slowCheckTimeout", func(t *testing.T) {
+		checks := NewChecks(models.HealthConfig{TimeoutMs: 10})
+		checks.Register("postgres", func(ctx context.Context) error {
+			<-ctx.Done()
+			time.Sleep(100 * time.Millisecond)
+			return nil
+		}, Readiness)
+
+		start := time.Now()
+		report := checks.Run(context.Background(), Readiness)
+		if report.Status != models.HealthFail || report.Checks["postgres"].Error != context.DeadlineExceeded.Error() {
+			t.Errorf("wrong readiness report: %+v", report)
+		}
+		if time.Since(start) > 50*time.Millisecond {
+			t.Errorf("probe waited for the slow check: %v", time.Since(start))
+		}
+	})
+
+	t.Run("cachedResults", func(t *testing.T) {
+		calls := 0
+		checks := NewChecks(models.HealthConfig{TimeoutMs: 1000, CacheTTLMs: 60000})
+		checks.Register("postgres", func(context.Context) error {
+			calls++
+			return nil
+		}, Readiness, Startup)
+
+		checks.Run(context.Background(), Readiness)
+		checks.Run(context.Background(), Startup)
+		if calls != 1 {
+			t.Errorf("check wasn't cached: got %v calls want %v", calls, 1)
+		}
+	})
+}
@@ -0,0 +1,26 @@
+package metrics
+
+import (
+	"net/http"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+// Registry holds the process wide metrics of the service, exposed by Handler. It's used instead of the default
+// registry so only the collectors registered by the service are exposed. Collectors of a single server, such as its
+// pool and HTTP metrics, are registered with a registry owned by the server instead, so a server can be created more
+// than once per process.
+var Registry = prometheus.NewRegistry()
+
+func init() {
+	Registry.MustRegister(
+		prometheus.NewGoCollector(),
+		prometheus.NewProcessCollector(prometheus.ProcessCollectorOpts{}),
+	)
+}
+
+// Handler serves the metrics of Registry together with those of the registry of a server
+func Handler(serverRegistry prometheus.Gatherer) http.Handler {
+	return promhttp.HandlerFor(prometheus.Gatherers{Registry, serverRegistry}, promhttp.HandlerOpts{})
+}
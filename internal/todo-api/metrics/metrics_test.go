@@ -0,0 +1,31 @@
+package metrics
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+func TestHandler(t *testing.T) {
+	t.Run("serverRegistries", func(t *testing.T) {
+		for _, name := range []string{"first", "second"} {
+			registry := prometheus.NewRegistry()
+			registry.MustRegister(prometheus.NewGauge(prometheus.GaugeOpts{Name: "todo_test_server"}))
+
+			res := httptest.NewRecorder()
+			Handler(registry).ServeHTTP(res, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+
+			body, _ := ioutil.ReadAll(res.Body)
+			if res.Code != http.StatusOK {
+				t.Errorf("unexpected status of %v server: got %v want %v", name, res.Code, http.StatusOK)
+			}
+			if !strings.Contains(string(body), "todo_test_server") || !strings.Contains(string(body), "go_goroutines") {
+				t.Errorf("unexpected metrics of %v server: %s", name, body)
+			}
+		}
+	})
+}
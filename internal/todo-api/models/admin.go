@@ -0,0 +1,60 @@
+package models
+
+import (
+	"time"
+
+	validation "github.com/go-ozzo/ozzo-validation/v4"
+	"github.com/rs/zerolog"
+)
+
+// maxTTLSec of log level changes and debug tokens
+const maxTTLSec = 24 * 60 * 60
+
+// BuildInfo response model of the build details of the service
+type BuildInfo struct {
+	Version   string `json:"version"`
+	Commit    string `json:"commit"`
+	BuildDate string `json:"build_date"`
+	GoVersion string `json:"go_version"`
+}
+
+// LogLevelRequest request model to PUT the log level, the level is reverted after `ttl_sec` or the configured ttl
+type LogLevelRequest struct {
+	Level  string `json:"level"`
+	TTLSec int    `json:"ttl_sec,omitempty"`
+}
+
+func (l *LogLevelRequest) IsValid() error {
+	return validation.ValidateStruct(l,
+		validation.Field(&l.Level, validation.Required, validation.By(func(value interface{}) error {
+			_, err := zerolog.ParseLevel(value.(string))
+			return err
+		})),
+		validation.Field(&l.TTLSec, validation.Min(0), validation.Max(maxTTLSec)),
+	)
+}
+
+// LogLevelResponse response model of the log level, `revert_on` is omitted if it's the configured level
+type LogLevelResponse struct {
+	Level    string     `json:"level"`
+	RevertOn *time.Time `json:"revert_on,omitempty"`
+}
+
+// DebugTokenRequest request model to POST a debug token valid for `ttl_sec`
+type DebugTokenRequest struct {
+	TTLSec int `json:"ttl_sec"`
+}
+
+func (d *DebugTokenRequest) IsValid() error {
+	return validation.ValidateStruct(d,
+		validation.Field(&d.TTLSec, validation.Required, validation.Min(1), validation.Max(maxTTLSec)),
+	)
+}
+
+// DebugTokenResponse response model to POST a debug token, requests sending the token in the header are logged at
+// debug level
+type DebugTokenResponse struct {
+	Header    string    `json:"header"`
+	Token     string    `json:"token"`
+	ExpiresOn time.Time `json:"expires_on"`
+}
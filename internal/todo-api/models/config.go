@@ -1,26 +1,260 @@
 package models
 
 import (
+	"errors"
+	"net"
+	"net/url"
+	"os"
+	"strconv"
+
+	validation "github.com/go-ozzo/ozzo-validation/v4"
+
 	"github.com/alexsniffin/go-api-starter/pkg/models"
 )
 
+var portRules = []validation.Rule{validation.Required, validation.Min(1), validation.Max(65535)}
+
 type Config struct {
 	Environment string
 	Logger      models.Logger
 	HTTPServer  HTTPServerConfig
+	AdminServer AdminServerConfig
+	GRPCServer  GRPCServerConfig
 	HTTPRouter  HTTPRouterConfig
+	REST        RESTConfig
 	Database    DatabaseConfig
+	Outbox      OutboxConfig
+	ChangeFeed  ChangeFeedConfig
+	Events      EventsConfig
+	WebSocket   WebSocketConfig
+	GraphQL     GraphQLConfig
+	Supervisor  SupervisorConfig
+	Shutdown    ShutdownConfig
+	Health      HealthConfig
+	Tracing     TracingConfig
+}
+
+// Validate reports the errors of every section of the config together
+func (c Config) Validate() error {
+	return validation.ValidateStruct(&c,
+		validation.Field(&c.Environment, validation.Required),
+		validation.Field(&c.Logger),
+		validation.Field(&c.HTTPServer),
+		validation.Field(&c.AdminServer, validation.By(func(interface{}) error {
+			if c.AdminServer.Port == c.HTTPServer.Port || c.AdminServer.Port == c.GRPCServer.Port {
+				return errors.New("port must differ from the ports of the other servers")
+			}
+			return nil
+		})),
+		validation.Field(&c.GRPCServer, validation.By(func(interface{}) error {
+			if c.GRPCServer.Port == c.HTTPServer.Port {
+				return errors.New("port must differ from the port of the http server")
+			}
+			return nil
+		})),
+		validation.Field(&c.HTTPRouter),
+		validation.Field(&c.REST),
+		validation.Field(&c.Database),
+		validation.Field(&c.Outbox),
+		validation.Field(&c.ChangeFeed),
+		validation.Field(&c.Events),
+		validation.Field(&c.WebSocket),
+		validation.Field(&c.GraphQL),
+		validation.Field(&c.Supervisor),
+		validation.Field(&c.Shutdown),
+		validation.Field(&c.Health),
+		validation.Field(&c.Tracing),
+	)
 }
 
 type HTTPServerConfig struct {
+	Port                 int
+	Listeners            []string
+	ReadTimeoutSec       int
+	ReadHeaderTimeoutSec int
+	WriteTimeoutSec      int
+	IdleTimeoutSec       int
+	H2C                  bool
+	TLS                  TLSConfig
+}
+
+func (c HTTPServerConfig) Validate() error {
+	return validation.ValidateStruct(&c,
+		validation.Field(&c.Port, validation.When(len(c.Listeners) == 0, portRules...).
+			Else(validation.Min(0), validation.Max(65535))),
+		validation.Field(&c.Listeners, validation.Each(validation.By(func(value interface{}) error {
+			_, err := ParseListener(value.(string))
+			return err
+		}))),
+		validation.Field(&c.ReadTimeoutSec, validation.Min(0)),
+		validation.Field(&c.ReadHeaderTimeoutSec, validation.Min(0)),
+		validation.Field(&c.WriteTimeoutSec, validation.Min(0)),
+		validation.Field(&c.IdleTimeoutSec, validation.Min(0)),
+		validation.Field(&c.H2C, validation.When(c.TLS.Enabled(), validation.Empty.
+			Error("is only for plaintext, HTTP/2 is negotiated with TLS"))),
+		validation.Field(&c.TLS),
+	)
+}
+
+// Networks of a Listener
+const (
+	ListenerTCP     = "tcp"
+	ListenerUnix    = "unix"
+	ListenerSystemd = "systemd"
+)
+
+// Listener of the HTTP server
+type Listener struct {
+	Network string
+	// Address is the host and port of tcp or the path of the unix socket
+	Address string
+	// Mode of the unix socket file, the default of the umask if 0
+	Mode os.FileMode
+	// Name of the sockets activated by systemd, every socket if empty
+	Name string
+}
+
+// ParseListener parses `tcp://host:port`, `unix:///path` with an optional octal `mode` of the socket file, or
+// `systemd` with an optional name of the activated sockets, e.g. `systemd://http`
+func ParseListener(spec string) (Listener, error) {
+	if spec == ListenerSystemd {
+		return Listener{Network: ListenerSystemd}, nil
+	}
+	u, err := url.Parse(spec)
+	if err != nil {
+		return Listener{}, err
+	}
+
+	switch u.Scheme {
+	case ListenerTCP:
+		if _, _, err = net.SplitHostPort(u.Host); err != nil {
+			return Listener{}, err
+		}
+		return Listener{Network: ListenerTCP, Address: u.Host}, nil
+	case ListenerUnix:
+		if u.Host != "" {
+			return Listener{}, errors.New("unix listener requires an absolute path, e.g. unix:///run/todo-api.sock")
+		}
+		if u.Path == "" {
+			return Listener{}, errors.New("unix listener requires a path")
+		}
+		listener := Listener{Network: ListenerUnix, Address: u.Path}
+		if mode := u.Query().Get("mode"); mode != "" {
+			m, err := strconv.ParseUint(mode, 8, 32)
+			if err != nil {
+				return Listener{}, errors.New("mode of a unix listener must be octal")
+			}
+			listener.Mode = os.FileMode(m)
+		}
+		return listener, nil
+	case ListenerSystemd:
+		return Listener{Network: ListenerSystemd, Name: u.Host}, nil
+	}
+	return Listener{}, errors.New("must be tcp://host:port, unix:///path or systemd")
+}
+
+// Client authentication modes of TLSConfig
+const (
+	ClientAuthNone          = "none"
+	ClientAuthRequest       = "request"
+	ClientAuthVerifyIfGiven = "verify-if-given"
+	ClientAuthRequire       = "require"
+)
+
+type TLSConfig struct {
+	CertFile       string
+	KeyFile        string
+	ClientCAFile   string
+	ClientAuth     string
+	ClientIdentity string
+}
+
+// Enabled is true if a certificate is configured
+func (c TLSConfig) Enabled() bool {
+	return c.CertFile != ""
+}
+
+func (c TLSConfig) Validate() error {
+	verified := c.ClientAuth == ClientAuthVerifyIfGiven || c.ClientAuth == ClientAuthRequire
+	return validation.ValidateStruct(&c,
+		validation.Field(&c.KeyFile, validation.When(c.Enabled(), validation.Required)),
+		validation.Field(&c.ClientCAFile, validation.When(verified, validation.Required)),
+		validation.Field(&c.ClientAuth, validation.In(ClientAuthNone, ClientAuthRequest, ClientAuthVerifyIfGiven,
+			ClientAuthRequire), validation.When(c.ClientAuth != "" && c.ClientAuth != ClientAuthNone,
+			validation.By(func(interface{}) error {
+				if !c.Enabled() {
+					return errors.New("requires a CertFile")
+				}
+				return nil
+			}))),
+		validation.Field(&c.ClientIdentity, validation.In("cn", "email", "uri"),
+			validation.When(c.ClientIdentity != "" && !verified, validation.Empty.
+				Error("requires verified client certificates, ClientAuth must be verify-if-given or require"))),
+	)
+}
+
+type AdminServerConfig struct {
+	Port int
+}
+
+func (c AdminServerConfig) Validate() error {
+	return validation.ValidateStruct(&c,
+		validation.Field(&c.Port, portRules...),
+	)
+}
+
+type GRPCServerConfig struct {
 	Port int
 }
 
+func (c GRPCServerConfig) Validate() error {
+	return validation.ValidateStruct(&c,
+		validation.Field(&c.Port, portRules...),
+	)
+}
+
 type HTTPRouterConfig struct {
 	TimeoutSec     int
+	UserHeader     string
 	AllowedOrigins []string
 	AllowedMethods []string
 	AllowedHeaders []string
+	Compression    CompressionConfig
+}
+
+func (c HTTPRouterConfig) Validate() error {
+	return validation.ValidateStruct(&c,
+		validation.Field(&c.TimeoutSec, validation.Required, validation.Min(1)),
+		validation.Field(&c.AllowedMethods, validation.Each(validation.In("GET", "HEAD", "POST", "PUT", "PATCH",
+			"DELETE", "OPTIONS"))),
+		validation.Field(&c.Compression),
+	)
+}
+
+type CompressionConfig struct {
+	Encodings    []string
+	MinSizeBytes int
+	ContentTypes []string
+}
+
+func (c CompressionConfig) Validate() error {
+	return validation.ValidateStruct(&c,
+		validation.Field(&c.Encodings, validation.Each(validation.In("br", "zstd", "gzip"))),
+		validation.Field(&c.MinSizeBytes, validation.Min(0)),
+		validation.Field(&c.ContentTypes, validation.Each(validation.Required)),
+	)
+}
+
+type RESTConfig struct {
+	MaxBodyBytes   int64
+	MaxImportBytes int64
+}
+
+func (c RESTConfig) Validate() error {
+	return validation.ValidateStruct(&c,
+		validation.Field(&c.MaxBodyBytes, validation.Required, validation.Min(int64(1))),
+		validation.Field(&c.MaxImportBytes, validation.Required, validation.Min(int64(1))),
+	)
 }
 
 type DatabaseConfig struct {
@@ -28,7 +262,149 @@ type DatabaseConfig struct {
 	Port        int
 	User        string
 	DbName      string
-	Password    string
+	Password    string `secret:"true"`
 	Tables      []string
 	CreateTable bool
 }
+
+func (c DatabaseConfig) Validate() error {
+	return validation.ValidateStruct(&c,
+		validation.Field(&c.Host, validation.Required),
+		validation.Field(&c.Port, portRules...),
+		validation.Field(&c.User, validation.Required),
+		validation.Field(&c.DbName, validation.Required),
+	)
+}
+
+type OutboxConfig struct {
+	PollIntervalMs int
+	BatchSize      int
+}
+
+func (c OutboxConfig) Validate() error {
+	return validation.ValidateStruct(&c,
+		validation.Field(&c.PollIntervalMs, validation.Required, validation.Min(1)),
+		validation.Field(&c.BatchSize, validation.Required, validation.Min(1), validation.Max(10000)),
+	)
+}
+
+type ChangeFeedConfig struct {
+	MaxBackoffMs int
+	BackfillSize int
+}
+
+func (c ChangeFeedConfig) Validate() error {
+	return validation.ValidateStruct(&c,
+		validation.Field(&c.MaxBackoffMs, validation.Required, validation.Min(1)),
+		validation.Field(&c.BackfillSize, validation.Required, validation.Min(1)),
+	)
+}
+
+type EventsConfig struct {
+	LogSize      int
+	BufferSize   int
+	HeartbeatSec int
+	RetryMs      int
+}
+
+func (c EventsConfig) Validate() error {
+	return validation.ValidateStruct(&c,
+		validation.Field(&c.LogSize, validation.Min(0)),
+		validation.Field(&c.BufferSize, validation.Required, validation.Min(1)),
+		validation.Field(&c.HeartbeatSec, validation.Required, validation.Min(1)),
+		validation.Field(&c.RetryMs, validation.Min(0)),
+	)
+}
+
+type WebSocketConfig struct {
+	SendBufferSize     int
+	MaxMessageBytes    int64
+	PingPeriodSec      int
+	PongWaitSec        int
+	WriteWaitSec       int
+	MutationTimeoutSec int
+}
+
+func (c WebSocketConfig) Validate() error {
+	return validation.ValidateStruct(&c,
+		validation.Field(&c.SendBufferSize, validation.Required, validation.Min(1)),
+		validation.Field(&c.MaxMessageBytes, validation.Required, validation.Min(int64(1))),
+		validation.Field(&c.PingPeriodSec, validation.Required, validation.Min(1)),
+		validation.Field(&c.PongWaitSec, validation.Required, validation.Min(c.PingPeriodSec+1).
+			Error("must be greater than PingPeriodSec")),
+		validation.Field(&c.WriteWaitSec, validation.Required, validation.Min(1)),
+		validation.Field(&c.MutationTimeoutSec, validation.Required, validation.Min(1)),
+	)
+}
+
+type GraphQLConfig struct {
+	MaxDepth      int
+	MaxComplexity int
+	HeartbeatSec  int
+}
+
+func (c GraphQLConfig) Validate() error {
+	return validation.ValidateStruct(&c,
+		validation.Field(&c.MaxDepth, validation.Required, validation.Min(1)),
+		validation.Field(&c.MaxComplexity, validation.Required, validation.Min(1)),
+		validation.Field(&c.HeartbeatSec, validation.Required, validation.Min(1)),
+	)
+}
+
+type SupervisorConfig struct {
+	MaxRestarts  int
+	MaxBackoffMs int
+}
+
+func (c SupervisorConfig) Validate() error {
+	return validation.ValidateStruct(&c,
+		validation.Field(&c.MaxRestarts, validation.Min(0)),
+		validation.Field(&c.MaxBackoffMs, validation.Required, validation.Min(1)),
+	)
+}
+
+type ShutdownConfig struct {
+	TimeoutSec    int
+	DrainDelaySec int
+}
+
+func (c ShutdownConfig) Validate() error {
+	return validation.ValidateStruct(&c,
+		validation.Field(&c.TimeoutSec, validation.Required, validation.Min(1)),
+		validation.Field(&c.DrainDelaySec, validation.Min(0), validation.Max(c.TimeoutSec-1).
+			Error("must be less than TimeoutSec")),
+	)
+}
+
+type HealthConfig struct {
+	TimeoutMs       int
+	CacheTTLMs      int
+	MaxOutboxLagSec int
+}
+
+func (c HealthConfig) Validate() error {
+	return validation.ValidateStruct(&c,
+		validation.Field(&c.TimeoutMs, validation.Required, validation.Min(1)),
+		validation.Field(&c.CacheTTLMs, validation.Min(0)),
+		validation.Field(&c.MaxOutboxLagSec, validation.Required, validation.Min(1)),
+	)
+}
+
+type TracingConfig struct {
+	ServiceName string
+	Exporter    string
+	Endpoint    string
+	Insecure    bool
+	Sampler     string
+	SampleRatio float64
+}
+
+func (c TracingConfig) Validate() error {
+	return validation.ValidateStruct(&c,
+		validation.Field(&c.ServiceName, validation.Required),
+		validation.Field(&c.Exporter, validation.In("otlp", "stdout", "none")),
+		validation.Field(&c.Endpoint, validation.When(c.Exporter == "otlp", validation.Required)),
+		validation.Field(&c.Sampler, validation.In("always", "never", "ratio")),
+		validation.Field(&c.SampleRatio, validation.Min(0.0), validation.Max(1.0)),
+	)
+}
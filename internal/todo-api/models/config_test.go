@@ -0,0 +1,54 @@
+package models
+
+import (
+	"os"
+	"testing"
+
+	"github.com/alexsniffin/go-api-starter/pkg/config"
+)
+
+func TestConfig(t *testing.T) {
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = os.Chdir("../../.."); err != nil {
+		t.Fatal(err)
+	}
+	defer os.Chdir(wd)
+
+	var cfg Config
+	if err = config.NewConfig("todo-api", "TODO", config.Options{Strict: true}, &cfg); err != nil {
+		t.Errorf("invalid default config: %v", err)
+	}
+}
+
+func TestParseListener(t *testing.T) {
+	tests := []struct {
+		spec    string
+		want    Listener
+		wantErr bool
+	}{
+		{spec: "tcp://127.0.0.1:8080", want: Listener{Network: ListenerTCP, Address: "127.0.0.1:8080"}},
+		{spec: "tcp://127.0.0.1", wantErr: true},
+		{spec: "unix:///run/todo.sock?mode=0660",
+			want: Listener{Network: ListenerUnix, Address: "/run/todo.sock", Mode: 0660}},
+		{spec: "unix://run/todo.sock", wantErr: true},
+		{spec: "unix://", wantErr: true},
+		{spec: "unix:///run/todo.sock?mode=rw", wantErr: true},
+		{spec: "systemd", want: Listener{Network: ListenerSystemd}},
+		{spec: "systemd://http", want: Listener{Network: ListenerSystemd, Name: "http"}},
+		{spec: "udp://127.0.0.1:8080", wantErr: true},
+	}
+	for _, test := range tests {
+		t.Run(test.spec, func(t *testing.T) {
+			got, err := ParseListener(test.spec)
+			if (err != nil) != test.wantErr {
+				t.Fatalf("unexpected error: got %v want error %v", err, test.wantErr)
+			}
+			if got != test.want {
+				t.Errorf("wrong listener: got %+v want %+v", got, test.want)
+			}
+		})
+	}
+}
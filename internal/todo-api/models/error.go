@@ -1,5 +1,30 @@
 package models
 
+import "net/http"
+
 type Error struct {
 	Message string `json:"message"`
 }
+
+// ProblemContentType is the content type of a Problem
+const ProblemContentType = "application/problem+json"
+
+// Problem is an RFC 7807 problem details error response, the message repeats the detail for clients of Error
+type Problem struct {
+	Type    string `json:"type"`
+	Title   string `json:"title"`
+	Status  int    `json:"status"`
+	Detail  string `json:"detail,omitempty"`
+	Message string `json:"message"`
+}
+
+// NewProblem creates the Problem of the status code with the detail
+func NewProblem(statusCode int, detail string) Problem {
+	return Problem{
+		Type:    "about:blank",
+		Title:   http.StatusText(statusCode),
+		Status:  statusCode,
+		Detail:  detail,
+		Message: detail,
+	}
+}
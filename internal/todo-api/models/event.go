@@ -0,0 +1,24 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// Todo domain event types
+const (
+	TodoCreated = "todo.created"
+	TodoUpdated = "todo.updated"
+	TodoDeleted = "todo.deleted"
+)
+
+// TodoEvent domain event recorded in the outbox for a TodoItem mutation
+type TodoEvent struct {
+	ID        int64           `json:"id" pg:"id,pk"`
+	Type      string          `json:"type" pg:"type"`
+	TodoID    int             `json:"todo_id" pg:"todo_id"`
+	List      string          `json:"list,omitempty" pg:"list"`
+	UserID    string          `json:"user_id,omitempty" pg:"user_id"`
+	Payload   json.RawMessage `json:"payload" pg:"payload"`
+	CreatedOn time.Time       `json:"created_on" pg:"created_on"`
+}
@@ -0,0 +1,23 @@
+package models
+
+import "time"
+
+const (
+	HealthOK   = "ok"
+	HealthFail = "fail"
+	HealthWarn = "warn"
+)
+
+// HealthCheckResult is the result of a single health check
+type HealthCheckResult struct {
+	Status     string    `json:"status"`
+	Error      string    `json:"error,omitempty"`
+	DurationMs int64     `json:"duration_ms"`
+	CheckedOn  time.Time `json:"checked_on"`
+}
+
+// HealthReport response model of the health probes, the status fails if any check fails, warnings don't fail it
+type HealthReport struct {
+	Status string                       `json:"status"`
+	Checks map[string]HealthCheckResult `json:"checks"`
+}
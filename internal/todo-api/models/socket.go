@@ -0,0 +1,23 @@
+package models
+
+// Socket message types
+const (
+	SocketSubscribe   = "subscribe"
+	SocketUnsubscribe = "unsubscribe"
+	SocketCreate      = "create"
+	SocketDelete      = "delete"
+	SocketEvent       = "event"
+	SocketAck         = "ack"
+	SocketError       = "error"
+)
+
+// SocketMessage message exchanged over the WebSocket API, `RequestID` is echoed in the ack or error for a request
+type SocketMessage struct {
+	Type      string           `json:"type"`
+	RequestID string           `json:"request_id,omitempty"`
+	List      string           `json:"list,omitempty"`
+	ID        int              `json:"id,omitempty"`
+	Todo      *TodoPostRequest `json:"todo,omitempty"`
+	Event     *TodoEvent       `json:"event,omitempty"`
+	Message   string           `json:"message,omitempty"`
+}
@@ -11,7 +11,36 @@ type TodoItem struct {
 	tableName struct{}  `pg:"todo"` // nolint:structcheck,unused
 	ID        int       `json:"id" pg:"id,pk"`
 	Todo      string    `json:"todo" pg:"todo"`
+	List      string    `json:"list,omitempty" pg:"list"`
+	UserID    string    `json:"user_id,omitempty" pg:"user_id"`
+	Done      bool      `json:"done" pg:"done"`
 	CreatedOn time.Time `json:"created_on" pg:"created_on"`
+	UpdatedOn time.Time `json:"updated_on" pg:"updated_on"`
+	// IdempotencyKey of the request creating the todo, a todo is only created once per key of the user
+	IdempotencyKey string `json:"-" pg:"idempotency_key"`
+	// ExternalID identifies the todo in another system, e.g. of an import, it's unique per user
+	ExternalID string `json:"external_id,omitempty" pg:"external_id"`
+}
+
+// TodoFilter selects TodoItems to list, a page starts after the ID of the last TodoItem of the previous page
+type TodoFilter struct {
+	UserID  string
+	List    string
+	Done    *bool
+	AfterID int
+	Limit   int
+	// AllUsers lists the todos of every user instead of the user, e.g. to export the database
+	AllUsers bool
+}
+
+// IdempotencyKeyHeader of a POST of a TodoItem, the TodoItem is only created once for the key so the request can be
+// retried
+const IdempotencyKeyHeader = "Idempotency-Key"
+
+// TodoListResponse response model to a GET of a page of TodoItems, there's a next page if the cursor is set
+type TodoListResponse struct {
+	Items      []TodoItem `json:"items"`
+	NextCursor string     `json:"next_cursor,omitempty"`
 }
 
 // TodoPostResponse response model to POST
@@ -22,10 +51,56 @@ type TodoPostResponse struct {
 // TodoPostRequest request model to POST
 type TodoPostRequest struct {
 	Todo string `json:"todo"`
+	List string `json:"list,omitempty"`
 }
 
 func (tReq *TodoPostRequest) IsValid() error {
 	return validation.ValidateStruct(tReq,
 		validation.Field(&tReq.Todo, validation.Required),
+		validation.Field(&tReq.List, validation.Length(0, 100)),
 	)
 }
+
+// TodoItem creates the TodoItem to be stored for the request of the user
+func (tReq *TodoPostRequest) TodoItem(userID string) TodoItem {
+	now := time.Now()
+	return TodoItem{
+		Todo:      tReq.Todo,
+		List:      tReq.List,
+		UserID:    userID,
+		CreatedOn: now,
+		UpdatedOn: now,
+	}
+}
+
+// TodoPutRequest request model to PUT
+type TodoPutRequest struct {
+	Todo string `json:"todo"`
+	List string `json:"list,omitempty"`
+	Done bool   `json:"done"`
+}
+
+func (tReq *TodoPutRequest) IsValid() error {
+	return validation.ValidateStruct(tReq,
+		validation.Field(&tReq.Todo, validation.Required),
+		validation.Field(&tReq.List, validation.Length(0, 100)),
+	)
+}
+
+// TodoItem creates the TodoItem to be updated for the request
+func (tReq *TodoPutRequest) TodoItem(id int) TodoItem {
+	return TodoItem{
+		ID:        id,
+		Todo:      tReq.Todo,
+		List:      tReq.List,
+		Done:      tReq.Done,
+		UpdatedOn: time.Now(),
+	}
+}
+
+// GraphQLRequest request model to the GraphQL endpoint
+type GraphQLRequest struct {
+	Query         string                 `json:"query"`
+	OperationName string                 `json:"operationName"`
+	Variables     map[string]interface{} `json:"variables"`
+}
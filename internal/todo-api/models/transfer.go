@@ -0,0 +1,73 @@
+package models
+
+import (
+	"time"
+
+	validation "github.com/go-ozzo/ozzo-validation/v4"
+)
+
+// TodoRecord of a TodoItem in an export or import, the ID is only exported
+type TodoRecord struct {
+	ID         int       `json:"id,omitempty"`
+	ExternalID string    `json:"external_id,omitempty"`
+	Todo       string    `json:"todo"`
+	List       string    `json:"list,omitempty"`
+	Done       bool      `json:"done"`
+	CreatedOn  time.Time `json:"created_on"`
+	UpdatedOn  time.Time `json:"updated_on"`
+}
+
+// NewTodoRecord creates the record of the TodoItem
+func NewTodoRecord(todo TodoItem) TodoRecord {
+	return TodoRecord{
+		ID:         todo.ID,
+		ExternalID: todo.ExternalID,
+		Todo:       todo.Todo,
+		List:       todo.List,
+		Done:       todo.Done,
+		CreatedOn:  todo.CreatedOn,
+		UpdatedOn:  todo.UpdatedOn,
+	}
+}
+
+func (r *TodoRecord) IsValid() error {
+	return validation.ValidateStruct(r,
+		validation.Field(&r.ExternalID, validation.Length(0, 255)),
+		validation.Field(&r.Todo, validation.Required),
+		validation.Field(&r.List, validation.Length(0, 100)),
+	)
+}
+
+// TodoItem creates the TodoItem of the user to be imported, the creation time is kept if the record has one
+func (r *TodoRecord) TodoItem(userID string) TodoItem {
+	now := time.Now()
+	createdOn := r.CreatedOn
+	if createdOn.IsZero() {
+		createdOn = now
+	}
+	return TodoItem{
+		Todo:       r.Todo,
+		List:       r.List,
+		UserID:     userID,
+		Done:       r.Done,
+		CreatedOn:  createdOn,
+		UpdatedOn:  now,
+		ExternalID: r.ExternalID,
+	}
+}
+
+// TodoImportResponse response model to an import, reporting the errors of the records which weren't imported by line
+type TodoImportResponse struct {
+	DryRun  bool              `json:"dry_run"`
+	Valid   int               `json:"valid"`
+	Created int               `json:"created"`
+	Updated int               `json:"updated"`
+	Failed  int               `json:"failed"`
+	Errors  []TodoImportError `json:"errors"`
+}
+
+// TodoImportError of the record of a line, only the first errors of an import are reported
+type TodoImportError struct {
+	Line    int    `json:"line"`
+	Message string `json:"message"`
+}
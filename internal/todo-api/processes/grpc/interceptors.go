@@ -0,0 +1,154 @@
+package grpc
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/rs/xid"
+	"github.com/rs/zerolog"
+	"github.com/rs/zerolog/log"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+
+	"github.com/alexsniffin/go-api-starter/internal/todo-api/metrics"
+	"github.com/alexsniffin/go-api-starter/internal/todo-api/utils"
+)
+
+const requestIDHeader = "request-id"
+
+var requestDuration = promauto.With(metrics.Registry).NewHistogramVec(prometheus.HistogramOpts{
+	Name:    "grpc_request_duration_seconds",
+	Help:    "The latency of the gRPC calls.",
+	Buckets: prometheus.DefBuckets,
+}, []string{"method", "code"})
+
+// wrappedStream overrides the context of a stream
+type wrappedStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (w *wrappedStream) Context() context.Context {
+	return w.ctx
+}
+
+func unaryRecoverer(logger zerolog.Logger) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo,
+		handler grpc.UnaryHandler) (resp interface{}, err error) {
+		defer recoverer(logger, info.FullMethod, &err)
+		return handler(ctx, req)
+	}
+}
+
+func streamRecoverer(logger zerolog.Logger) grpc.StreamServerInterceptor {
+	return func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo,
+		handler grpc.StreamHandler) (err error) {
+		defer recoverer(logger, info.FullMethod, &err)
+		return handler(srv, ss)
+	}
+}
+
+func recoverer(logger zerolog.Logger, method string, err *error) {
+	if r := recover(); r != nil {
+		logger.Error().Caller().Interface("panic", r).Str("method", method).Msg("recovered from panic in grpc call")
+		*err = status.Error(codes.Internal, "Internal server error with request")
+	}
+}
+
+func unaryMetrics() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo,
+		handler grpc.UnaryHandler) (interface{}, error) {
+		start := time.Now()
+		resp, err := handler(ctx, req)
+		requestDuration.WithLabelValues(info.FullMethod, status.Code(err).String()).Observe(time.Since(start).Seconds())
+		return resp, err
+	}
+}
+
+func streamMetrics() grpc.StreamServerInterceptor {
+	return func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		start := time.Now()
+		err := handler(srv, ss)
+		requestDuration.WithLabelValues(info.FullMethod, status.Code(err).String()).Observe(time.Since(start).Seconds())
+		return err
+	}
+}
+
+func unaryAuth(userHeader string) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo,
+		handler grpc.UnaryHandler) (interface{}, error) {
+		return handler(withUser(ctx, userHeader), req)
+	}
+}
+
+func streamAuth(userHeader string) grpc.StreamServerInterceptor {
+	return func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		return handler(srv, &wrappedStream{ss, withUser(ss.Context(), userHeader)})
+	}
+}
+
+// withUser identifies the caller from the metadata set by the authenticating proxy, like the HTTP auth handler
+func withUser(ctx context.Context, userHeader string) context.Context {
+	if userHeader == "" {
+		return ctx
+	}
+
+	var userID string
+	if md, ok := metadata.FromIncomingContext(ctx); ok {
+		if values := md.Get(strings.ToLower(userHeader)); len(values) > 0 {
+			userID = values[0]
+		}
+	}
+	return utils.WithUser(ctx, userID)
+}
+
+func unaryLogging(logger zerolog.Logger) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo,
+		handler grpc.UnaryHandler) (interface{}, error) {
+		start := time.Now()
+		ctx = withLogger(ctx, logger)
+		resp, err := handler(ctx, req)
+		logCall(ctx, info.FullMethod, err, start)
+		return resp, err
+	}
+}
+
+func streamLogging(logger zerolog.Logger) grpc.StreamServerInterceptor {
+	return func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		start := time.Now()
+		ctx := withLogger(ss.Context(), logger)
+		err := handler(srv, &wrappedStream{ss, ctx})
+		logCall(ctx, info.FullMethod, err, start)
+		return err
+	}
+}
+
+// withLogger adds a logger with the request ID and caller to the context and returns the request ID to the client
+func withLogger(ctx context.Context, logger zerolog.Logger) context.Context {
+	reqID := xid.New().String()
+	if md, ok := metadata.FromIncomingContext(ctx); ok {
+		if values := md.Get(requestIDHeader); len(values) > 0 && values[0] != "" {
+			reqID = values[0]
+		}
+	}
+	_ = grpc.SetHeader(ctx, metadata.Pairs(requestIDHeader, reqID))
+
+	subLogger := logger.With().Str("reqID", reqID).Logger()
+	if userID := utils.UserFromCtx(ctx); userID != "" {
+		subLogger = subLogger.With().Str("userID", userID).Logger()
+	}
+	return subLogger.WithContext(ctx)
+}
+
+func logCall(ctx context.Context, method string, err error, start time.Time) {
+	log.Ctx(ctx).Info().
+		Str("method", method).
+		Str("code", status.Code(err).String()).
+		Int64("duration", time.Since(start).Milliseconds()).
+		Msg("gRPC Request")
+}
@@ -0,0 +1,104 @@
+package grpc
+
+import (
+	"context"
+	"fmt"
+	"net"
+
+	"github.com/rs/zerolog"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/reflection"
+
+	"github.com/alexsniffin/go-api-starter/internal/todo-api/models"
+	todov1 "github.com/alexsniffin/go-api-starter/pkg/api/todo/v1"
+)
+
+type Server struct {
+	*grpc.Server
+
+	cfg    models.GRPCServerConfig
+	logger zerolog.Logger
+	health *health.Server
+}
+
+// NewServer creates a gRPC server for the TodoService with the health and reflection services. Calls are intercepted
+// for recovery, metrics, identifying the caller from `userHeader` and logging, like the HTTP middleware.
+func NewServer(
+	cfg models.GRPCServerConfig,
+	userHeader string,
+	logger zerolog.Logger,
+	todoService todov1.TodoServiceServer,
+) *Server {
+	grpcServer := grpc.NewServer(
+		grpc.ChainUnaryInterceptor(
+			unaryRecoverer(logger),
+			unaryMetrics(),
+			unaryAuth(userHeader),
+			unaryLogging(logger),
+		),
+		grpc.ChainStreamInterceptor(
+			streamRecoverer(logger),
+			streamMetrics(),
+			streamAuth(userHeader),
+			streamLogging(logger),
+		),
+	)
+
+	healthServer := health.NewServer()
+	healthpb.RegisterHealthServer(grpcServer, healthServer)
+	todov1.RegisterTodoServiceServer(grpcServer, todoService)
+	reflection.Register(grpcServer)
+
+	return &Server{
+		grpcServer,
+		cfg,
+		logger,
+		healthServer,
+	}
+}
+
+func (g *Server) Name() string {
+	return "grpc"
+}
+
+// Start a gRPC server which will block the current goroutine until it's shutdown or a problem occurs.
+func (g *Server) Start(_ context.Context) error {
+	g.logger.Info().Msg(fmt.Sprint("running grpc server on 0.0.0.0:", g.cfg.Port))
+
+	listener, err := net.Listen("tcp", fmt.Sprint(":", g.cfg.Port))
+	if err != nil {
+		g.logger.Error().Caller().Err(err).Msg("grpc server failed to listen")
+		return err
+	}
+
+	err = g.Serve(listener)
+	if err != nil && err != grpc.ErrServerStopped {
+		g.logger.Error().Caller().Err(err).Msg("grpc server stopped unexpected")
+		return err
+	}
+
+	g.logger.Info().Msg("grpc server process stopped")
+	return nil
+}
+
+// Shutdown marks the server as not serving and waits for in-flight calls to finish, remaining calls are cancelled
+// when the context is done.
+func (g *Server) Shutdown(ctx context.Context) error {
+	g.health.Shutdown()
+
+	stopped := make(chan struct{})
+	go func() {
+		g.GracefulStop()
+		close(stopped)
+	}()
+
+	select {
+	case <-stopped:
+		return nil
+	case <-ctx.Done():
+		g.Stop()
+		return ctx.Err()
+	}
+}
@@ -0,0 +1,156 @@
+package http
+
+import (
+	"net"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/pkg/errors"
+
+	"github.com/alexsniffin/go-api-starter/internal/todo-api/models"
+)
+
+// listenFDsStart is the first file descriptor of the sockets passed by systemd
+var listenFDsStart = 3
+
+// activated are the sockets passed by systemd socket activation, they're read once and every socket is served by one
+// listener of the config
+var activated struct {
+	mu        sync.Mutex
+	read      bool
+	listeners []activatedListener
+}
+
+type activatedListener struct {
+	name     string
+	listener net.Listener
+	claimed  bool
+}
+
+// listen creates the listeners, the listeners which were created are closed if one fails
+func listen(listeners []models.Listener) ([]net.Listener, error) {
+	var created []net.Listener
+	for _, listener := range listeners {
+		l, err := listenOn(listener)
+		if err != nil {
+			for _, c := range created {
+				_ = c.Close()
+			}
+			return nil, err
+		}
+		created = append(created, l...)
+	}
+	return created, nil
+}
+
+func listenOn(listener models.Listener) ([]net.Listener, error) {
+	switch listener.Network {
+	case models.ListenerUnix:
+		l, err := listenUnix(listener.Address, listener.Mode)
+		if err != nil {
+			return nil, err
+		}
+		return []net.Listener{l}, nil
+	case models.ListenerSystemd:
+		return claimActivated(listener.Name)
+	}
+
+	l, err := net.Listen("tcp", listener.Address)
+	if err != nil {
+		return nil, err
+	}
+	return []net.Listener{l}, nil
+}
+
+// listenUnix listens on a unix socket with the mode. A socket file left by a process which didn't stop cleanly is
+// removed, the socket file is removed again once the listener is closed.
+func listenUnix(path string, mode os.FileMode) (net.Listener, error) {
+	if info, err := os.Lstat(path); err == nil && info.Mode()&os.ModeSocket != 0 {
+		if err = os.Remove(path); err != nil {
+			return nil, errors.Wrap(err, "failed to remove stale unix socket")
+		}
+	}
+
+	l, err := net.Listen("unix", path)
+	if err != nil {
+		return nil, err
+	}
+	if mode != 0 {
+		if err = os.Chmod(path, mode); err != nil {
+			_ = l.Close()
+			return nil, errors.Wrap(err, "failed to set the mode of the unix socket")
+		}
+	}
+	return l, nil
+}
+
+// claimActivated returns the unclaimed sockets passed by systemd of the name, or every unclaimed socket without a name
+func claimActivated(name string) ([]net.Listener, error) {
+	activated.mu.Lock()
+	defer activated.mu.Unlock()
+
+	if !activated.read {
+		listeners, err := readActivated()
+		if err != nil {
+			return nil, err
+		}
+		activated.listeners = listeners
+		activated.read = true
+	}
+
+	var claimed []net.Listener
+	for i := range activated.listeners {
+		l := &activated.listeners[i]
+		if !l.claimed && (name == "" || l.name == name) {
+			l.claimed = true
+			claimed = append(claimed, l.listener)
+		}
+	}
+	if len(claimed) == 0 {
+		if name != "" {
+			return nil, errors.Errorf("no socket named %q was passed by systemd", name)
+		}
+		return nil, errors.New("no sockets were passed by systemd")
+	}
+	return claimed, nil
+}
+
+// readActivated reads the sockets passed to the process by systemd with the LISTEN_PID, LISTEN_FDS and LISTEN_FDNAMES
+// variables, which are unset so they aren't inherited
+func readActivated() ([]activatedListener, error) {
+	defer func() {
+		_ = os.Unsetenv("LISTEN_PID")
+		_ = os.Unsetenv("LISTEN_FDS")
+		_ = os.Unsetenv("LISTEN_FDNAMES")
+	}()
+
+	pid, err := strconv.Atoi(os.Getenv("LISTEN_PID"))
+	if err != nil || pid != os.Getpid() {
+		return nil, nil
+	}
+	count, err := strconv.Atoi(os.Getenv("LISTEN_FDS"))
+	if err != nil {
+		return nil, errors.Wrap(err, "invalid LISTEN_FDS")
+	}
+	names := strings.Split(os.Getenv("LISTEN_FDNAMES"), ":")
+
+	listeners := make([]activatedListener, 0, count)
+	for i := 0; i < count; i++ {
+		var name string
+		if i < len(names) {
+			name = names[i]
+		}
+
+		// the listener has a duplicate of the descriptor, so the passed descriptor is closed
+		file := os.NewFile(uintptr(listenFDsStart+i), name)
+		l, err := net.FileListener(file)
+		_ = file.Close()
+		if err != nil {
+			return nil, errors.Wrapf(err, "socket %d passed by systemd isn't a listener", listenFDsStart+i)
+		}
+		listeners = append(listeners, activatedListener{name: name, listener: l})
+	}
+	return listeners, nil
+}
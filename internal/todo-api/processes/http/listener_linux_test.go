@@ -0,0 +1,95 @@
+package http
+
+import (
+	"fmt"
+	"net"
+	"os"
+	"syscall"
+	"testing"
+)
+
+func TestClaimActivated(t *testing.T) {
+	first, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer first.Close()
+	second, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer second.Close()
+
+	// the activated sockets are closed once they're read, so duplicates of the descriptors of the listeners are passed
+	// in order from the first, like systemd does
+	firstFD, err := dupListener(first)
+	if err != nil {
+		t.Fatal(err)
+	}
+	secondFD, err := dupListener(second)
+	if err != nil {
+		syscall.Close(firstFD)
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		activated.mu.Lock()
+		defer activated.mu.Unlock()
+
+		if !activated.read {
+			syscall.Close(firstFD)
+			syscall.Close(secondFD)
+		}
+		activated.read = false
+		activated.listeners = nil
+		os.Unsetenv("LISTEN_PID")
+		os.Unsetenv("LISTEN_FDS")
+		os.Unsetenv("LISTEN_FDNAMES")
+	})
+	if secondFD != firstFD+1 {
+		t.Skip("descriptors of the listeners aren't consecutive")
+	}
+
+	defer func(start int) { listenFDsStart = start }(listenFDsStart)
+	listenFDsStart = firstFD
+	os.Setenv("LISTEN_PID", fmt.Sprint(os.Getpid()))
+	os.Setenv("LISTEN_FDS", "2")
+	os.Setenv("LISTEN_FDNAMES", "http:admin")
+
+	named, err := claimActivated("admin")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(named) != 1 || named[0].Addr().String() != second.Addr().String() {
+		t.Errorf("wrong named listener: got %v want %v", named, second.Addr())
+	}
+	rest, err := claimActivated("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rest) != 1 || rest[0].Addr().String() != first.Addr().String() {
+		t.Errorf("wrong listeners: got %v want %v", rest, first.Addr())
+	}
+	if _, err = claimActivated(""); err == nil {
+		t.Errorf("missing error once every socket is claimed")
+	}
+	if os.Getenv("LISTEN_FDS") != "" {
+		t.Errorf("LISTEN_FDS wasn't unset")
+	}
+	for _, l := range append(named, rest...) {
+		l.Close()
+	}
+}
+
+// dupListener duplicates the descriptor of the listener, the duplicate is owned by the caller
+func dupListener(l net.Listener) (int, error) {
+	conn, err := l.(*net.TCPListener).SyscallConn()
+	if err != nil {
+		return 0, err
+	}
+
+	fd, dupErr := 0, error(nil)
+	if err = conn.Control(func(s uintptr) { fd, dupErr = syscall.Dup(int(s)) }); err != nil {
+		return 0, err
+	}
+	return fd, dupErr
+}
@@ -0,0 +1,77 @@
+package http
+
+import (
+	"context"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/rs/zerolog"
+
+	"github.com/alexsniffin/go-api-starter/internal/todo-api/models"
+)
+
+func TestServer_Listeners(t *testing.T) {
+	dir, err := ioutil.TempDir("", "listeners")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	socket := filepath.Join(dir, "api.sock")
+	// a stale socket of a process which didn't stop cleanly
+	stale, err := net.Listen("unix", socket)
+	if err != nil {
+		t.Fatal(err)
+	}
+	stale.(*net.UnixListener).SetUnlinkOnClose(false)
+	stale.Close()
+
+	server, err := NewServer(models.HTTPServerConfig{
+		Listeners: []string{"tcp://127.0.0.1:0", "unix://" + socket + "?mode=0660"},
+	}, zerolog.Nop(), userHandler)
+	if err != nil {
+		t.Fatal(err)
+	}
+	listeners, err := listen(server.listeners)
+	if err != nil {
+		t.Fatal(err)
+	}
+	done := make(chan error)
+	go func() {
+		done <- server.serve(listeners...)
+	}()
+
+	info, err := os.Stat(socket)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Mode().Perm() != 0660 {
+		t.Errorf("wrong mode of the socket: got %v want %v", info.Mode().Perm(), os.FileMode(0660))
+	}
+
+	unixClient := &http.Client{Transport: &http.Transport{
+		DialContext: func(ctx context.Context, _, _ string) (net.Conn, error) {
+			return (&net.Dialer{}).DialContext(ctx, "unix", socket)
+		},
+	}}
+	if got := get(t, unixClient, "http://unix/"); got != "HTTP/1.1 " {
+		t.Errorf("wrong response of the unix socket: got %v want %v", got, "HTTP/1.1 ")
+	}
+	if got := get(t, http.DefaultClient, "http://"+listeners[0].Addr().String()); got != "HTTP/1.1 " {
+		t.Errorf("wrong response of the tcp listener: got %v want %v", got, "HTTP/1.1 ")
+	}
+
+	if err = server.Shutdown(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if err = <-done; err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+	if _, err = os.Stat(socket); !os.IsNotExist(err) {
+		t.Errorf("socket wasn't removed: %v", err)
+	}
+}
@@ -1,10 +1,17 @@
 package http
 
 import (
+	"context"
+	"crypto/tls"
 	"fmt"
+	"net"
 	"net/http"
+	"time"
 
+	"github.com/pkg/errors"
 	"github.com/rs/zerolog"
+	"golang.org/x/net/http2"
+	"golang.org/x/net/http2/h2c"
 
 	"github.com/alexsniffin/go-api-starter/internal/todo-api/models"
 )
@@ -12,30 +19,111 @@ import (
 type Server struct {
 	*http.Server
 
-	cfg    models.HTTPServerConfig
-	logger zerolog.Logger
+	name      string
+	listeners []models.Listener
+	logger    zerolog.Logger
 }
 
-func NewServer(cfg models.HTTPServerConfig, logger zerolog.Logger, routerHandler http.Handler) *Server {
+// NewServer creates the HTTP server of the API on the listeners of the config, or on the port without listeners. With
+// TLS, HTTP/2 is negotiated and the certificate is reloaded once it's rotated, and callers with verified client
+// certificates are identified by them. Without TLS, HTTP/2 is served as h2c if enabled.
+func NewServer(cfg models.HTTPServerConfig, logger zerolog.Logger, routerHandler http.Handler) (*Server, error) {
+	server := newServer("http", cfg.Port, logger, routerHandler)
+	if len(cfg.Listeners) > 0 {
+		server.listeners = make([]models.Listener, len(cfg.Listeners))
+		for i, spec := range cfg.Listeners {
+			listener, err := models.ParseListener(spec)
+			if err != nil {
+				return nil, errors.Wrapf(err, "invalid listener %q", spec)
+			}
+			server.listeners[i] = listener
+		}
+	}
+	server.ReadTimeout = time.Duration(cfg.ReadTimeoutSec) * time.Second
+	server.ReadHeaderTimeout = time.Duration(cfg.ReadHeaderTimeoutSec) * time.Second
+	server.WriteTimeout = time.Duration(cfg.WriteTimeoutSec) * time.Second
+	server.IdleTimeout = time.Duration(cfg.IdleTimeoutSec) * time.Second
+
+	if !cfg.TLS.Enabled() {
+		if cfg.H2C {
+			server.Handler = h2c.NewHandler(server.Handler, &http2.Server{IdleTimeout: server.IdleTimeout})
+		}
+		return server, nil
+	}
+
+	tlsConfig, err := newTLSConfig(cfg.TLS, logger)
+	if err != nil {
+		return nil, err
+	}
+	server.TLSConfig = tlsConfig
+	if cfg.TLS.ClientIdentity != "" {
+		server.Handler = identityHandler(cfg.TLS.ClientIdentity, logger, server.Handler)
+	}
+	if err = http2.ConfigureServer(server.Server, &http2.Server{IdleTimeout: server.IdleTimeout}); err != nil {
+		return nil, errors.Wrap(err, "failed to configure http2")
+	}
+	return server, nil
+}
+
+// NewAdminServer creates the HTTP server of the internal admin endpoints
+func NewAdminServer(cfg models.AdminServerConfig, logger zerolog.Logger, routerHandler http.Handler) *Server {
+	return newServer("admin", cfg.Port, logger, routerHandler)
+}
+
+func newServer(name string, port int, logger zerolog.Logger, routerHandler http.Handler) *Server {
+	addr := fmt.Sprint(":", port)
 	return &Server{
 		&http.Server{
-			Addr:    fmt.Sprint(":", cfg.Port),
+			Addr:    addr,
 			Handler: routerHandler,
 		},
-		cfg,
+		name,
+		[]models.Listener{{Network: models.ListenerTCP, Address: addr}},
 		logger,
 	}
 }
 
-// Start an HTTP server which will block the current goroutine. Will write an error to the `errCh` if a problem occurs.
-func (h *Server) Start(errCh chan<- error) {
-	h.logger.Info().Msg(fmt.Sprint("running server on 0.0.0.0:", h.cfg.Port))
+func (h *Server) Name() string {
+	return h.name
+}
+
+// Start an HTTP server which will block the current goroutine until it's shutdown or a problem occurs.
+func (h *Server) Start(_ context.Context) error {
+	listeners, err := listen(h.listeners)
+	if err != nil {
+		h.logger.Error().Caller().Err(err).Msg(h.name + " server failed to listen")
+		return err
+	}
+	return h.serve(listeners...)
+}
 
-	err := h.ListenAndServe()
-	if err != http.ErrServerClosed {
-		h.logger.Error().Caller().Err(err).Msg("http server stopped unexpected")
-		errCh <- err
-	} else {
-		h.logger.Info().Msg("http server process stopped")
+// serve the listeners until the server is shutdown, with TLS if it's configured. If a listener fails the others are
+// closed, so the server stops as a whole.
+func (h *Server) serve(listeners ...net.Listener) error {
+	errs := make(chan error, len(listeners))
+	for _, listener := range listeners {
+		h.logger.Info().Bool("tls", h.TLSConfig != nil).Msg(fmt.Sprint("running ", h.name, " server on ",
+			listener.Addr().Network(), "://", listener.Addr().String()))
+		if h.TLSConfig != nil {
+			listener = tls.NewListener(listener, h.TLSConfig)
+		}
+		go func(listener net.Listener) {
+			errs <- h.Serve(listener)
+		}(listener)
 	}
+
+	var err error
+	for range listeners {
+		if serveErr := <-errs; serveErr != http.ErrServerClosed && err == nil {
+			err = serveErr
+			_ = h.Close()
+		}
+	}
+	if err != nil {
+		h.logger.Error().Caller().Err(err).Msg(h.name + " server stopped unexpected")
+		return err
+	}
+
+	h.logger.Info().Msg(h.name + " server process stopped")
+	return nil
 }
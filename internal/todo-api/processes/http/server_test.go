@@ -0,0 +1,225 @@
+package http
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"fmt"
+	"io/ioutil"
+	"math/big"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/rs/zerolog"
+	"golang.org/x/net/http2"
+
+	"github.com/alexsniffin/go-api-starter/internal/todo-api/models"
+	"github.com/alexsniffin/go-api-starter/internal/todo-api/utils"
+)
+
+// userHandler answers with the protocol and the caller of a request
+var userHandler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+	fmt.Fprintf(w, "%s %s", r.Proto, utils.UserFromCtx(r.Context()))
+})
+
+func TestNewServer_TLS(t *testing.T) {
+	dir, err := ioutil.TempDir("", "tls")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	ca, caKey := newCert(t, "ca", nil, nil)
+	writePEM(t, filepath.Join(dir, "ca.pem"), "CERTIFICATE", ca.Raw)
+	serverCert, serverKey := newCert(t, "localhost", ca, caKey)
+	writeKeyPair(t, dir, "server", serverCert, serverKey)
+	clientCert, clientKey := newCert(t, "alice", ca, caKey)
+	anonymousCert, anonymousKey := newCert(t, "", ca, caKey)
+
+	server, err := NewServer(models.HTTPServerConfig{
+		Port: 1,
+		TLS: models.TLSConfig{
+			CertFile:       filepath.Join(dir, "server.pem"),
+			KeyFile:        filepath.Join(dir, "server-key.pem"),
+			ClientCAFile:   filepath.Join(dir, "ca.pem"),
+			ClientAuth:     models.ClientAuthVerifyIfGiven,
+			ClientIdentity: "cn",
+		},
+	}, zerolog.Nop(), userHandler)
+	if err != nil {
+		t.Fatal(err)
+	}
+	addr := serveTest(t, server)
+	defer server.Close()
+
+	roots := x509.NewCertPool()
+	roots.AddCert(ca)
+	clientKeyPair := tls.Certificate{Certificate: [][]byte{clientCert.Raw}, PrivateKey: clientKey}
+	anonymousKeyPair := tls.Certificate{Certificate: [][]byte{anonymousCert.Raw}, PrivateKey: anonymousKey}
+
+	tests := []struct {
+		name  string
+		certs []tls.Certificate
+		want  string
+	}{
+		{"clientCertificateIdentity", []tls.Certificate{clientKeyPair}, "HTTP/2.0 alice"},
+		{"withoutClientCertificate", nil, "HTTP/2.0 "},
+		{"withoutIdentity", []tls.Certificate{anonymousKeyPair},
+			`{"type":"about:blank","title":"Unauthorized","status":401,"detail":"client certificate has no cn identity",` +
+				`"message":"client certificate has no cn identity"}` + "\n"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			client := &http.Client{Transport: &http.Transport{
+				TLSClientConfig:   &tls.Config{RootCAs: roots, Certificates: test.certs},
+				ForceAttemptHTTP2: true,
+			}}
+			if got := get(t, client, "https://"+addr); got != test.want {
+				t.Errorf("wrong response: got %v want %v", got, test.want)
+			}
+		})
+	}
+}
+
+func TestCertificate_Reload(t *testing.T) {
+	dir, err := ioutil.TempDir("", "tls")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	cert, key := newCert(t, "first", nil, nil)
+	writeKeyPair(t, dir, "server", cert, key)
+	c, err := newCertificate(filepath.Join(dir, "server.pem"), filepath.Join(dir, "server-key.pem"), zerolog.Nop())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	rotated, rotatedKey := newCert(t, "second", nil, nil)
+	writeKeyPair(t, dir, "server", rotated, rotatedKey)
+	modTime := time.Now().Add(time.Minute)
+	for _, file := range []string{"server.pem", "server-key.pem"} {
+		if err = os.Chtimes(filepath.Join(dir, file), modTime, modTime); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	got, _ := c.GetCertificate(nil)
+	if leaf, _ := x509.ParseCertificate(got.Certificate[0]); leaf.Subject.CommonName != "first" {
+		t.Errorf("certificate reloaded before the check interval: got %v", leaf.Subject.CommonName)
+	}
+	c.checked = time.Time{}
+	got, _ = c.GetCertificate(nil)
+	if leaf, _ := x509.ParseCertificate(got.Certificate[0]); leaf.Subject.CommonName != "second" {
+		t.Errorf("wrong certificate: got %v want %v", leaf.Subject.CommonName, "second")
+	}
+}
+
+func TestNewServer_H2C(t *testing.T) {
+	server, err := NewServer(models.HTTPServerConfig{Port: 1, H2C: true, ReadHeaderTimeoutSec: 10},
+		zerolog.Nop(), userHandler)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if server.ReadHeaderTimeout != 10*time.Second {
+		t.Errorf("wrong read header timeout: got %v want %v", server.ReadHeaderTimeout, 10*time.Second)
+	}
+	addr := serveTest(t, server)
+	defer server.Close()
+
+	client := &http.Client{Transport: &http2.Transport{
+		AllowHTTP: true,
+		DialTLS: func(network, addr string, _ *tls.Config) (net.Conn, error) {
+			return net.Dial(network, addr)
+		},
+	}}
+	if got := get(t, client, "http://"+addr); got != "HTTP/2.0 " {
+		t.Errorf("wrong response: got %v want %v", got, "HTTP/2.0 ")
+	}
+}
+
+// serveTest serves the server on a free port and returns its address
+func serveTest(t *testing.T, server *Server) string {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	go server.serve(listener)
+	return listener.Addr().String()
+}
+
+func get(t *testing.T, client *http.Client, url string) string {
+	resp, err := client.Get(url)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(body)
+}
+
+// newCert creates a certificate of the common name signed by the parent, or a self-signed CA without a parent
+func newCert(t *testing.T, commonName string, parent *x509.Certificate, parentKey *ecdsa.PrivateKey) (
+	*x509.Certificate, *ecdsa.PrivateKey) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	serial, err := rand.Int(rand.Reader, big.NewInt(1<<62))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	template := &x509.Certificate{
+		SerialNumber: serial,
+		Subject:      pkix.Name{CommonName: commonName},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth},
+		DNSNames:     []string{"localhost"},
+		IPAddresses:  []net.IP{net.ParseIP("127.0.0.1")},
+	}
+	if parent == nil {
+		template.IsCA = true
+		template.BasicConstraintsValid = true
+		parent, parentKey = template, key
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, template, parent, &key.PublicKey, parentKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return cert, key
+}
+
+func writeKeyPair(t *testing.T, dir, name string, cert *x509.Certificate, key *ecdsa.PrivateKey) {
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	writePEM(t, filepath.Join(dir, name+".pem"), "CERTIFICATE", cert.Raw)
+	writePEM(t, filepath.Join(dir, name+"-key.pem"), "EC PRIVATE KEY", keyDER)
+}
+
+func writePEM(t *testing.T, file, blockType string, der []byte) {
+	if err := ioutil.WriteFile(file, pem.EncodeToMemory(&pem.Block{Type: blockType, Bytes: der}), 0600); err != nil {
+		t.Fatal(err)
+	}
+}
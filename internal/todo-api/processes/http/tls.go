@@ -0,0 +1,168 @@
+package http
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/rs/zerolog"
+
+	"github.com/alexsniffin/go-api-starter/internal/todo-api/models"
+	"github.com/alexsniffin/go-api-starter/internal/todo-api/utils"
+)
+
+// certCheckInterval is how often the files of the certificate are checked for a rotation, at most
+const certCheckInterval = time.Second
+
+var clientAuthTypes = map[string]tls.ClientAuthType{
+	"":                             tls.NoClientCert,
+	models.ClientAuthNone:          tls.NoClientCert,
+	models.ClientAuthRequest:       tls.RequestClientCert,
+	models.ClientAuthVerifyIfGiven: tls.VerifyClientCertIfGiven,
+	models.ClientAuthRequire:       tls.RequireAndVerifyClientCert,
+}
+
+func newTLSConfig(cfg models.TLSConfig, logger zerolog.Logger) (*tls.Config, error) {
+	cert, err := newCertificate(cfg.CertFile, cfg.KeyFile, logger)
+	if err != nil {
+		return nil, err
+	}
+
+	tlsConfig := &tls.Config{
+		MinVersion:     tls.VersionTLS12,
+		GetCertificate: cert.GetCertificate,
+		ClientAuth:     clientAuthTypes[cfg.ClientAuth],
+	}
+	if cfg.ClientCAFile != "" {
+		pem, err := ioutil.ReadFile(cfg.ClientCAFile)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to read client ca file")
+		}
+		tlsConfig.ClientCAs = x509.NewCertPool()
+		if !tlsConfig.ClientCAs.AppendCertsFromPEM(pem) {
+			return nil, errors.New("no certificates in client ca file")
+		}
+	}
+	return tlsConfig, nil
+}
+
+// certificate of the server, the key pair is loaded again once either file is modified so a rotated certificate is
+// served without a restart
+type certificate struct {
+	certFile string
+	keyFile  string
+	logger   zerolog.Logger
+
+	mu      sync.Mutex
+	cert    *tls.Certificate
+	modTime time.Time
+	checked time.Time
+}
+
+func newCertificate(certFile, keyFile string, logger zerolog.Logger) (*certificate, error) {
+	c := &certificate{certFile: certFile, keyFile: keyFile, logger: logger}
+	if err := c.load(); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// GetCertificate returns the certificate for a handshake, a rotated certificate which fails to load is logged and the
+// previous one is still served
+func (c *certificate) GetCertificate(*tls.ClientHelloInfo) (*tls.Certificate, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if time.Since(c.checked) >= certCheckInterval {
+		if modTime, err := c.lastModified(); err == nil && !modTime.Equal(c.modTime) {
+			if err = c.load(); err != nil {
+				c.logger.Error().Caller().Err(err).Msg("failed to reload the tls certificate")
+			} else {
+				c.logger.Info().Msg("reloaded the rotated tls certificate")
+			}
+		}
+		c.checked = time.Now()
+	}
+	return c.cert, nil
+}
+
+func (c *certificate) load() error {
+	modTime, err := c.lastModified()
+	if err != nil {
+		return err
+	}
+	cert, err := tls.LoadX509KeyPair(c.certFile, c.keyFile)
+	if err != nil {
+		return errors.Wrap(err, "failed to load tls certificate")
+	}
+
+	c.cert = &cert
+	c.modTime = modTime
+	c.checked = time.Now()
+	return nil
+}
+
+// lastModified is the latest modification of the files, which are followed if they're links as mounted secrets are
+func (c *certificate) lastModified() (time.Time, error) {
+	var modTime time.Time
+	for _, file := range []string{c.certFile, c.keyFile} {
+		info, err := os.Stat(file)
+		if err != nil {
+			return time.Time{}, errors.Wrap(err, "failed to stat tls certificate")
+		}
+		if info.ModTime().After(modTime) {
+			modTime = info.ModTime()
+		}
+	}
+	return modTime, nil
+}
+
+// identityHandler identifies the caller of a verified client certificate by the field of its subject. A verified
+// certificate without the field is rejected with a 401, so its caller can't fall back to the user header. Requests
+// without a certificate, allowed by verify-if-given, are passed on as is and may still be identified by the header.
+func identityHandler(field string, logger zerolog.Logger, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.TLS == nil || len(r.TLS.VerifiedChains) == 0 || len(r.TLS.VerifiedChains[0]) == 0 {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		identity := clientIdentity(field, r.TLS.VerifiedChains[0][0])
+		if identity == "" {
+			logger.Debug().Caller().Str("subject", r.TLS.VerifiedChains[0][0].Subject.String()).
+				Msg("client certificate without identity rejected")
+			writeProblem(w, http.StatusUnauthorized, "client certificate has no "+field+" identity")
+			return
+		}
+		next.ServeHTTP(w, r.WithContext(utils.WithUser(r.Context(), identity)))
+	})
+}
+
+// writeProblem writes an RFC 7807 problem of the status code with the detail
+func writeProblem(w http.ResponseWriter, statusCode int, detail string) {
+	w.Header().Set("Content-Type", models.ProblemContentType)
+	w.WriteHeader(statusCode)
+	_ = json.NewEncoder(w).Encode(models.NewProblem(statusCode, detail))
+}
+
+func clientIdentity(field string, cert *x509.Certificate) string {
+	switch field {
+	case "cn":
+		return cert.Subject.CommonName
+	case "email":
+		if len(cert.EmailAddresses) > 0 {
+			return cert.EmailAddresses[0]
+		}
+	case "uri":
+		if len(cert.URIs) > 0 {
+			return cert.URIs[0].String()
+		}
+	}
+	return ""
+}
@@ -0,0 +1,31 @@
+package outbox
+
+import (
+	"context"
+
+	"github.com/rs/zerolog"
+
+	"github.com/alexsniffin/go-api-starter/internal/todo-api/models"
+)
+
+// LogPublisher is a Publisher which writes events to the log, used when no broker is configured
+type LogPublisher struct {
+	logger zerolog.Logger
+}
+
+// NewLogPublisher creates a new LogPublisher
+func NewLogPublisher(logger zerolog.Logger) *LogPublisher {
+	return &LogPublisher{
+		logger: logger,
+	}
+}
+
+// Publish logs the event
+func (l *LogPublisher) Publish(_ context.Context, event models.TodoEvent) error {
+	l.logger.Info().
+		Int64("eventID", event.ID).
+		Str("type", event.Type).
+		Int("todoID", event.TodoID).
+		Msg("todo event published")
+	return nil
+}
@@ -0,0 +1,139 @@
+package outbox
+
+import (
+	"context"
+	"time"
+
+	"github.com/go-pg/pg"
+	"github.com/rs/zerolog"
+
+	"github.com/alexsniffin/go-api-starter/internal/todo-api/clients/postgres"
+	"github.com/alexsniffin/go-api-starter/internal/todo-api/models"
+)
+
+// Publisher delivers domain events from the outbox to downstream consumers
+type Publisher interface {
+	Publish(ctx context.Context, event models.TodoEvent) error
+}
+
+// Relay polls the outbox for unsent events and publishes them in order. Rows are claimed with
+// `FOR UPDATE SKIP LOCKED` so multiple replicas can run a relay concurrently without publishing the same event twice.
+type Relay struct {
+	cfg    models.OutboxConfig
+	logger zerolog.Logger
+
+	pgClient  postgres.DatabaseClient
+	publisher Publisher
+}
+
+// NewRelay creates a new outbox Relay
+func NewRelay(
+	cfg models.OutboxConfig,
+	logger zerolog.Logger,
+	pgClient postgres.DatabaseClient,
+	publisher Publisher,
+) *Relay {
+	return &Relay{
+		cfg:       cfg,
+		logger:    logger,
+		pgClient:  pgClient,
+		publisher: publisher,
+	}
+}
+
+func (r *Relay) Name() string {
+	return "outbox-relay"
+}
+
+// Start polling the outbox which will block the current goroutine until the context is cancelled, an in-flight batch
+// is finished first.
+func (r *Relay) Start(ctx context.Context) error {
+	r.logger.Info().Msg("running outbox relay")
+
+	ticker := time.NewTicker(time.Duration(r.cfg.PollIntervalMs) * time.Millisecond)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			r.logger.Info().Msg("outbox relay process stopped")
+			return nil
+		case <-ticker.C:
+			r.drain(ctx)
+		}
+	}
+}
+
+// Shutdown has nothing to release, the relay stops once the context of `Start` is cancelled.
+func (r *Relay) Shutdown(_ context.Context) error {
+	return nil
+}
+
+// Lag is the age of the oldest unsent event, zero if all events are sent
+func (r *Relay) Lag(ctx context.Context) (time.Duration, error) {
+	var lagSec float64
+	_, err := r.pgClient.GetConnection().QueryOneContext(ctx, pg.Scan(&lagSec), `SELECT
+		COALESCE(EXTRACT(EPOCH FROM now() - min(created_on)), 0) FROM outbox WHERE sent_on IS NULL`)
+	if err != nil {
+		return 0, err
+	}
+
+	return time.Duration(lagSec * float64(time.Second)), nil
+}
+
+// drain relays batches until the outbox is empty, a batch fails or a shutdown is signaled
+func (r *Relay) drain(ctx context.Context) {
+	for {
+		if ctx.Err() != nil {
+			return
+		}
+
+		// a claimed batch is finished even if a shutdown is signaled meanwhile
+		sent, err := r.relayBatch(context.Background())
+		if err != nil {
+			r.logger.Error().Caller().Err(err).Msg("failed to relay outbox events")
+			return
+		}
+		if sent < r.cfg.BatchSize {
+			return
+		}
+	}
+}
+
+// relayBatch claims a batch of unsent events, publishes them in order and marks the published events as sent. If
+// publishing fails, the events published so far are still marked as sent and the rest are retried on the next poll.
+func (r *Relay) relayBatch(ctx context.Context) (int, error) {
+	var sent []int64
+	var publishErr error
+
+	err := r.pgClient.GetConnection().RunInTransaction(func(tx *pg.Tx) error {
+		var events []models.TodoEvent
+		_, err := tx.QueryContext(ctx, &events, `SELECT id, type, todo_id, list, user_id, payload, created_on FROM outbox
+			WHERE sent_on IS NULL ORDER BY id LIMIT ? FOR UPDATE SKIP LOCKED`, r.cfg.BatchSize)
+		if err != nil {
+			return err
+		}
+
+		for i := 0; i < len(events); i++ {
+			if publishErr = r.publisher.Publish(ctx, events[i]); publishErr != nil {
+				break
+			}
+			sent = append(sent, events[i].ID)
+		}
+		if len(sent) == 0 {
+			return nil
+		}
+
+		_, err = tx.ExecContext(ctx, `UPDATE outbox SET sent_on = ? WHERE id IN (?)`, time.Now(), pg.In(sent))
+		return err
+	})
+	if err != nil {
+		return 0, err
+	}
+	if publishErr != nil {
+		return len(sent), publishErr
+	}
+
+	r.logger.Debug().Int("count", len(sent)).Msg("relayed outbox events")
+	return len(sent), nil
+}
@@ -0,0 +1,105 @@
+package reload
+
+import (
+	"context"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"syscall"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+	"github.com/rs/zerolog"
+
+	"github.com/alexsniffin/go-api-starter/internal/todo-api/models"
+	"github.com/alexsniffin/go-api-starter/pkg/config"
+)
+
+// debounceDelay groups the events of a single write of the config file, editors write and rename in several steps
+const debounceDelay = 100 * time.Millisecond
+
+// Reloader loads the config again when the config file changes or the process receives SIGHUP, and passes it to
+// `apply`. Invalid configs are logged and ignored, the running config is kept.
+type Reloader struct {
+	logger zerolog.Logger
+
+	loader *config.Loader
+	apply  func(cfg models.Config) error
+}
+
+// NewReloader creates a new config Reloader
+func NewReloader(logger zerolog.Logger, loader *config.Loader, apply func(cfg models.Config) error) *Reloader {
+	return &Reloader{
+		logger: logger,
+		loader: loader,
+		apply:  apply,
+	}
+}
+
+func (r *Reloader) Name() string {
+	return "config-reload"
+}
+
+// Start watching the config file and listening for SIGHUP which will block the current goroutine until the context is
+// cancelled. The directory of the file is watched so a replaced file, e.g. a Kubernetes ConfigMap, is noticed too.
+func (r *Reloader) Start(ctx context.Context) error {
+	hup := make(chan os.Signal, 1)
+	signal.Notify(hup, syscall.SIGHUP)
+	defer signal.Stop(hup)
+
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return err
+	}
+	defer watcher.Close()
+
+	path := filepath.Clean(r.loader.Path())
+	if err = watcher.Add(filepath.Dir(path)); err != nil {
+		return err
+	}
+	realPath, _ := filepath.EvalSymlinks(path)
+
+	r.logger.Info().Str("path", path).Msg("watching config for changes")
+
+	var debounce <-chan time.Time
+	for {
+		select {
+		case <-ctx.Done():
+			r.logger.Info().Msg("config reload process stopped")
+			return nil
+		case <-hup:
+			r.reload("signal")
+		case event := <-watcher.Events:
+			currentPath, _ := filepath.EvalSymlinks(path)
+			written := filepath.Clean(event.Name) == path && event.Op&(fsnotify.Write|fsnotify.Create) != 0
+			if written || (currentPath != "" && currentPath != realPath) {
+				realPath = currentPath
+				debounce = time.After(debounceDelay)
+			}
+		case <-debounce:
+			debounce = nil
+			r.reload("file")
+		case err = <-watcher.Errors:
+			r.logger.Error().Caller().Err(err).Msg("failed to watch config")
+			return err
+		}
+	}
+}
+
+// Shutdown has nothing to release, the reloader stops once the context of `Start` is cancelled.
+func (r *Reloader) Shutdown(_ context.Context) error {
+	return nil
+}
+
+func (r *Reloader) reload(trigger string) {
+	r.logger.Info().Str("trigger", trigger).Msg("reloading config")
+
+	var cfg models.Config
+	if err := r.loader.Load(&cfg); err != nil {
+		r.logger.Error().Err(err).Msg("config not reloaded, keeping the running config")
+		return
+	}
+	if err := r.apply(cfg); err != nil {
+		r.logger.Error().Err(err).Msg("config not reloaded, keeping the running config")
+	}
+}
@@ -0,0 +1,94 @@
+package reload
+
+import (
+	"bytes"
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/rs/zerolog"
+
+	"github.com/alexsniffin/go-api-starter/internal/todo-api/models"
+	"github.com/alexsniffin/go-api-starter/pkg/config"
+)
+
+func TestReloader(t *testing.T) {
+	content, err := ioutil.ReadFile("../../../../configs/todo-api.yaml")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	dir, err := ioutil.TempDir("", "reload")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "todo-api.yaml")
+	if err = ioutil.WriteFile(path, content, 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = os.Chdir(dir); err != nil {
+		t.Fatal(err)
+	}
+	defer os.Chdir(wd)
+
+	loader := config.NewLoader("todo-api", "TODO", config.Options{Strict: true})
+	var cfg models.Config
+	if err = loader.Load(&cfg); err != nil {
+		t.Fatal(err)
+	}
+
+	applied := make(chan models.Config, 1)
+	reloader := NewReloader(zerolog.New(os.Stdout), loader, func(cfg models.Config) error {
+		applied <- cfg
+		return nil
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	stopped := make(chan error)
+	go func() {
+		stopped <- reloader.Start(ctx)
+	}()
+	time.Sleep(50 * time.Millisecond)
+
+	t.Run("changedConfig", func(t *testing.T) {
+		changed := bytes.Replace(content, []byte("TimeoutSec: 30"), []byte("TimeoutSec: 10"), 1)
+		if err := ioutil.WriteFile(path, changed, 0600); err != nil {
+			t.Fatal(err)
+		}
+
+		select {
+		case cfg := <-applied:
+			if cfg.HTTPRouter.TimeoutSec != 10 {
+				t.Errorf("wrong timeout: got %v want %v", cfg.HTTPRouter.TimeoutSec, 10)
+			}
+		case <-time.After(time.Second):
+			t.Errorf("config not applied")
+		}
+	})
+
+	t.Run("invalidConfig", func(t *testing.T) {
+		if err := ioutil.WriteFile(path, []byte("Environment: localhost\n"), 0600); err != nil {
+			t.Fatal(err)
+		}
+
+		select {
+		case cfg := <-applied:
+			t.Errorf("invalid config applied: %+v", cfg)
+		case <-time.After(3 * debounceDelay):
+		}
+	})
+
+	cancel()
+	if err = <-stopped; err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
@@ -0,0 +1,31 @@
+package supervisor
+
+import "context"
+
+// closer is a Process for components without a run loop of their own, e.g. clients, so they're shut down in order
+// with the processes depending on them
+type closer struct {
+	name     string
+	shutdown func(ctx context.Context) error
+}
+
+// NewCloser creates a Process which idles until it's shut down with `shutdown`
+func NewCloser(name string, shutdown func(ctx context.Context) error) Process {
+	return &closer{
+		name:     name,
+		shutdown: shutdown,
+	}
+}
+
+func (c *closer) Name() string {
+	return c.name
+}
+
+func (c *closer) Start(ctx context.Context) error {
+	<-ctx.Done()
+	return nil
+}
+
+func (c *closer) Shutdown(ctx context.Context) error {
+	return c.shutdown(ctx)
+}
@@ -0,0 +1,313 @@
+package supervisor
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/rs/zerolog"
+)
+
+// Process is a long-running component of the server
+type Process interface {
+	// Name identifies the process in logs and its Status
+	Name() string
+	// Start runs the process and blocks until it stops. Returns nil if it was stopped by Shutdown or the cancellation
+	// of the context.
+	Start(ctx context.Context) error
+	// Shutdown stops the process gracefully, the context of Start is cancelled afterwards
+	Shutdown(ctx context.Context) error
+}
+
+// Policy decides what happens when a process stops unexpectedly
+type Policy struct {
+	// Restart the process with backoff instead of shutting down the server, for non-critical workers
+	Restart bool
+	// MaxRestarts before the process is left failed, 0 restarts without limit
+	MaxRestarts int
+	// MaxBackoff between restarts, the backoff doubles from 100ms
+	MaxBackoff time.Duration
+}
+
+// Critical processes shut down the server when they stop unexpectedly
+var Critical = Policy{}
+
+type State string
+
+const (
+	StatePending    State = "pending"
+	StateRunning    State = "running"
+	StateRestarting State = "restarting"
+	StateStopped    State = "stopped"
+	StateFailed     State = "failed"
+)
+
+// Status is the state of a registered process
+type Status struct {
+	Name      string `json:"name"`
+	State     State  `json:"state"`
+	Restarts  int    `json:"restarts"`
+	LastError string `json:"last_error,omitempty"`
+}
+
+// Supervisor starts the registered processes, restarts or reports the ones that stop unexpectedly and shuts them down
+// in reverse order of registration, so processes have to be registered after the processes they depend on.
+type Supervisor struct {
+	logger zerolog.Logger
+
+	mu       sync.Mutex
+	procs    []*proc
+	started  bool
+	closed   bool
+	fatalErr chan error
+}
+
+type proc struct {
+	Process
+	policy Policy
+
+	ctx    context.Context
+	cancel context.CancelFunc
+	done   chan struct{}
+
+	mu       sync.Mutex
+	state    State
+	restarts int
+	lastErr  error
+	stopping bool
+}
+
+// New creates a new Supervisor
+func New(logger zerolog.Logger) *Supervisor {
+	return &Supervisor{
+		logger:   logger,
+		fatalErr: make(chan error, 1),
+	}
+}
+
+// Register a process with its Policy, processes registered after Start are ignored
+func (s *Supervisor) Register(p Process, policy Policy) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.started {
+		s.logger.Error().Caller().Str("process", p.Name()).Msg("process registered after start, ignoring")
+		return
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	s.procs = append(s.procs, &proc{
+		Process: p,
+		policy:  policy,
+		ctx:     ctx,
+		cancel:  cancel,
+		done:    make(chan struct{}),
+		state:   StatePending,
+	})
+}
+
+// Start all registered processes in order of registration without blocking
+func (s *Supervisor) Start() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.started || s.closed {
+		return
+	}
+	s.started = true
+	for i := 0; i < len(s.procs); i++ {
+		go s.run(s.procs[i])
+	}
+}
+
+// Fatal receives the error of a critical process which stopped unexpectedly, it's closed once shutdown is done
+func (s *Supervisor) Fatal() <-chan error {
+	return s.fatalErr
+}
+
+// Statuses of the registered processes in order of registration
+func (s *Supervisor) Statuses() []Status {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	statuses := make([]Status, 0, len(s.procs))
+	for i := 0; i < len(s.procs); i++ {
+		statuses = append(statuses, s.procs[i].status())
+	}
+	return statuses
+}
+
+// Shutdown the processes in reverse order of registration, each process gets whatever is left of the context. Returns
+// the errors of the processes which failed to shutdown gracefully.
+func (s *Supervisor) Shutdown(ctx context.Context) error {
+	s.mu.Lock()
+	if s.closed {
+		s.mu.Unlock()
+		return nil
+	}
+	s.closed = true
+	started := s.started
+	s.mu.Unlock()
+
+	var errs []string
+	for i := len(s.procs) - 1; i >= 0; i-- {
+		p := s.procs[i]
+		if err := p.stop(ctx, started); err != nil {
+			s.logger.Error().Caller().Err(err).Str("process", p.Name()).Msg("failed to shutdown process gracefully")
+			errs = append(errs, fmt.Sprintf("%s: %v", p.Name(), err))
+		} else {
+			s.logger.Info().Str("process", p.Name()).Msg("shutdown process gracefully")
+		}
+	}
+
+	s.mu.Lock()
+	close(s.fatalErr)
+	s.mu.Unlock()
+
+	if len(errs) > 0 {
+		return errors.New(strings.Join(errs, "; "))
+	}
+	return nil
+}
+
+// run the process until it's stopped, restarting it according to its Policy
+func (s *Supervisor) run(p *proc) {
+	defer close(p.done)
+
+	backoff := time.Duration(0)
+	for {
+		p.setState(StateRunning, nil)
+		err := p.start()
+		if p.isStopping() {
+			p.setState(StateStopped, nil)
+			return
+		}
+		if err == nil {
+			err = errors.New("process stopped unexpectedly")
+		}
+
+		if !p.policy.Restart {
+			p.setState(StateFailed, err)
+			s.logger.Error().Caller().Err(err).Str("process", p.Name()).Msg("critical process failed")
+			s.fatal(fmt.Errorf("%s: %w", p.Name(), err))
+			return
+		}
+		if p.policy.MaxRestarts > 0 && p.restartCount() >= p.policy.MaxRestarts {
+			p.setState(StateFailed, err)
+			s.logger.Error().Caller().Err(err).Str("process", p.Name()).Msg("process failed, restarts exhausted")
+			return
+		}
+
+		backoff = nextBackoff(backoff, p.policy.MaxBackoff)
+		p.setState(StateRestarting, err)
+		s.logger.Error().Caller().Err(err).Str("process", p.Name()).Dur("backoff", backoff).Msg("process failed, restarting")
+
+		select {
+		case <-p.ctx.Done():
+			p.setState(StateStopped, nil)
+			return
+		case <-time.After(backoff):
+		}
+		p.mu.Lock()
+		p.restarts++
+		p.mu.Unlock()
+	}
+}
+
+func (s *Supervisor) fatal(err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.closed {
+		return
+	}
+	select {
+	case s.fatalErr <- err:
+	default:
+	}
+}
+
+// start the process, a panic is returned as an error so it's handled by the Policy
+func (p *proc) start() (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("panic: %v", r)
+		}
+	}()
+
+	return p.Start(p.ctx)
+}
+
+// stop shuts down the process, then cancels its context and waits for it to return
+func (p *proc) stop(ctx context.Context, started bool) error {
+	p.mu.Lock()
+	p.stopping = true
+	p.mu.Unlock()
+
+	err := p.Shutdown(ctx)
+	p.cancel()
+	if !started {
+		return err
+	}
+
+	select {
+	case <-p.done:
+	case <-ctx.Done():
+		if err == nil {
+			err = ctx.Err()
+		}
+	}
+	return err
+}
+
+func (p *proc) setState(state State, err error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.state = state
+	if err != nil {
+		p.lastErr = err
+	}
+}
+
+func (p *proc) isStopping() bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	return p.stopping
+}
+
+func (p *proc) restartCount() int {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	return p.restarts
+}
+
+func (p *proc) status() Status {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	status := Status{
+		Name:     p.Name(),
+		State:    p.state,
+		Restarts: p.restarts,
+	}
+	if p.lastErr != nil {
+		status.LastError = p.lastErr.Error()
+	}
+	return status
+}
+
+func nextBackoff(current, max time.Duration) time.Duration {
+	if current == 0 {
+		return 100 * time.Millisecond
+	}
+	if max > 0 && current*2 > max {
+		return max
+	}
+	return current * 2
+}
@@ -0,0 +1,166 @@
+package supervisor
+
+import (
+	"context"
+	"errors"
+	"os"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/rs/zerolog"
+)
+
+// fakeProcess fails its first `failures` runs, then runs until it's shutdown
+type fakeProcess struct {
+	name     string
+	failures int
+	order    *[]string
+	orderMu  *sync.Mutex
+
+	mu   sync.Mutex
+	runs int
+	stop chan struct{}
+}
+
+func newFakeProcess(name string, failures int, order *[]string, orderMu *sync.Mutex) *fakeProcess {
+	return &fakeProcess{
+		name:     name,
+		failures: failures,
+		order:    order,
+		orderMu:  orderMu,
+		stop:     make(chan struct{}),
+	}
+}
+
+func (f *fakeProcess) Name() string {
+	return f.name
+}
+
+func (f *fakeProcess) Start(ctx context.Context) error {
+	f.mu.Lock()
+	f.runs++
+	runs := f.runs
+	f.mu.Unlock()
+
+	if runs <= f.failures {
+		return errors.New("failed")
+	}
+
+	select {
+	case <-f.stop:
+	case <-ctx.Done():
+	}
+	return nil
+}
+
+func (f *fakeProcess) Shutdown(_ context.Context) error {
+	f.orderMu.Lock()
+	*f.order = append(*f.order, f.name)
+	f.orderMu.Unlock()
+
+	close(f.stop)
+	return nil
+}
+
+func waitForState(t *testing.T, s *Supervisor, name string, state State) Status {
+	deadline := time.Now().Add(5 * time.Second)
+	for time.Now().Before(deadline) {
+		statuses := s.Statuses()
+		for i := 0; i < len(statuses); i++ {
+			if statuses[i].Name == name && statuses[i].State == state {
+				return statuses[i]
+			}
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	t.Fatalf("process %s never reached state %s: %v", name, state, s.Statuses())
+	return Status{}
+}
+
+func TestSupervisor(t *testing.T) {
+	t.Run("shutdownInReverseOrder", func(t *testing.T) {
+		var order []string
+		var orderMu sync.Mutex
+		s := New(zerolog.New(os.Stdout))
+		s.Register(newFakeProcess("postgres", 0, &order, &orderMu), Critical)
+		s.Register(newFakeProcess("relay", 0, &order, &orderMu), Policy{Restart: true})
+		s.Register(newFakeProcess("http", 0, &order, &orderMu), Critical)
+		s.Start()
+		waitForState(t, s, "http", StateRunning)
+
+		if err := s.Shutdown(context.Background()); err != nil {
+			t.Errorf("unexpected shutdown error: %v", err)
+		}
+
+		expected := []string{"http", "relay", "postgres"}
+		if !reflect.DeepEqual(order, expected) {
+			t.Errorf("wrong shutdown order: got %v want %v", order, expected)
+		}
+		for _, status := range s.Statuses() {
+			if status.State != StateStopped {
+				t.Errorf("process %s wasn't stopped: %v", status.Name, status.State)
+			}
+		}
+		if _, ok := <-s.Fatal(); ok {
+			t.Errorf("fatal channel wasn't closed after shutdown")
+		}
+	})
+
+	t.Run("restartsFailedWorkers", func(t *testing.T) {
+		var order []string
+		var orderMu sync.Mutex
+		s := New(zerolog.New(os.Stdout))
+		s.Register(newFakeProcess("relay", 2, &order, &orderMu), Policy{Restart: true, MaxBackoff: time.Millisecond})
+		s.Start()
+		defer s.Shutdown(context.Background())
+
+		status := waitForState(t, s, "relay", StateRunning)
+		for status.Restarts < 2 {
+			status = waitForState(t, s, "relay", StateRunning)
+		}
+		if status.LastError != "failed" {
+			t.Errorf("wrong last error: got %v want %v", status.LastError, "failed")
+		}
+		select {
+		case err := <-s.Fatal():
+			t.Errorf("unexpected fatal error: %v", err)
+		default:
+		}
+	})
+
+	t.Run("maxRestarts", func(t *testing.T) {
+		var order []string
+		var orderMu sync.Mutex
+		s := New(zerolog.New(os.Stdout))
+		s.Register(newFakeProcess("relay", 5, &order, &orderMu),
+			Policy{Restart: true, MaxRestarts: 1, MaxBackoff: time.Millisecond})
+		s.Start()
+		defer s.Shutdown(context.Background())
+
+		status := waitForState(t, s, "relay", StateFailed)
+		if status.Restarts != 1 {
+			t.Errorf("wrong restarts: got %v want %v", status.Restarts, 1)
+		}
+	})
+
+	t.Run("failedCriticalProcess", func(t *testing.T) {
+		var order []string
+		var orderMu sync.Mutex
+		s := New(zerolog.New(os.Stdout))
+		s.Register(newFakeProcess("http", 1, &order, &orderMu), Critical)
+		s.Start()
+		defer s.Shutdown(context.Background())
+
+		select {
+		case err := <-s.Fatal():
+			if err == nil || err.Error() != "http: failed" {
+				t.Errorf("wrong fatal error: got %v want %v", err, "http: failed")
+			}
+		case <-time.After(5 * time.Second):
+			t.Errorf("critical failure wasn't reported")
+		}
+		waitForState(t, s, "http", StateFailed)
+	})
+}
@@ -0,0 +1,37 @@
+package router
+
+import (
+	"github.com/go-chi/chi"
+	"github.com/go-chi/chi/middleware"
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/alexsniffin/go-api-starter/internal/todo-api/handlers/admin"
+	"github.com/alexsniffin/go-api-starter/internal/todo-api/handlers/health"
+	"github.com/alexsniffin/go-api-starter/internal/todo-api/metrics"
+)
+
+// Creates Chi based multiplexer router of the internal admin endpoints, which must not be exposed publicly
+func NewAdminRouter(
+	registry prometheus.Gatherer,
+	adminHandler admin.Handler,
+	healthHandler health.Handler,
+) *chi.Mux {
+	r := chi.NewRouter()
+
+	r.Use(middleware.Recoverer)
+
+	r.Get("/livez", healthHandler.Livez)
+	r.Get("/readyz", healthHandler.Readyz)
+	r.Get("/startupz", healthHandler.Startupz)
+
+	r.Get("/metrics", metrics.Handler(registry).ServeHTTP)
+	r.Mount("/debug", middleware.Profiler())
+
+	r.Get("/buildinfo", adminHandler.BuildInfo)
+	r.Route("/loglevel", func(r chi.Router) {
+		r.Get("/", adminHandler.GetLogLevel)
+		r.Put("/", adminHandler.SetLogLevel)
+	})
+	r.Post("/debugtoken", adminHandler.PostDebugToken)
+	return r
+}
@@ -1,62 +1,102 @@
 package router
 
 import (
-	"net/http"
-	"time"
-
 	"github.com/go-chi/chi"
 	"github.com/go-chi/chi/middleware"
-	"github.com/go-chi/cors"
-	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"github.com/prometheus/client_golang/prometheus"
 	"github.com/rs/zerolog"
 	httpMetrics "github.com/slok/go-http-metrics/metrics/prometheus"
 	httpMiddleware "github.com/slok/go-http-metrics/middleware"
 	nm "github.com/slok/go-http-metrics/middleware/negroni"
 	"github.com/urfave/negroni"
 
+	"github.com/alexsniffin/go-api-starter/internal/todo-api/handlers/auth"
+	"github.com/alexsniffin/go-api-starter/internal/todo-api/handlers/cache"
+	"github.com/alexsniffin/go-api-starter/internal/todo-api/handlers/compress"
+	"github.com/alexsniffin/go-api-starter/internal/todo-api/handlers/events"
+	"github.com/alexsniffin/go-api-starter/internal/todo-api/handlers/gql"
+	"github.com/alexsniffin/go-api-starter/internal/todo-api/handlers/health"
 	lHandler "github.com/alexsniffin/go-api-starter/internal/todo-api/handlers/logging"
 	"github.com/alexsniffin/go-api-starter/internal/todo-api/handlers/todo"
+	tHandler "github.com/alexsniffin/go-api-starter/internal/todo-api/handlers/tracing"
+	"github.com/alexsniffin/go-api-starter/internal/todo-api/handlers/ws"
 	"github.com/alexsniffin/go-api-starter/internal/todo-api/models"
+	pkgModels "github.com/alexsniffin/go-api-starter/pkg/models"
 )
 
 // Creates Chi based multiplexer router with middleware
-func NewRouter(cfg models.HTTPRouterConfig, logger zerolog.Logger, todoHandler todo.Handler) *chi.Mux {
+func NewRouter(
+	cfg models.HTTPRouterConfig,
+	loggerCfg pkgModels.Logger,
+	settings *Settings,
+	logger zerolog.Logger,
+	registry prometheus.Registerer,
+	todoHandler todo.Handler,
+	eventsHandler events.Handler,
+	wsHandler ws.Handler,
+	gqlHandler gql.Handler,
+	healthHandler health.Handler,
+) *chi.Mux {
 	r := chi.NewRouter()
 
 	r.Use(middleware.RequestID)
 	r.Use(middleware.RealIP)
 	r.Use(middleware.Recoverer)
-	r.Use(lHandler.NewHandlerFunc(logger))
-	r.Use(middleware.Timeout(time.Duration(cfg.TimeoutSec) * time.Second))
+	r.Use(tHandler.NewDefaultHandlerFunc())
+	r.Use(lHandler.NewHandlerFunc(logger, loggerCfg))
+	r.Use(auth.NewHandlerFunc(cfg.UserHeader))
+	r.Use(compress.NewHandlerFunc(cfg.Compression))
 
 	httpMw := httpMiddleware.New(httpMiddleware.Config{
 		DisableMeasureInflight: true,
-		Recorder:               httpMetrics.NewRecorder(httpMetrics.Config{}),
+		Recorder:               httpMetrics.NewRecorder(httpMetrics.Config{Registry: registry}),
 	})
 
-	r.Use(cors.Handler(cors.Options{
-		AllowedOrigins:   cfg.AllowedOrigins,
-		AllowedMethods:   cfg.AllowedMethods,
-		AllowedHeaders:   cfg.AllowedHeaders,
-		AllowCredentials: false,
-	}))
+	r.Use(settings.corsHandler)
+
+	// reads of todos are revalidated by the client, exports and mutations aren't stored
+	privateCache := cache.NewHandlerFunc(cache.Private(cfg.UserHeader))
+	noStore := cache.NewHandlerFunc(cache.NoStore)
+	exportCache := cache.NewHandlerFunc(cache.Policy{CacheControl: "private, no-store", Vary: []string{cfg.UserHeader}})
 
 	r.Route("/api", func(r chi.Router) {
-		r.Route("/todo", func(r chi.Router) {
-			r.Route("/{id}", func(r chi.Router) {
-				idMetricHandler := nm.Handler("/api/todo/{id}", httpMw)
-				r.Get("/", negroni.New(idMetricHandler, negroni.WrapFunc(todoHandler.Get)).ServeHTTP)
-				r.Delete("/", negroni.New(idMetricHandler, negroni.WrapFunc(todoHandler.Delete)).ServeHTTP)
+		// event streams, websockets, subscriptions, exports and imports are long-lived, so they are excluded from the
+		// request timeout
+		r.Get("/todo/events", negroni.New(nm.Handler("/api/todo/events", httpMw),
+			negroni.WrapFunc(eventsHandler.Stream)).ServeHTTP)
+		r.With(exportCache).Get("/todo/export", negroni.New(nm.Handler("/api/todo/export", httpMw),
+			negroni.WrapFunc(todoHandler.Export)).ServeHTTP)
+		r.With(noStore).Post("/todo/import", negroni.New(nm.Handler("/api/todo/import", httpMw),
+			negroni.WrapFunc(todoHandler.Import)).ServeHTTP)
+		r.Get("/ws", negroni.New(nm.Handler("/api/ws", httpMw), negroni.WrapFunc(wsHandler.Connect)).ServeHTTP)
+		gqlMetricHandler := nm.Handler("/api/graphql", httpMw)
+		r.Get("/graphql", negroni.New(gqlMetricHandler, negroni.WrapFunc(gqlHandler.Subscribe)).ServeHTTP)
+
+		r.Group(func(r chi.Router) {
+			r.Use(settings.timeoutHandler)
+
+			r.Route("/todo", func(r chi.Router) {
+				r.Route("/{id}", func(r chi.Router) {
+					idMetricHandler := nm.Handler("/api/todo/{id}", httpMw)
+					r.With(privateCache).Get("/", negroni.New(idMetricHandler,
+						negroni.WrapFunc(todoHandler.Get)).ServeHTTP)
+					r.With(noStore).Put("/", negroni.New(idMetricHandler, negroni.WrapFunc(todoHandler.Put)).ServeHTTP)
+					r.With(noStore).Delete("/", negroni.New(idMetricHandler,
+						negroni.WrapFunc(todoHandler.Delete)).ServeHTTP)
+				})
+				todoMetricHandler := nm.Handler("/api/todo", httpMw)
+				r.With(privateCache).Get("/", negroni.New(todoMetricHandler,
+					negroni.WrapFunc(todoHandler.List)).ServeHTTP)
+				r.With(noStore).Post("/", negroni.New(todoMetricHandler, negroni.WrapFunc(todoHandler.Post)).ServeHTTP)
 			})
-			r.Post("/", negroni.New(nm.Handler("/api/todo", httpMw), negroni.WrapFunc(todoHandler.Post)).ServeHTTP)
-		})
-		r.Get("/health", func(w http.ResponseWriter, _ *http.Request) {
-			w.WriteHeader(http.StatusOK)
+			r.With(noStore).Post("/graphql", negroni.New(gqlMetricHandler,
+				negroni.WrapFunc(gqlHandler.Query)).ServeHTTP)
+			r.With(noStore).Get("/health", healthHandler.Readyz)
 		})
 	})
 
-	r.Route("/metrics", func(r chi.Router) {
-		r.Get("/", promhttp.Handler().ServeHTTP)
-	})
+	r.Get("/livez", healthHandler.Livez)
+	r.Get("/readyz", healthHandler.Readyz)
+	r.Get("/startupz", healthHandler.Startupz)
 	return r
 }
@@ -0,0 +1,56 @@
+package router
+
+import (
+	"net/http"
+	"sync/atomic"
+	"time"
+
+	"github.com/go-chi/chi/middleware"
+	"github.com/go-chi/cors"
+
+	"github.com/alexsniffin/go-api-starter/internal/todo-api/models"
+)
+
+// Settings of the router which are applied to running requests without a restart, the CORS lists and the request
+// timeout
+type Settings struct {
+	cors           atomic.Value
+	allowedOrigins atomic.Value
+	timeout        int64
+}
+
+// NewSettings creates the settings of the router from the config
+func NewSettings(cfg models.HTTPRouterConfig) *Settings {
+	s := &Settings{}
+	s.Apply(cfg)
+	return s
+}
+
+// Apply replaces the settings, requests in flight keep the settings they started with
+func (s *Settings) Apply(cfg models.HTTPRouterConfig) {
+	s.cors.Store(cors.New(cors.Options{
+		AllowedOrigins:   cfg.AllowedOrigins,
+		AllowedMethods:   cfg.AllowedMethods,
+		AllowedHeaders:   cfg.AllowedHeaders,
+		AllowCredentials: false,
+	}))
+	s.allowedOrigins.Store(cfg.AllowedOrigins)
+	atomic.StoreInt64(&s.timeout, int64(time.Duration(cfg.TimeoutSec)*time.Second))
+}
+
+// AllowedOrigins returns the origins allowed to make cross-origin requests
+func (s *Settings) AllowedOrigins() []string {
+	return s.allowedOrigins.Load().([]string)
+}
+
+func (s *Settings) corsHandler(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		s.cors.Load().(*cors.Cors).Handler(next).ServeHTTP(w, r)
+	})
+}
+
+func (s *Settings) timeoutHandler(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		middleware.Timeout(time.Duration(atomic.LoadInt64(&s.timeout)))(next).ServeHTTP(w, r)
+	})
+}
@@ -0,0 +1,60 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/alexsniffin/go-api-starter/internal/todo-api/clients/postgres"
+	"github.com/alexsniffin/go-api-starter/internal/todo-api/health"
+	"github.com/alexsniffin/go-api-starter/internal/todo-api/models"
+	"github.com/alexsniffin/go-api-starter/internal/todo-api/processes/outbox"
+	"github.com/alexsniffin/go-api-starter/internal/todo-api/processes/supervisor"
+)
+
+// registerHealthChecks registers the checks of the server's dependencies. Liveness only depends on the processes, so
+// an unavailable database fails readiness without restarting the server.
+func registerHealthChecks(
+	cfg models.HealthConfig,
+	checks *health.Checks,
+	pgClient *postgres.Client,
+	outboxRelay *outbox.Relay,
+	processes *supervisor.Supervisor,
+) {
+	checks.Register("processes", func(context.Context) error {
+		return processesInState(processes.Statuses(), supervisor.StateFailed)
+	}, health.Liveness)
+
+	checks.Register("started", func(context.Context) error {
+		return processesInState(processes.Statuses(), supervisor.StatePending)
+	}, health.Startup)
+
+	checks.Register("postgres", pgClient.Ping, health.Readiness, health.Startup)
+	checks.Register("migrations", pgClient.CheckMigrations, health.Readiness, health.Startup)
+
+	// the outbox is shared by all replicas, so a lagging relay is reported without failing readiness
+	checks.RegisterWarning("outbox_lag", func(ctx context.Context) error {
+		lag, err := outboxRelay.Lag(ctx)
+		if err != nil {
+			return err
+		}
+		if maxLag := time.Duration(cfg.MaxOutboxLagSec) * time.Second; lag > maxLag {
+			return fmt.Errorf("oldest unsent event is %s old, exceeds %s", lag.Round(time.Second), maxLag)
+		}
+		return nil
+	}, health.Readiness)
+}
+
+func processesInState(statuses []supervisor.Status, state supervisor.State) error {
+	var names []string
+	for i := 0; i < len(statuses); i++ {
+		if statuses[i].State == state {
+			names = append(names, statuses[i].Name)
+		}
+	}
+	if len(names) > 0 {
+		return fmt.Errorf("processes %s: %s", state, strings.Join(names, ", "))
+	}
+	return nil
+}
@@ -0,0 +1,52 @@
+package server
+
+import (
+	"github.com/pkg/errors"
+	"github.com/rs/zerolog"
+
+	"github.com/alexsniffin/go-api-starter/internal/todo-api/models"
+	"github.com/alexsniffin/go-api-starter/pkg/config"
+	"github.com/alexsniffin/go-api-starter/pkg/logger"
+)
+
+// ErrNotReloadable is returned by Reload if a setting changed which requires a restart
+var ErrNotReloadable = errors.New("settings changed which can't be reloaded without a restart")
+
+// Reload applies the settings which are safe to change while running, the CORS lists and timeout of the router and
+// the log level. The config is rejected as a whole if any other setting changed, the changes are logged.
+func (s *Server) Reload(cfg models.Config) error {
+	s.cfgMu.Lock()
+	defer s.cfgMu.Unlock()
+
+	if changes := config.Diff(s.cfg, withReloadable(cfg, s.cfg)); len(changes) > 0 {
+		s.logger.Error().Strs("changes", changes).Msg("config changes require a restart")
+		return ErrNotReloadable
+	}
+
+	changes := config.Diff(s.cfg, cfg)
+	if len(changes) == 0 {
+		s.logger.Info().Msg("config unchanged")
+		return nil
+	}
+
+	level, err := zerolog.ParseLevel(cfg.Logger.Level)
+	if err != nil {
+		return err
+	}
+	logger.SetConfiguredLevel(level)
+	s.routerSettings.Apply(cfg.HTTPRouter)
+	s.cfg = cfg
+
+	s.logger.Info().Strs("changes", changes).Msg("config reloaded")
+	return nil
+}
+
+// withReloadable returns the config with the reloadable settings of src
+func withReloadable(cfg, src models.Config) models.Config {
+	cfg.Logger.Level = src.Logger.Level
+	cfg.HTTPRouter.TimeoutSec = src.HTTPRouter.TimeoutSec
+	cfg.HTTPRouter.AllowedOrigins = src.HTTPRouter.AllowedOrigins
+	cfg.HTTPRouter.AllowedMethods = src.HTTPRouter.AllowedMethods
+	cfg.HTTPRouter.AllowedHeaders = src.HTTPRouter.AllowedHeaders
+	return cfg
+}
@@ -0,0 +1,59 @@
+package server
+
+import (
+	"errors"
+	"os"
+	"reflect"
+	"testing"
+
+	"github.com/rs/zerolog"
+
+	"github.com/alexsniffin/go-api-starter/internal/todo-api/models"
+	"github.com/alexsniffin/go-api-starter/internal/todo-api/router"
+	"github.com/alexsniffin/go-api-starter/pkg/logger"
+)
+
+func initReloadServer() (*Server, models.Config) {
+	cfg := models.Config{}
+	cfg.Logger.Level = "info"
+	cfg.HTTPRouter = models.HTTPRouterConfig{TimeoutSec: 30, AllowedOrigins: []string{"https://a.example.com"}}
+	cfg.Database.Port = 5432
+
+	return &Server{
+		cfg:            cfg,
+		logger:         zerolog.New(os.Stdout),
+		routerSettings: router.NewSettings(cfg.HTTPRouter),
+	}, cfg
+}
+
+func TestServer_Reload(t *testing.T) {
+	t.Run("reloadableSettings", func(t *testing.T) {
+		s, cfg := initReloadServer()
+		cfg.Logger.Level = "warn"
+		cfg.HTTPRouter.AllowedOrigins = []string{"https://b.example.com"}
+		defer logger.SetConfiguredLevel(zerolog.InfoLevel)
+
+		if err := s.Reload(cfg); err != nil {
+			t.Errorf("unexpected error: %v", err)
+		}
+		if origins := s.routerSettings.AllowedOrigins(); !reflect.DeepEqual(origins, cfg.HTTPRouter.AllowedOrigins) {
+			t.Errorf("wrong allowed origins: got %v want %v", origins, cfg.HTTPRouter.AllowedOrigins)
+		}
+		if level, _ := logger.Level(); level != zerolog.WarnLevel {
+			t.Errorf("wrong level: got %v want %v", level, zerolog.WarnLevel)
+		}
+	})
+
+	t.Run("notReloadableSetting", func(t *testing.T) {
+		s, cfg := initReloadServer()
+		cfg.HTTPRouter.AllowedOrigins = []string{"https://b.example.com"}
+		cfg.Database.Port = 5433
+
+		if err := s.Reload(cfg); !errors.Is(err, ErrNotReloadable) {
+			t.Errorf("wrong error: got %v want %v", err, ErrNotReloadable)
+		}
+		if origins := s.routerSettings.AllowedOrigins(); origins[0] != "https://a.example.com" {
+			t.Errorf("allowed origins of rejected config applied: %v", origins)
+		}
+	})
+}
@@ -2,111 +2,199 @@ package server
 
 import (
 	"context"
-	"os"
 	"sync"
 	"time"
 
+	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus"
+
 	"github.com/rs/zerolog"
 	"github.com/unrolled/render"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
 
 	"github.com/alexsniffin/go-api-starter/internal/todo-api/clients/postgres"
+	"github.com/alexsniffin/go-api-starter/internal/todo-api/events"
+	adminHandler "github.com/alexsniffin/go-api-starter/internal/todo-api/handlers/admin"
+	eventsHandler "github.com/alexsniffin/go-api-starter/internal/todo-api/handlers/events"
+	gqlHandler "github.com/alexsniffin/go-api-starter/internal/todo-api/handlers/gql"
+	healthHandler "github.com/alexsniffin/go-api-starter/internal/todo-api/handlers/health"
+	"github.com/alexsniffin/go-api-starter/internal/todo-api/handlers/rpc"
 	todoHandler "github.com/alexsniffin/go-api-starter/internal/todo-api/handlers/todo"
+	wsHandler "github.com/alexsniffin/go-api-starter/internal/todo-api/handlers/ws"
+	"github.com/alexsniffin/go-api-starter/internal/todo-api/health"
 	"github.com/alexsniffin/go-api-starter/internal/todo-api/models"
+	"github.com/alexsniffin/go-api-starter/internal/todo-api/processes/grpc"
 	"github.com/alexsniffin/go-api-starter/internal/todo-api/processes/http"
+	"github.com/alexsniffin/go-api-starter/internal/todo-api/processes/outbox"
+	"github.com/alexsniffin/go-api-starter/internal/todo-api/processes/reload"
+	"github.com/alexsniffin/go-api-starter/internal/todo-api/processes/supervisor"
 	"github.com/alexsniffin/go-api-starter/internal/todo-api/router"
 	"github.com/alexsniffin/go-api-starter/internal/todo-api/store/todo"
+	"github.com/alexsniffin/go-api-starter/pkg/config"
 )
 
+// ErrShutdownTimeout is returned by Shutdown if the processes didn't shutdown before the deadline
+var ErrShutdownTimeout = errors.New("shutdown deadline reached, remaining processes terminated ungracefully")
+
 // Server handles the runtime of the application.
 type Server struct {
+	cfgMu  sync.Mutex
 	cfg    models.Config
 	logger zerolog.Logger
 
-	httpServer *http.Server
-	pgClient   postgres.Client
+	supervisor     *supervisor.Supervisor
+	healthHandler  healthHandler.Handler
+	routerSettings *router.Settings
 
-	fatalErrCh chan error
-	shutdown   sync.Once
+	shutdown    sync.Once
+	shutdownErr error
 }
 
-// NewServer creates a new server instance with dependencies.
-func NewServer(cfg models.Config, logger zerolog.Logger) *Server {
+// NewServer creates a new server instance with dependencies. The config is reloaded with the loader while running.
+func NewServer(
+	cfg models.Config,
+	logger zerolog.Logger,
+	tracerProvider *sdktrace.TracerProvider,
+	loader *config.Loader,
+) (*Server, error) {
 	// set up pg client
 	newPgClient, err := postgres.NewClient(logger, cfg.Database)
 	if err != nil {
-		logger.Panic().Caller().Err(err).Msg("failed to initialize pg client")
+		return nil, errors.Wrap(err, "failed to initialize pg client")
 	}
 
 	// set up store and handler
 	newTodoStore := todo.NewStore(newPgClient)
-	newTodoHandler := todoHandler.NewHandler(logger, render.New(), newTodoStore)
+	newTodoHandler := todoHandler.NewHandler(cfg.REST, logger, render.New(), &newTodoStore)
+
+	// collectors of this server are registered with its own registry, the process wide metrics are served with them
+	newRegistry := prometheus.NewRegistry()
+	for _, collector := range []prometheus.Collector{postgres.NewPoolCollector(&newPgClient),
+		todo.NewOpenTodosCollector(logger, &newTodoStore)} {
+		if err = newRegistry.Register(collector); err != nil {
+			return nil, errors.Wrap(err, "failed to register metrics")
+		}
+	}
+
+	// set up event hub, stream and websocket handlers
+	newEventHub := events.NewHub(cfg.Events)
+	newEventsHandler := eventsHandler.NewHandler(cfg.Events, logger, newEventHub)
+	newRouterSettings := router.NewSettings(cfg.HTTPRouter)
+	newWsHandler := wsHandler.NewHandler(cfg.WebSocket, newRouterSettings.AllowedOrigins, logger, newTodoStore,
+		newEventHub)
+
+	// set up GraphQL handler
+	newGqlHandler, err := gqlHandler.NewHandler(cfg.GraphQL, logger, render.New(), newTodoStore, newEventHub)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to initialize graphql schema")
+	}
 
 	// set up router and HTTP server
-	newRouter := router.NewRouter(cfg.HTTPRouter, logger, newTodoHandler)
-	newHTTPServer := http.NewServer(cfg.HTTPServer, logger, newRouter)
-
-	return &Server{
-		cfg:        cfg,
-		logger:     logger,
-		httpServer: newHTTPServer,
-		pgClient:   newPgClient,
-		fatalErrCh: make(chan error),
+	newHealthChecks := health.NewChecks(cfg.Health)
+	newHealthHandler := healthHandler.NewHandler(logger, render.New(), newHealthChecks)
+	newRouter := router.NewRouter(cfg.HTTPRouter, cfg.Logger, newRouterSettings, logger, newRegistry, newTodoHandler,
+		newEventsHandler, newWsHandler, newGqlHandler, newHealthHandler)
+	newHTTPServer, err := http.NewServer(cfg.HTTPServer, logger, newRouter)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to initialize http server")
+	}
+	newHTTPServer.RegisterOnShutdown(newEventHub.Close)
+
+	// set up admin HTTP server on the internal port
+	newAdminServer := http.NewAdminServer(cfg.AdminServer, logger,
+		router.NewAdminRouter(newRegistry, adminHandler.NewHandler(cfg.Logger, logger, render.New()), newHealthHandler))
+
+	// set up gRPC server
+	newGRPCServer := grpc.NewServer(cfg.GRPCServer, cfg.HTTPRouter.UserHeader, logger,
+		rpc.NewTodoService(newTodoStore, newEventHub))
+
+	// set up outbox relay for domain events and the change feed, which broadcasts the events of all replicas to the
+	// subscribers of the event hub
+	newOutboxRelay := outbox.NewRelay(cfg.Outbox, logger, &newPgClient, outbox.NewLogPublisher(logger))
+	newChangeFeed := postgres.NewChangeFeed(cfg.ChangeFeed, logger, &newPgClient, newEventHub)
+
+	// register processes after their dependencies, they're shutdown in reverse order. The http and grpc servers are
+	// shutdown first to prevent new requests, websocket connections are hijacked from the http server and have to be
+	// closed separately, relaying and listening for events stops before closing the pg connection they depend on. The
+	// admin server is shutdown last so metrics and probes are served while draining.
+	workerPolicy := supervisor.Policy{
+		Restart:     true,
+		MaxRestarts: cfg.Supervisor.MaxRestarts,
+		MaxBackoff:  time.Duration(cfg.Supervisor.MaxBackoffMs) * time.Millisecond,
+	}
+	newSupervisor := supervisor.New(logger)
+	newSupervisor.Register(supervisor.NewCloser("tracing", tracerProvider.Shutdown), supervisor.Critical)
+	newSupervisor.Register(newAdminServer, supervisor.Critical)
+	newSupervisor.Register(supervisor.NewCloser("postgres", func(context.Context) error {
+		return newPgClient.Shutdown()
+	}), supervisor.Critical)
+	newSupervisor.Register(newChangeFeed, workerPolicy)
+	newSupervisor.Register(newOutboxRelay, workerPolicy)
+	newSupervisor.Register(supervisor.NewCloser("websocket", newWsHandler.Shutdown), supervisor.Critical)
+	newSupervisor.Register(newGRPCServer, supervisor.Critical)
+	newSupervisor.Register(newHTTPServer, supervisor.Critical)
+
+	registerHealthChecks(cfg.Health, newHealthChecks, &newPgClient, newOutboxRelay, newSupervisor)
+
+	newServer := &Server{
+		cfg:            cfg,
+		logger:         logger,
+		supervisor:     newSupervisor,
+		healthHandler:  newHealthHandler,
+		routerSettings: newRouterSettings,
 	}
+	newSupervisor.Register(reload.NewReloader(logger, loader, newServer.Reload), workerPolicy)
+
+	return newServer, nil
 }
 
-// Start invokes all asynchronous server processes.
-func (s *Server) Start() {
-	go s.httpServer.Start(s.fatalErrCh)
+// Start invokes all asynchronous server processes and marks the server as ready. Blocks until a critical process
+// fails, returning its error, or the server is shutdown.
+func (s *Server) Start() error {
+	s.supervisor.Start()
+	s.healthHandler.SetReady(true)
 
-	for err := range s.fatalErrCh {
-		if err != nil {
-			s.logger.Error().Caller().Err(err).Msg("fatal error received from process")
-			s.Shutdown(true)
-		}
+	if err, ok := <-s.supervisor.Fatal(); ok {
+		return err
 	}
+	return nil
 }
 
-// Shutdown signals the shutdown process across all processes in the server.
-func (s *Server) Shutdown(fromErr bool) {
+// Statuses reports the state of the server processes.
+func (s *Server) Statuses() []supervisor.Status {
+	return s.supervisor.Statuses()
+}
+
+// Shutdown the server within `Shutdown.TimeoutSec` or the deadline of the context. Readiness fails first and the
+// server keeps serving for `Shutdown.DrainDelaySec`, so load balancers stop routing to it before its listeners are
+// closed. Returns the errors of the processes which failed to shutdown gracefully, further calls return the same.
+func (s *Server) Shutdown(ctx context.Context) error {
 	s.shutdown.Do(func() {
-		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		s.cfgMu.Lock()
+		cfg := s.cfg.Shutdown
+		s.cfgMu.Unlock()
+
+		ctx, cancel := context.WithTimeout(ctx, time.Duration(cfg.TimeoutSec)*time.Second)
 		defer cancel()
 
-		graceful := make(chan bool)
-
-		// terminate the server if the deadline is reached, regardless of shutdown processes and returns exit status 2
-		go func(graceful <-chan bool) {
-			for {
-				select {
-				case <-ctx.Done():
-					s.logger.Panic().Msg("shutdown deadline reached, terminating remaining processes ungracefully")
-				case <-graceful:
-					return
-				}
+		s.healthHandler.SetReady(false)
+		if drainDelay := time.Duration(cfg.DrainDelaySec) * time.Second; drainDelay > 0 {
+			s.logger.Info().Dur("delay", drainDelay).Msg("readiness failing, draining before shutdown")
+			select {
+			case <-time.After(drainDelay):
+			case <-ctx.Done():
 			}
-		}(graceful)
-
-		// shutdown http server first to prevent new requests
-		err := s.httpServer.Shutdown(ctx)
-		if err != nil {
-			s.logger.Error().Caller().Err(err).Msg("failed to shutdown http server gracefully")
-		} else {
-			s.logger.Info().Msg("shutdown http server gracefully")
 		}
 
-		err = s.pgClient.Shutdown()
-		if err != nil {
-			s.logger.Error().Caller().Err(err).Msg("failed to shutdown postgres gracefully")
-		} else {
-			s.logger.Info().Msg("shutdown postgres gracefully")
-		}
-
-		close(s.fatalErrCh)
-		close(graceful)
-
-		if fromErr {
-			s.logger.Info().Msg("graceful shutdown succeeded, an error was detected, exiting with status code 1")
-			os.Exit(1)
+		s.shutdownErr = s.supervisor.Shutdown(ctx)
+		if ctx.Err() != nil {
+			if s.shutdownErr != nil {
+				s.shutdownErr = errors.Wrap(ErrShutdownTimeout, s.shutdownErr.Error())
+			} else {
+				s.shutdownErr = ErrShutdownTimeout
+			}
 		}
 	})
+
+	return s.shutdownErr
 }
@@ -0,0 +1,114 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/rs/zerolog"
+	"github.com/unrolled/render"
+
+	healthHandler "github.com/alexsniffin/go-api-starter/internal/todo-api/handlers/health"
+	"github.com/alexsniffin/go-api-starter/internal/todo-api/health"
+	"github.com/alexsniffin/go-api-starter/internal/todo-api/models"
+	"github.com/alexsniffin/go-api-starter/internal/todo-api/processes/supervisor"
+)
+
+type fakeProcess struct {
+	startErr   error
+	hang       bool
+	onShutdown func()
+	stop       chan struct{}
+}
+
+func (f *fakeProcess) Name() string {
+	return "fake"
+}
+
+func (f *fakeProcess) Start(ctx context.Context) error {
+	if f.startErr != nil {
+		return f.startErr
+	}
+	<-ctx.Done()
+	return nil
+}
+
+func (f *fakeProcess) Shutdown(ctx context.Context) error {
+	if f.onShutdown != nil {
+		f.onShutdown()
+	}
+	if f.hang {
+		<-ctx.Done()
+		return ctx.Err()
+	}
+	return nil
+}
+
+func initServer(cfg models.ShutdownConfig, process *fakeProcess) *Server {
+	logger := zerolog.New(os.Stdout)
+	newSupervisor := supervisor.New(logger)
+	newSupervisor.Register(process, supervisor.Critical)
+
+	return &Server{
+		cfg:           models.Config{Shutdown: cfg},
+		logger:        logger,
+		supervisor:    newSupervisor,
+		healthHandler: healthHandler.NewHandler(logger, render.New(), health.NewChecks(models.HealthConfig{})),
+	}
+}
+
+func TestServer(t *testing.T) {
+	t.Run("startFailedProcess", func(t *testing.T) {
+		s := initServer(models.ShutdownConfig{TimeoutSec: 1}, &fakeProcess{startErr: errors.New("listen failed")})
+
+		err := s.Start()
+		if err == nil || err.Error() != "fake: listen failed" {
+			t.Errorf("wrong start error: got %v want %v", err, "fake: listen failed")
+		}
+		if err = s.Shutdown(context.Background()); err != nil {
+			t.Errorf("unexpected shutdown error: %v", err)
+		}
+	})
+
+	t.Run("readinessFailsBeforeShutdown", func(t *testing.T) {
+		var readyOnShutdown bool
+		process := &fakeProcess{}
+		s := initServer(models.ShutdownConfig{TimeoutSec: 1}, process)
+		process.onShutdown = func() {
+			readyOnShutdown = s.healthHandler.IsReady()
+		}
+
+		started := make(chan error)
+		go func() {
+			started <- s.Start()
+		}()
+		for !s.healthHandler.IsReady() {
+			time.Sleep(time.Millisecond)
+		}
+
+		if err := s.Shutdown(context.Background()); err != nil {
+			t.Errorf("unexpected shutdown error: %v", err)
+		}
+		if readyOnShutdown {
+			t.Errorf("server was still ready when processes were shutdown")
+		}
+		if err := <-started; err != nil {
+			t.Errorf("unexpected start error: %v", err)
+		}
+	})
+
+	t.Run("shutdownTimeout", func(t *testing.T) {
+		s := initServer(models.ShutdownConfig{TimeoutSec: 1}, &fakeProcess{hang: true})
+		go s.Start()
+
+		err := s.Shutdown(context.Background())
+		if !errors.Is(err, ErrShutdownTimeout) {
+			t.Errorf("wrong shutdown error: got %v want %v", err, ErrShutdownTimeout)
+		}
+		if again := s.Shutdown(context.Background()); again != err {
+			t.Errorf("repeated shutdown returned a different error: got %v want %v", again, err)
+		}
+	})
+}
@@ -0,0 +1,71 @@
+package todo
+
+import (
+	"context"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/rs/zerolog"
+
+	"github.com/alexsniffin/go-api-starter/internal/todo-api/metrics"
+	"github.com/alexsniffin/go-api-starter/internal/todo-api/models"
+)
+
+// countTimeout of counting the open todos on a scrape
+const countTimeout = 5 * time.Second
+
+var (
+	todosCreated = promauto.With(metrics.Registry).NewCounter(prometheus.CounterOpts{
+		Name: "todos_created_total",
+		Help: "The number of todos created.",
+	})
+	todosCompleted = promauto.With(metrics.Registry).NewCounter(prometheus.CounterOpts{
+		Name: "todos_completed_total",
+		Help: "The number of todos marked as done.",
+	})
+	todosDeleted = promauto.With(metrics.Registry).NewCounter(prometheus.CounterOpts{
+		Name: "todos_deleted_total",
+		Help: "The number of todos deleted.",
+	})
+)
+
+// openTodosCollector counts the todos which aren't done on every scrape. The count is read from the database, so every
+// replica reports the same value.
+type openTodosCollector struct {
+	logger zerolog.Logger
+	store  *Store
+
+	open *prometheus.Desc
+}
+
+// NewOpenTodosCollector creates a collector of the number of open todos of the store
+func NewOpenTodosCollector(logger zerolog.Logger, store *Store) prometheus.Collector {
+	return &openTodosCollector{
+		logger: logger,
+		store:  store,
+
+		open: prometheus.NewDesc("todos_open", "The number of todos which aren't done.", nil, nil),
+	}
+}
+
+func (c *openTodosCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.open
+}
+
+func (c *openTodosCollector) Collect(ch chan<- prometheus.Metric) {
+	ctx, cancel := context.WithTimeout(context.Background(), countTimeout)
+	defer cancel()
+
+	count, err := c.store.pgClient.GetConnection().Model((*models.TodoItem)(nil)).
+		Context(ctx).
+		Where("done = false").
+		Count()
+	if err != nil {
+		c.logger.Error().Err(err).Caller().Msg("failed to count open todos")
+		ch <- prometheus.NewInvalidMetric(c.open, err)
+		return
+	}
+
+	ch <- prometheus.MustNewConstMetric(c.open, prometheus.GaugeValue, float64(count))
+}
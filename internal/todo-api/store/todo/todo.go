@@ -1,9 +1,16 @@
 package todo
 
 import (
+	"encoding/json"
 	"errors"
+	"time"
 
+	"github.com/go-pg/pg"
 	"github.com/rs/zerolog/log"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
 	"golang.org/x/net/context"
 
 	"github.com/alexsniffin/go-api-starter/internal/todo-api/clients/postgres"
@@ -12,10 +19,19 @@ import (
 
 type TodoStore interface {
 	GetTodo(ctx context.Context, id int) (models.TodoItem, bool, error)
+	ListTodos(ctx context.Context, filter models.TodoFilter) ([]models.TodoItem, error)
 	DeleteTodo(ctx context.Context, id int) (int, error)
 	PostTodo(ctx context.Context, todo models.TodoItem) (int, error)
+	UpdateTodo(ctx context.Context, todo models.TodoItem) (models.TodoItem, bool, error)
+	UpsertTodo(ctx context.Context, todo models.TodoItem) (models.TodoItem, bool, error)
+	ListTodoEvents(ctx context.Context, todoIDs []int) ([]models.TodoEvent, error)
 }
 
+// ErrExternalIDExists is returned by PostTodo if a TodoItem of the user with the external ID already exists
+var ErrExternalIDExists = errors.New("todo with the external id already exists")
+
+var tracer = otel.Tracer("github.com/alexsniffin/go-api-starter/internal/todo-api/store/todo")
+
 type Store struct {
 	pgClient postgres.DatabaseClient
 }
@@ -29,6 +45,9 @@ func NewStore(pgClient postgres.Client) Store {
 
 // GetTodo gets a TodoItem from the database
 func (s *Store) GetTodo(ctx context.Context, id int) (models.TodoItem, bool, error) {
+	ctx, span := tracer.Start(ctx, "TodoStore.GetTodo", trace.WithAttributes(attribute.Int("todo.id", id)))
+	defer span.End()
+
 	log.Ctx(ctx).Debug().Caller().Caller().Msg("get db request for todo")
 
 	var result models.TodoItem
@@ -41,6 +60,7 @@ func (s *Store) GetTodo(ctx context.Context, id int) (models.TodoItem, bool, err
 		if err.Error() == "pg: no rows in result set" {
 			return models.TodoItem{}, false, nil
 		}
+		recordError(span, err)
 		log.Ctx(ctx).Error().Err(err).Caller().Msg("failed to get todo from db")
 		return result, false, err
 	}
@@ -49,42 +69,292 @@ func (s *Store) GetTodo(ctx context.Context, id int) (models.TodoItem, bool, err
 	return result, true, nil
 }
 
-// DeleteTodo deletes a TodoItem from the database
+// ListTodos lists a page of TodoItems from the database ordered by ID. Todos of a list are shared, otherwise only the
+// todos of the user are listed unless the filter is of all users.
+func (s *Store) ListTodos(ctx context.Context, filter models.TodoFilter) ([]models.TodoItem, error) {
+	ctx, span := tracer.Start(ctx, "TodoStore.ListTodos", trace.WithAttributes(
+		attribute.String("todo.list", filter.List),
+		attribute.Int("page.limit", filter.Limit),
+	))
+	defer span.End()
+
+	log.Ctx(ctx).Debug().Caller().Msg("list db request for todos")
+
+	results := make([]models.TodoItem, 0, filter.Limit)
+	query := s.pgClient.GetConnection().
+		Model(&results).
+		Context(ctx).
+		Where("id > ?", filter.AfterID).
+		Order("id ASC").
+		Limit(filter.Limit)
+	if filter.List != "" {
+		query = query.Where("list = ?", filter.List)
+	} else if !filter.AllUsers {
+		query = query.Where("COALESCE(user_id, '') = ?", filter.UserID)
+	}
+	if filter.Done != nil {
+		query = query.Where("done = ?", *filter.Done)
+	}
+
+	err := query.Select()
+	if err != nil {
+		recordError(span, err)
+		log.Ctx(ctx).Error().Err(err).Caller().Msg("failed to list todos from db")
+		return nil, err
+	}
+
+	log.Ctx(ctx).Debug().Caller().Msgf("%d todos listed from db", len(results))
+	return results, nil
+}
+
+// DeleteTodo deletes a TodoItem from the database and records a TodoDeleted event in the same transaction
 func (s *Store) DeleteTodo(ctx context.Context, id int) (int, error) {
+	ctx, span := tracer.Start(ctx, "TodoStore.DeleteTodo", trace.WithAttributes(attribute.Int("todo.id", id)))
+	defer span.End()
+
 	log.Ctx(ctx).Debug().Caller().Msg("delete db request for todo")
 
-	result, err := s.pgClient.GetConnection().
-		Model((*models.TodoItem)(nil)).
-		Context(ctx).
-		Where("id = ?", id).
-		Delete()
+	var count int
+	err := s.pgClient.GetConnection().RunInTransaction(func(tx *pg.Tx) error {
+		var deleted models.TodoItem
+		result, err := tx.Model(&deleted).
+			Context(ctx).
+			Where("id = ?", id).
+			Returning("*").
+			Delete()
+		if err != nil {
+			return err
+		}
+
+		count = result.RowsAffected()
+		if count == 0 {
+			return nil
+		}
+		return insertEvent(ctx, tx, models.TodoDeleted, deleted)
+	})
 	if err != nil {
+		recordError(span, err)
 		log.Ctx(ctx).Error().Err(err).Caller().Msg("failed to delete todo from db")
 		return 0, err
 	}
+	todosDeleted.Add(float64(count))
 
 	log.Ctx(ctx).Debug().Caller().Msgf("todo deleted from db")
-	return result.RowsAffected(), nil
+	return count, nil
 }
 
-// PostTodo posts a TodoItem to the database
+// PostTodo posts a TodoItem to the database and records a TodoCreated event in the same transaction. A TodoItem with
+// the idempotency key of a TodoItem of the user which was already posted isn't posted again, the ID of the existing
+// TodoItem is returned.
 func (s *Store) PostTodo(ctx context.Context, todo models.TodoItem) (int, error) {
+	ctx, span := tracer.Start(ctx, "TodoStore.PostTodo")
+	defer span.End()
+
 	log.Ctx(ctx).Debug().Caller().Msg("insert db request for todo")
 
-	result, err := s.pgClient.GetConnection().
-		Model(&todo).
-		Context(ctx).
-		Returning("id").
-		Insert(&todo)
+	var replayed bool
+	err := s.pgClient.GetConnection().RunInTransaction(func(tx *pg.Tx) error {
+		query := tx.Model(&todo).
+			Context(ctx).
+			Returning("id")
+		if todo.IdempotencyKey != "" {
+			query = query.OnConflict("((COALESCE(user_id, '')), idempotency_key) DO NOTHING")
+		}
+		result, err := query.Insert(&todo)
+		if err == pg.ErrNoRows && todo.IdempotencyKey != "" {
+			replayed = true
+			return tx.Model((*models.TodoItem)(nil)).
+				Context(ctx).
+				Column("id").
+				Where("COALESCE(user_id, '') = ?", todo.UserID).
+				Where("idempotency_key = ?", todo.IdempotencyKey).
+				Select(&todo.ID)
+		}
+		if err != nil {
+			return err
+		}
+		if result.RowsAffected() == 0 {
+			return errors.New("failed to insert record")
+		}
+
+		return insertEvent(ctx, tx, models.TodoCreated, todo)
+	})
+	if pgErr, ok := err.(pg.Error); ok && pgErr.Field('n') == "todo_external_id_idx" {
+		return 0, ErrExternalIDExists
+	}
 	if err != nil {
+		recordError(span, err)
 		log.Ctx(ctx).Error().Err(err).Caller().Msg("failed to insert todo into db")
 		return 0, err
 	}
-	if result.RowsAffected() == 0 {
-		iErr := errors.New("failed to insert record")
-		log.Ctx(ctx).Error().Err(iErr).Caller().Msg("failed to insert todo into db")
-		return 0, iErr
+	if replayed {
+		log.Ctx(ctx).Debug().Caller().Msg("todo already inserted with the idempotency key")
+		return todo.ID, nil
+	}
+	todosCreated.Inc()
+
+	return todo.ID, nil
+}
+
+// UpdateTodo updates the todo, list and done state of a TodoItem in the database and records a TodoUpdated event in the
+// same transaction
+func (s *Store) UpdateTodo(ctx context.Context, todo models.TodoItem) (models.TodoItem, bool, error) {
+	ctx, span := tracer.Start(ctx, "TodoStore.UpdateTodo", trace.WithAttributes(attribute.Int("todo.id", todo.ID)))
+	defer span.End()
+
+	log.Ctx(ctx).Debug().Caller().Msg("update db request for todo")
+
+	var found, completed bool
+	err := s.pgClient.GetConnection().RunInTransaction(func(tx *pg.Tx) error {
+		var wasDone bool
+		err := tx.Model((*models.TodoItem)(nil)).
+			Context(ctx).
+			Column("done").
+			Where("id = ?", todo.ID).
+			For("UPDATE").
+			Select(&wasDone)
+		if err == pg.ErrNoRows {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		result, err := tx.Model(&todo).
+			Context(ctx).
+			Column("todo", "list", "done", "updated_on").
+			Where("id = ?", todo.ID).
+			Returning("*").
+			Update()
+		if err != nil {
+			return err
+		}
+
+		found = result.RowsAffected() > 0
+		if !found {
+			return nil
+		}
+		completed = !wasDone && todo.Done
+		return insertEvent(ctx, tx, models.TodoUpdated, todo)
+	})
+	if err != nil {
+		recordError(span, err)
+		log.Ctx(ctx).Error().Err(err).Caller().Msg("failed to update todo in db")
+		return models.TodoItem{}, false, err
+	}
+	if !found {
+		return models.TodoItem{}, false, nil
+	}
+	if completed {
+		todosCompleted.Inc()
+	}
+
+	log.Ctx(ctx).Debug().Caller().Msg("todo updated in db")
+	return todo, true, nil
+}
+
+// upsertTodoQuery inserts a TodoItem or updates the TodoItem of the user with the same external ID, returning its ID,
+// when it was created, whether it was inserted and the done state it had before. `?TableName` resolves to the todo
+// table of the model.
+const upsertTodoQuery = `WITH previous AS (
+		SELECT done FROM ?TableName WHERE COALESCE(user_id, '') = COALESCE(?user_id, '') AND external_id = ?external_id
+	)
+	INSERT INTO ?TableName (todo, list, user_id, done, created_on, updated_on, external_id)
+	VALUES (?todo, ?list, ?user_id, COALESCE(?done, false), ?created_on, ?updated_on, ?external_id)
+	ON CONFLICT ((COALESCE(user_id, '')), external_id) DO UPDATE
+	SET todo = EXCLUDED.todo, list = EXCLUDED.list, done = EXCLUDED.done, updated_on = EXCLUDED.updated_on
+	RETURNING id, created_on, (xmax = 0), COALESCE((SELECT done FROM previous), false)`
+
+// UpsertTodo inserts a TodoItem, or updates the todo, list and done state of the TodoItem of the user with the same
+// external ID, and records a TodoCreated or TodoUpdated event in the same transaction. Returns whether it was created.
+func (s *Store) UpsertTodo(ctx context.Context, todo models.TodoItem) (models.TodoItem, bool, error) {
+	ctx, span := tracer.Start(ctx, "TodoStore.UpsertTodo",
+		trace.WithAttributes(attribute.String("todo.external_id", todo.ExternalID)))
+	defer span.End()
+
+	log.Ctx(ctx).Debug().Caller().Msg("upsert db request for todo")
+
+	var created, completed bool
+	err := s.pgClient.GetConnection().RunInTransaction(func(tx *pg.Tx) error {
+		// a single statement, so concurrent upserts of a new external ID update the todo instead of failing on the
+		// unique index. The done state before the update is only used for the metric of completed todos.
+		var previouslyDone bool
+		_, err := tx.ModelContext(ctx, &todo).QueryOne(pg.Scan(&todo.ID, &todo.CreatedOn, &created, &previouslyDone),
+			upsertTodoQuery)
+		if err != nil {
+			return err
+		}
+
+		if created {
+			return insertEvent(ctx, tx, models.TodoCreated, todo)
+		}
+		completed = !previouslyDone && todo.Done
+		return insertEvent(ctx, tx, models.TodoUpdated, todo)
+	})
+	if err != nil {
+		recordError(span, err)
+		log.Ctx(ctx).Error().Err(err).Caller().Msg("failed to upsert todo in db")
+		return models.TodoItem{}, false, err
+	}
+	if created {
+		todosCreated.Inc()
+	}
+	if completed {
+		todosCompleted.Inc()
 	}
 
-	return todo.ID, err
+	log.Ctx(ctx).Debug().Caller().Msg("todo upserted in db")
+	return todo, created, nil
+}
+
+// ListTodoEvents lists the history of TodoEvents of the TodoItems from the outbox ordered by ID
+func (s *Store) ListTodoEvents(ctx context.Context, todoIDs []int) ([]models.TodoEvent, error) {
+	ctx, span := tracer.Start(ctx, "TodoStore.ListTodoEvents",
+		trace.WithAttributes(attribute.Int("todo.count", len(todoIDs))))
+	defer span.End()
+
+	log.Ctx(ctx).Debug().Caller().Msg("list db request for todo events")
+
+	var results []models.TodoEvent
+	_, err := s.pgClient.GetConnection().QueryContext(ctx, &results, `SELECT id, type, todo_id, list, user_id, payload,
+		created_on FROM outbox WHERE todo_id IN (?) ORDER BY id`, pg.In(todoIDs))
+	if err != nil {
+		recordError(span, err)
+		log.Ctx(ctx).Error().Err(err).Caller().Msg("failed to list todo events from db")
+		return nil, err
+	}
+
+	return results, nil
+}
+
+// insertEvent writes a TodoEvent to the outbox and notifies the change feed, must be called within the transaction of
+// the mutation
+func insertEvent(ctx context.Context, tx *pg.Tx, eventType string, todo models.TodoItem) error {
+	payload, err := json.Marshal(todo)
+	if err != nil {
+		return err
+	}
+
+	event := models.TodoEvent{
+		Type:      eventType,
+		TodoID:    todo.ID,
+		List:      todo.List,
+		UserID:    todo.UserID,
+		Payload:   payload,
+		CreatedOn: time.Now(),
+	}
+	_, err = tx.QueryOneContext(ctx, pg.Scan(&event.ID),
+		`INSERT INTO outbox (type, todo_id, list, user_id, payload, created_on) VALUES (?, ?, ?, ?, ?, ?) RETURNING id`,
+		event.Type, event.TodoID, event.List, event.UserID, event.Payload, event.CreatedOn)
+	if err != nil {
+		return err
+	}
+
+	return postgres.Notify(ctx, tx, event)
+}
+
+// recordError marks the span of a store method as failed
+func recordError(span trace.Span, err error) {
+	span.RecordError(err)
+	span.SetStatus(codes.Error, err.Error())
 }
@@ -4,6 +4,7 @@ import (
 	"context"
 	"fmt"
 	"os"
+	"sync"
 	"testing"
 	"time"
 
@@ -11,9 +12,11 @@ import (
 	"github.com/go-pg/pg"
 	"github.com/go-pg/pg/orm"
 	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus/testutil"
 	"github.com/testcontainers/testcontainers-go"
 	"github.com/testcontainers/testcontainers-go/wait"
 
+	"github.com/alexsniffin/go-api-starter/internal/todo-api/clients/postgres"
 	"github.com/alexsniffin/go-api-starter/internal/todo-api/models"
 	"github.com/alexsniffin/go-api-starter/mocks"
 )
@@ -85,6 +88,9 @@ func initDb(t *testing.T) (*pg.DB, testcontainers.Container) {
 	})
 	unexpected(t, errors.Wrap(err, "failed to create table"))
 
+	err = postgres.Migrate(pgClient)
+	unexpected(t, errors.Wrap(err, "failed to migrate"))
+
 	return pgClient, pgContainer
 }
 
@@ -115,3 +121,180 @@ func TestGetTodo_ValidEmptyResponse(t *testing.T) {
 	dbMock.AssertNumberOfCalls(t, "GetConnection", 1)
 	dbMock.AssertExpectations(t)
 }
+
+func TestPostTodo_WritesOutboxEvent(t *testing.T) {
+	skipCI(t)
+	t.Parallel()
+
+	db, container := initDb(t)
+	defer container.Terminate(context.Background())
+
+	dbMock := &mocks.DatabaseClient{}
+	todoStore := Store{
+		pgClient: dbMock,
+	}
+
+	dbMock.On("GetConnection").Return(db)
+
+	id, err := todoStore.PostTodo(context.Background(), models.TodoItem{Todo: "test", CreatedOn: time.Now()})
+	unexpected(t, err)
+
+	var events []models.TodoEvent
+	_, err = db.Query(&events, `SELECT id, type, todo_id, payload, created_on FROM outbox WHERE sent_on IS NULL`)
+	unexpected(t, err)
+
+	if len(events) != 1 {
+		t.Errorf("unexpected number of outbox events: got %v want %v", len(events), 1)
+		t.FailNow()
+	}
+	if events[0].Type != models.TodoCreated || events[0].TodoID != id {
+		t.Errorf("unexpected outbox event: %+v", events[0])
+	}
+
+	dbMock.AssertExpectations(t)
+}
+
+func TestUpdateTodo_CountsCompletion(t *testing.T) {
+	skipCI(t)
+	t.Parallel()
+
+	db, container := initDb(t)
+	defer container.Terminate(context.Background())
+
+	dbMock := &mocks.DatabaseClient{}
+	todoStore := Store{
+		pgClient: dbMock,
+	}
+
+	dbMock.On("GetConnection").Return(db)
+
+	id, err := todoStore.PostTodo(context.Background(), models.TodoItem{Todo: "test", CreatedOn: time.Now()})
+	unexpected(t, err)
+
+	completed := testutil.ToFloat64(todosCompleted)
+	for i := 0; i < 2; i++ {
+		_, found, err := todoStore.UpdateTodo(context.Background(), models.TodoItem{ID: id, Todo: "test", Done: true})
+		unexpected(t, err)
+		if !found {
+			t.Errorf("todo not found: %v", id)
+			t.FailNow()
+		}
+	}
+
+	if got := testutil.ToFloat64(todosCompleted) - completed; got != 1 {
+		t.Errorf("wrong number of completed todos: got %v want %v", got, 1)
+	}
+
+	dbMock.AssertExpectations(t)
+}
+
+func TestPostTodo_IdempotencyKey(t *testing.T) {
+	skipCI(t)
+	t.Parallel()
+
+	db, container := initDb(t)
+	defer container.Terminate(context.Background())
+
+	dbMock := &mocks.DatabaseClient{}
+	todoStore := Store{
+		pgClient: dbMock,
+	}
+
+	dbMock.On("GetConnection").Return(db)
+
+	todo := models.TodoItem{Todo: "test", UserID: "user", CreatedOn: time.Now(), IdempotencyKey: "key"}
+	id, err := todoStore.PostTodo(context.Background(), todo)
+	unexpected(t, err)
+	replayedID, err := todoStore.PostTodo(context.Background(), todo)
+	unexpected(t, err)
+
+	if replayedID != id {
+		t.Errorf("wrong id of the replayed todo: got %v want %v", replayedID, id)
+	}
+	count, err := db.Model((*models.TodoItem)(nil)).Count()
+	unexpected(t, err)
+	if count != 1 {
+		t.Errorf("wrong number of todos: got %v want %v", count, 1)
+	}
+
+	dbMock.AssertExpectations(t)
+}
+
+func TestUpsertTodo_ExternalID(t *testing.T) {
+	skipCI(t)
+	t.Parallel()
+
+	db, container := initDb(t)
+	defer container.Terminate(context.Background())
+
+	dbMock := &mocks.DatabaseClient{}
+	todoStore := Store{
+		pgClient: dbMock,
+	}
+
+	dbMock.On("GetConnection").Return(db)
+
+	todo := models.TodoItem{Todo: "test", UserID: "user", CreatedOn: time.Now(), UpdatedOn: time.Now(), ExternalID: "a"}
+	created, isNew, err := todoStore.UpsertTodo(context.Background(), todo)
+	unexpected(t, err)
+	if !isNew {
+		t.Errorf("wrong created flag of the first upsert: got %v want %v", isNew, true)
+	}
+
+	todo.Todo = "updated"
+	updated, isNew, err := todoStore.UpsertTodo(context.Background(), todo)
+	unexpected(t, err)
+	if isNew || updated.ID != created.ID || updated.Todo != "updated" {
+		t.Errorf("wrong upserted todo: got %+v, %v want id %v updated", updated, isNew, created.ID)
+	}
+
+	_, err = todoStore.PostTodo(context.Background(), todo)
+	if err != ErrExternalIDExists {
+		t.Errorf("wrong error posting a duplicate external id: got %v want %v", err, ErrExternalIDExists)
+	}
+
+	dbMock.AssertExpectations(t)
+}
+
+func TestUpsertTodo_Concurrent(t *testing.T) {
+	skipCI(t)
+	t.Parallel()
+
+	db, container := initDb(t)
+	defer container.Terminate(context.Background())
+
+	dbMock := &mocks.DatabaseClient{}
+	todoStore := Store{
+		pgClient: dbMock,
+	}
+
+	dbMock.On("GetConnection").Return(db)
+
+	// upserts of the same new external ID must all succeed, exactly one of them creating the todo
+	const upserts = 8
+	var wg sync.WaitGroup
+	results := make(chan bool, upserts)
+	for i := 0; i < upserts; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			todo := models.TodoItem{Todo: fmt.Sprint("test ", i), UserID: "user", CreatedOn: time.Now(),
+				UpdatedOn: time.Now(), ExternalID: "a"}
+			_, isNew, err := todoStore.UpsertTodo(context.Background(), todo)
+			unexpected(t, err)
+			results <- isNew
+		}(i)
+	}
+	wg.Wait()
+	close(results)
+
+	created := 0
+	for isNew := range results {
+		if isNew {
+			created++
+		}
+	}
+	if created != 1 {
+		t.Errorf("wrong number of created todos: got %v want %v", created, 1)
+	}
+}
@@ -0,0 +1,336 @@
+package transfer
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/alexsniffin/go-api-starter/internal/todo-api/models"
+)
+
+// Formats of exports and imports
+const (
+	FormatJSONL = "jsonl"
+	FormatCSV   = "csv"
+)
+
+// maxLineBytes of a JSON line
+const maxLineBytes = 1 << 20
+
+// columns of a CSV export, an import may have any of them in any order but requires the todo
+var columns = []string{"id", "external_id", "todo", "list", "done", "created_on", "updated_on"}
+
+// ContentType returns the content type of the format, or an empty string if the format isn't supported
+func ContentType(format string) string {
+	switch format {
+	case FormatJSONL:
+		return "application/x-ndjson"
+	case FormatCSV:
+		return "text/csv; charset=utf-8"
+	}
+	return ""
+}
+
+// Encoder writes the records of an export
+type Encoder interface {
+	Encode(record models.TodoRecord) error
+	// Flush writes the buffered records
+	Flush() error
+}
+
+// NewEncoder creates the Encoder of the format
+func NewEncoder(w io.Writer, format string) (Encoder, error) {
+	switch format {
+	case FormatJSONL:
+		buf := bufio.NewWriter(w)
+		return jsonlEncoder{buf: buf, enc: json.NewEncoder(buf)}, nil
+	case FormatCSV:
+		return &csvEncoder{w: csv.NewWriter(w)}, nil
+	}
+	return nil, errors.Errorf("unsupported format %q", format)
+}
+
+type jsonlEncoder struct {
+	buf *bufio.Writer
+	enc *json.Encoder
+}
+
+func (e jsonlEncoder) Encode(record models.TodoRecord) error {
+	return e.enc.Encode(record)
+}
+
+func (e jsonlEncoder) Flush() error {
+	return e.buf.Flush()
+}
+
+type csvEncoder struct {
+	w             *csv.Writer
+	headerWritten bool
+}
+
+func (e *csvEncoder) Encode(record models.TodoRecord) error {
+	if !e.headerWritten {
+		if err := e.w.Write(columns); err != nil {
+			return err
+		}
+		e.headerWritten = true
+	}
+
+	return e.w.Write([]string{
+		strconv.Itoa(record.ID),
+		record.ExternalID,
+		record.Todo,
+		record.List,
+		strconv.FormatBool(record.Done),
+		formatTime(record.CreatedOn),
+		formatTime(record.UpdatedOn),
+	})
+}
+
+func (e *csvEncoder) Flush() error {
+	if !e.headerWritten {
+		if err := e.w.Write(columns); err != nil {
+			return err
+		}
+		e.headerWritten = true
+	}
+	e.w.Flush()
+	return e.w.Error()
+}
+
+// lineCounter records the newlines of the input read, so the line of an offset of the input is known
+type lineCounter struct {
+	r io.Reader
+	// read is the number of bytes read
+	read int64
+	// newlines are the offsets of the newlines not yet before an offset passed to lineAt
+	newlines []int64
+	lines    int
+}
+
+func (c *lineCounter) Read(p []byte) (int, error) {
+	n, err := c.r.Read(p)
+	for i := 0; i < n; i++ {
+		if p[i] == '\n' {
+			c.newlines = append(c.newlines, c.read+int64(i))
+		}
+	}
+	c.read += int64(n)
+	return n, err
+}
+
+// lineAt is the line of the byte at the offset, offsets must not decrease between calls
+func (c *lineCounter) lineAt(offset int64) int {
+	for len(c.newlines) > 0 && c.newlines[0] < offset {
+		c.newlines = c.newlines[1:]
+		c.lines++
+	}
+	return c.lines + 1
+}
+
+// RecordError is a malformed record of an import, the next record can still be decoded
+type RecordError struct {
+	Line int
+	Err  error
+}
+
+func (e *RecordError) Error() string {
+	return fmt.Sprintf("line %d: %v", e.Line, e.Err)
+}
+
+// Decoder reads the records of an import. Decode returns the record and its line, io.EOF after the last record, a
+// *RecordError for a malformed record or any other error if the import can't be read further.
+type Decoder interface {
+	Decode() (models.TodoRecord, int, error)
+}
+
+// NewDecoder creates the Decoder of the format
+func NewDecoder(r io.Reader, format string) (Decoder, error) {
+	switch format {
+	case FormatJSONL:
+		scanner := bufio.NewScanner(r)
+		scanner.Buffer(make([]byte, 0, 64<<10), maxLineBytes)
+		return &jsonlDecoder{scanner: scanner}, nil
+	case FormatCSV:
+		// the csv.Reader reads from a bufio.Reader of the default size as is, so the input it consumed is known
+		counter := &lineCounter{r: r}
+		buffered := bufio.NewReader(counter)
+		reader := csv.NewReader(buffered)
+		reader.ReuseRecord = true
+		return &csvDecoder{r: reader, buffered: buffered, counter: counter}, nil
+	}
+	return nil, errors.Errorf("unsupported format %q", format)
+}
+
+type jsonlDecoder struct {
+	scanner *bufio.Scanner
+	line    int
+}
+
+func (d *jsonlDecoder) Decode() (models.TodoRecord, int, error) {
+	for d.scanner.Scan() {
+		d.line++
+		line := bytes.TrimSpace(d.scanner.Bytes())
+		if len(line) == 0 {
+			continue
+		}
+
+		var record models.TodoRecord
+		dec := json.NewDecoder(bytes.NewReader(line))
+		dec.DisallowUnknownFields()
+		if err := dec.Decode(&record); err != nil {
+			return models.TodoRecord{}, d.line, &RecordError{Line: d.line, Err: err}
+		}
+		if dec.More() {
+			return models.TodoRecord{}, d.line, &RecordError{Line: d.line, Err: errors.New("more than one value")}
+		}
+		return record, d.line, nil
+	}
+
+	if err := d.scanner.Err(); err != nil {
+		return models.TodoRecord{}, d.line + 1, errors.Wrapf(err, "line %d", d.line+1)
+	}
+	return models.TodoRecord{}, d.line, io.EOF
+}
+
+type csvDecoder struct {
+	r        *csv.Reader
+	buffered *bufio.Reader
+	counter  *lineCounter
+	// index of the column of each field of a record
+	index map[string]int
+	line  int
+}
+
+func (d *csvDecoder) Decode() (models.TodoRecord, int, error) {
+	if d.index == nil {
+		if err := d.readHeader(); err != nil {
+			return models.TodoRecord{}, 1, err
+		}
+	}
+
+	fields, err := d.r.Read()
+	if err == nil {
+		d.line = d.recordLine(fields)
+	}
+	if err == io.EOF {
+		return models.TodoRecord{}, d.line, io.EOF
+	}
+	if parseErr, ok := err.(*csv.ParseError); ok {
+		d.line = parseErr.Line
+		return models.TodoRecord{}, d.line, &RecordError{Line: d.line, Err: parseErr.Err}
+	}
+	if err != nil {
+		return models.TodoRecord{}, d.line, err
+	}
+
+	record, err := d.parse(fields)
+	if err != nil {
+		return models.TodoRecord{}, d.line, &RecordError{Line: d.line, Err: err}
+	}
+	return record, d.line, nil
+}
+
+// readHeader maps the columns of the header, the header is required so unknown columns aren't silently ignored
+func (d *csvDecoder) readHeader() error {
+	header, err := d.r.Read()
+	if err == io.EOF {
+		return errors.New("missing csv header")
+	}
+	if err != nil {
+		return errors.Wrap(err, "invalid csv header")
+	}
+
+	d.index = make(map[string]int, len(header))
+	for i, name := range header {
+		name = strings.TrimSpace(name)
+		if !isColumn(name) {
+			return errors.Errorf("unknown csv column %q, must be one of %s", name, strings.Join(columns, ", "))
+		}
+		if _, ok := d.index[name]; ok {
+			return errors.Errorf("duplicate csv column %q", name)
+		}
+		d.index[name] = i
+	}
+	if _, ok := d.index["todo"]; !ok {
+		return errors.New("missing csv column \"todo\"")
+	}
+	return nil
+}
+
+// recordLine is the line the record read last starts on, a quoted field may span lines
+func (d *csvDecoder) recordLine(fields []string) int {
+	// the csv.Reader consumes whole lines, so the last byte consumed is on the last line of the record
+	consumed := d.counter.read - int64(d.buffered.Buffered())
+	line := d.counter.lineAt(consumed - 1)
+	for i := 0; i < len(fields); i++ {
+		line -= strings.Count(fields[i], "\n")
+	}
+	return line
+}
+
+func (d *csvDecoder) parse(fields []string) (models.TodoRecord, error) {
+	field := func(name string) string {
+		if i, ok := d.index[name]; ok {
+			return strings.TrimSpace(fields[i])
+		}
+		return ""
+	}
+
+	record := models.TodoRecord{
+		ExternalID: field("external_id"),
+		Todo:       fields[d.index["todo"]],
+		List:       field("list"),
+	}
+	var err error
+	if id := field("id"); id != "" {
+		if record.ID, err = strconv.Atoi(id); err != nil {
+			return models.TodoRecord{}, errors.New("id must be an integer")
+		}
+	}
+	if done := field("done"); done != "" {
+		if record.Done, err = strconv.ParseBool(done); err != nil {
+			return models.TodoRecord{}, errors.New("done must be a boolean")
+		}
+	}
+	if record.CreatedOn, err = parseTime(field("created_on")); err != nil {
+		return models.TodoRecord{}, errors.New("created_on must be an RFC 3339 time")
+	}
+	if record.UpdatedOn, err = parseTime(field("updated_on")); err != nil {
+		return models.TodoRecord{}, errors.New("updated_on must be an RFC 3339 time")
+	}
+	return record, nil
+}
+
+func isColumn(name string) bool {
+	for _, column := range columns {
+		if name == column {
+			return true
+		}
+	}
+	return false
+}
+
+// formatTime formats the time as RFC 3339, a zero time is empty
+func formatTime(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	return t.UTC().Format(time.RFC3339Nano)
+}
+
+// parseTime parses an RFC 3339 time, an empty string is a zero time
+func parseTime(value string) (time.Time, error) {
+	if value == "" {
+		return time.Time{}, nil
+	}
+	return time.Parse(time.RFC3339Nano, value)
+}
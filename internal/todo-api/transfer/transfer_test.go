@@ -0,0 +1,123 @@
+package transfer
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/alexsniffin/go-api-starter/internal/todo-api/models"
+)
+
+func TestRoundTrip(t *testing.T) {
+	createdOn := time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC)
+	records := []models.TodoRecord{
+		{ID: 1, ExternalID: "a", Todo: "buy milk, eggs", List: "home", Done: true, CreatedOn: createdOn},
+		{ID: 2, Todo: "call \"mom\"", CreatedOn: createdOn, UpdatedOn: createdOn},
+	}
+
+	for _, format := range []string{FormatJSONL, FormatCSV} {
+		t.Run(format, func(t *testing.T) {
+			var buf bytes.Buffer
+			enc, err := NewEncoder(&buf, format)
+			if err != nil {
+				t.Fatal(err)
+			}
+			for _, record := range records {
+				if err = enc.Encode(record); err != nil {
+					t.Fatal(err)
+				}
+			}
+			if err = enc.Flush(); err != nil {
+				t.Fatal(err)
+			}
+
+			dec, err := NewDecoder(&buf, format)
+			if err != nil {
+				t.Fatal(err)
+			}
+			for i, want := range records {
+				got, _, err := dec.Decode()
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				if got.ID != want.ID || got.ExternalID != want.ExternalID || got.Todo != want.Todo ||
+					got.List != want.List || got.Done != want.Done || !got.CreatedOn.Equal(want.CreatedOn) ||
+					!got.UpdatedOn.Equal(want.UpdatedOn) {
+					t.Errorf("wrong record %d: got %+v want %+v", i, got, want)
+				}
+			}
+			if _, _, err = dec.Decode(); err != io.EOF {
+				t.Errorf("wrong error: got %v want %v", err, io.EOF)
+			}
+		})
+	}
+}
+
+func TestDecoder(t *testing.T) {
+	t.Run("malformedJSONLine", func(t *testing.T) {
+		dec, _ := NewDecoder(strings.NewReader("{\"todo\":\"a\"}\n\n{\"todo\":\"b\",\"owner\":\"x\"}\n{\"todo\":\"c\"}\n"),
+			FormatJSONL)
+
+		_, line, err := dec.Decode()
+		if err != nil || line != 1 {
+			t.Errorf("wrong first record: got %v, %v want %v, %v", line, err, 1, nil)
+		}
+		_, line, err = dec.Decode()
+		var recordErr *RecordError
+		if !errors.As(err, &recordErr) || line != 3 {
+			t.Errorf("wrong error: got %v, %v want record error of line %v", line, err, 3)
+		}
+		record, line, err := dec.Decode()
+		if err != nil || line != 4 || record.Todo != "c" {
+			t.Errorf("wrong last record: got %+v, %v, %v", record, line, err)
+		}
+	})
+
+	t.Run("csvColumnsByName", func(t *testing.T) {
+		dec, _ := NewDecoder(strings.NewReader("done,todo\ntrue,a\nmaybe,b\n"), FormatCSV)
+
+		record, line, err := dec.Decode()
+		if err != nil || line != 2 || record.Todo != "a" || !record.Done {
+			t.Errorf("wrong record: got %+v, %v, %v", record, line, err)
+		}
+		_, line, err = dec.Decode()
+		var recordErr *RecordError
+		if !errors.As(err, &recordErr) || line != 3 {
+			t.Errorf("wrong error: got %v, %v want record error of line %v", line, err, 3)
+		}
+	})
+
+	t.Run("csvQuotedLines", func(t *testing.T) {
+		dec, _ := NewDecoder(strings.NewReader("todo,list\n\"a\nb\r\nc\",home\n\nd,\"x\nwork\"\ne,home\n\"f\"g,home\n"),
+			FormatCSV)
+
+		wants := []struct {
+			line int
+			todo string
+		}{{2, "a\nb\nc"}, {6, "d"}, {8, "e"}}
+		for _, want := range wants {
+			record, line, err := dec.Decode()
+			if err != nil || line != want.line || record.Todo != want.todo {
+				t.Errorf("wrong record: got %+v, %v, %v want line %v", record, line, err, want.line)
+			}
+		}
+		_, line, err := dec.Decode()
+		var recordErr *RecordError
+		if !errors.As(err, &recordErr) || line != 9 {
+			t.Errorf("wrong error: got %v, %v want record error of line %v", line, err, 9)
+		}
+	})
+
+	t.Run("unknownCSVColumn", func(t *testing.T) {
+		dec, _ := NewDecoder(strings.NewReader("todo,owner\na,b\n"), FormatCSV)
+
+		_, _, err := dec.Decode()
+		var recordErr *RecordError
+		if err == nil || errors.As(err, &recordErr) {
+			t.Errorf("wrong error: got %v want error of the header", err)
+		}
+	})
+}
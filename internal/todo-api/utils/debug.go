@@ -0,0 +1,62 @@
+package utils
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/rs/zerolog"
+)
+
+type debugKey struct{}
+
+// WithDebug returns a copy of the context forcing debug logging regardless of the current log level
+func WithDebug(ctx context.Context) context.Context {
+	return context.WithValue(ctx, debugKey{}, true)
+}
+
+// DebugFromCtx reports whether debug logging is forced for the context
+func DebugFromCtx(ctx context.Context) bool {
+	debug, _ := ctx.Value(debugKey{}).(bool)
+	return debug
+}
+
+// DebugLogger returns a copy of the logger writing debug events regardless of the current log level
+func DebugLogger(logger zerolog.Logger) zerolog.Logger {
+	return logger.Level(zerolog.DebugLevel).Sample(nil)
+}
+
+// SignDebugToken creates a token forcing debug logging of the requests sending it until it expires, formatted as the
+// unix expiry and its HMAC-SHA256 signature with the key
+func SignDebugToken(key []byte, expiresOn time.Time) string {
+	expiry := strconv.FormatInt(expiresOn.Unix(), 10)
+	return expiry + "." + signDebugExpiry(key, expiry)
+}
+
+// VerifyDebugToken reports whether the token is signed with the key and hasn't expired
+func VerifyDebugToken(key []byte, token string, now time.Time) bool {
+	if len(key) == 0 {
+		return false
+	}
+
+	parts := strings.SplitN(token, ".", 2)
+	if len(parts) != 2 {
+		return false
+	}
+	expiresOn, err := strconv.ParseInt(parts[0], 10, 64)
+	if err != nil || now.Unix() > expiresOn {
+		return false
+	}
+
+	return hmac.Equal([]byte(parts[1]), []byte(signDebugExpiry(key, parts[0])))
+}
+
+func signDebugExpiry(key []byte, expiry string) string {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(expiry))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
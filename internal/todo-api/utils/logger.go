@@ -5,14 +5,27 @@ import (
 
 	"github.com/rs/zerolog"
 	"github.com/rs/zerolog/hlog"
+	"go.opentelemetry.io/otel/trace"
 )
 
 func GetSubLoggerCtx(logger zerolog.Logger, ctx context.Context) context.Context {
 	subLogger := logger
+	if DebugFromCtx(ctx) {
+		subLogger = DebugLogger(subLogger)
+	}
 	reqId, ok := hlog.IDFromCtx(ctx)
 	if ok {
 		subLogger = subLogger.With().Str("reqID", reqId.String()).Logger()
 	}
+	if userID := UserFromCtx(ctx); userID != "" {
+		subLogger = subLogger.With().Str("userID", userID).Logger()
+	}
+	if spanCtx := trace.SpanContextFromContext(ctx); spanCtx.IsValid() {
+		subLogger = subLogger.With().
+			Str("traceID", spanCtx.TraceID().String()).
+			Str("spanID", spanCtx.SpanID().String()).
+			Logger()
+	}
 	id, ok := ctx.Value("id").(int)
 	if ok {
 		subLogger = subLogger.With().Int("id", id).Logger()
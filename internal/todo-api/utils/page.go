@@ -0,0 +1,25 @@
+package utils
+
+import (
+	"encoding/base64"
+	"strconv"
+)
+
+// EncodePageToken encodes the ID of the last item of a page into an opaque token for the next page
+func EncodePageToken(lastID int) string {
+	return base64.RawURLEncoding.EncodeToString([]byte(strconv.Itoa(lastID)))
+}
+
+// DecodePageToken decodes a page token into the ID of the last item of the previous page, an empty token is the first
+// page
+func DecodePageToken(token string) (int, error) {
+	if token == "" {
+		return 0, nil
+	}
+
+	decoded, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil {
+		return 0, err
+	}
+	return strconv.Atoi(string(decoded))
+}
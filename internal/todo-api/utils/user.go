@@ -0,0 +1,18 @@
+package utils
+
+import (
+	"context"
+)
+
+type userKey struct{}
+
+// WithUser returns a copy of the context carrying the ID of the caller
+func WithUser(ctx context.Context, userID string) context.Context {
+	return context.WithValue(ctx, userKey{}, userID)
+}
+
+// UserFromCtx returns the ID of the caller, empty if the caller is anonymous
+func UserFromCtx(ctx context.Context) string {
+	userID, _ := ctx.Value(userKey{}).(string)
+	return userID
+}
@@ -0,0 +1,72 @@
+package cli
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+
+	"github.com/alexsniffin/go-api-starter/pkg/client"
+)
+
+// options shared by the commands
+type options struct {
+	configPath string
+	profile    string
+}
+
+// NewCommand creates the root command of the todo CLI with its subcommands
+func NewCommand() *cobra.Command {
+	opts := &options{}
+
+	cmd := &cobra.Command{
+		Use:           "todo",
+		Short:         "Manage todos of the Todo API",
+		SilenceUsage:  true,
+		SilenceErrors: true,
+	}
+	cmd.PersistentFlags().StringVar(&opts.configPath, "config", defaultConfigPath(), "path of the config file")
+	cmd.PersistentFlags().StringVarP(&opts.profile, "profile", "p", os.Getenv("TODO_PROFILE"),
+		"profile to use instead of the current, defaults to $TODO_PROFILE")
+	_ = cmd.RegisterFlagCompletionFunc("profile", opts.completeProfiles)
+
+	cmd.AddCommand(
+		newAddCommand(opts),
+		newListCommand(opts),
+		newDoneCommand(opts),
+		newRemoveCommand(opts),
+		newEditCommand(opts),
+		newProfileCommand(opts),
+	)
+	return cmd
+}
+
+// Execute runs the command of the arguments and returns the exit status
+func Execute() int {
+	if err := NewCommand().Execute(); err != nil {
+		fmt.Fprintln(os.Stderr, "error:", err)
+		return 1
+	}
+	return 0
+}
+
+// newClient creates the client of the API of the profile
+func (o *options) newClient() (*client.Client, error) {
+	profiles, err := loadProfiles(o.configPath)
+	if err != nil {
+		return nil, err
+	}
+	profile, err := profiles.profile(o.profile)
+	if err != nil {
+		return nil, err
+	}
+
+	newClient, err := client.NewClient(client.Config{
+		BaseURL:    profile.URL,
+		UserID:     profile.User,
+		UserHeader: profile.UserHeader,
+		Token:      profile.Token,
+	})
+	return newClient, errors.Wrap(err, "invalid profile")
+}
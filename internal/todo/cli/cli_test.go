@@ -0,0 +1,244 @@
+package cli
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/go-chi/chi"
+
+	"github.com/alexsniffin/go-api-starter/internal/todo-api/models"
+)
+
+// fakeAPI serves the todos of the REST API from memory
+type fakeAPI struct {
+	mu     sync.Mutex
+	todos  map[int]models.TodoItem
+	nextID int
+	users  []string
+}
+
+func newFakeAPI(t *testing.T) (*fakeAPI, string) {
+	api := &fakeAPI{todos: map[int]models.TodoItem{}, nextID: 1}
+
+	r := chi.NewRouter()
+	r.Use(func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			api.mu.Lock()
+			api.users = append(api.users, r.Header.Get("X-User-Id"))
+			api.mu.Unlock()
+			next.ServeHTTP(w, r)
+		})
+	})
+	r.Post("/api/todo", func(w http.ResponseWriter, r *http.Request) {
+		var req models.TodoPostRequest
+		_ = json.NewDecoder(r.Body).Decode(&req)
+		api.mu.Lock()
+		defer api.mu.Unlock()
+		id := api.nextID
+		api.nextID++
+		api.todos[id] = models.TodoItem{ID: id, Todo: req.Todo, List: req.List}
+		_ = json.NewEncoder(w).Encode(models.TodoPostResponse{ID: id})
+	})
+	r.Get("/api/todo", func(w http.ResponseWriter, r *http.Request) {
+		api.mu.Lock()
+		defer api.mu.Unlock()
+		response := models.TodoListResponse{Items: []models.TodoItem{}}
+		for id := 1; id < api.nextID; id++ {
+			todo, ok := api.todos[id]
+			if done := r.URL.Query().Get("done"); ok && (done == "" || done == strconv.FormatBool(todo.Done)) {
+				response.Items = append(response.Items, todo)
+			}
+		}
+		_ = json.NewEncoder(w).Encode(response)
+	})
+	r.Get("/api/todo/{id}", func(w http.ResponseWriter, r *http.Request) {
+		id, _ := strconv.Atoi(chi.URLParam(r, "id"))
+		api.mu.Lock()
+		defer api.mu.Unlock()
+		todo, ok := api.todos[id]
+		if !ok {
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+		_ = json.NewEncoder(w).Encode(todo)
+	})
+	r.Put("/api/todo/{id}", func(w http.ResponseWriter, r *http.Request) {
+		id, _ := strconv.Atoi(chi.URLParam(r, "id"))
+		var req models.TodoPutRequest
+		_ = json.NewDecoder(r.Body).Decode(&req)
+		api.mu.Lock()
+		defer api.mu.Unlock()
+		api.todos[id] = req.TodoItem(id)
+		_ = json.NewEncoder(w).Encode(api.todos[id])
+	})
+	r.Delete("/api/todo/{id}", func(w http.ResponseWriter, r *http.Request) {
+		id, _ := strconv.Atoi(chi.URLParam(r, "id"))
+		api.mu.Lock()
+		defer api.mu.Unlock()
+		if _, ok := api.todos[id]; !ok {
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+		delete(api.todos, id)
+	})
+
+	server := httptest.NewServer(r)
+	t.Cleanup(server.Close)
+	return api, server.URL
+}
+
+// initConfig creates a config file with a profile of the url which is current
+func initConfig(t *testing.T, url string) string {
+	dir, err := ioutil.TempDir("", "todo")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.RemoveAll(dir) })
+
+	configPath := filepath.Join(dir, "config.yaml")
+	if _, err = run(configPath, "profile", "set", "test", "--url", url, "--user", "alice"); err != nil {
+		t.Fatal(err)
+	}
+	return configPath
+}
+
+// run executes the command of the arguments with the config file, returning its output
+func run(configPath string, args ...string) (string, error) {
+	var out bytes.Buffer
+	cmd := NewCommand()
+	cmd.SetArgs(append([]string{"--config", configPath}, args...))
+	cmd.SetOut(&out)
+	cmd.SetErr(&out)
+	err := cmd.ExecuteContext(context.Background())
+	return out.String(), err
+}
+
+func TestTodoCommands(t *testing.T) {
+	t.Run("addAndList", func(t *testing.T) {
+		api, url := newFakeAPI(t)
+		configPath := initConfig(t, url)
+
+		out, err := run(configPath, "add", "buy", "milk", "--list", "home")
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if out != "1\n" {
+			t.Errorf("wrong output: got %v want %v", out, "1\n")
+		}
+
+		out, err = run(configPath, "ls")
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		expected := "ID  DONE  LIST  TODO\n1         home  buy milk\n"
+		if out != expected {
+			t.Errorf("wrong output: got %q want %q", out, expected)
+		}
+		if api.users[0] != "alice" {
+			t.Errorf("wrong user: got %v want %v", api.users[0], "alice")
+		}
+	})
+
+	t.Run("doneAsJSON", func(t *testing.T) {
+		_, url := newFakeAPI(t)
+		configPath := initConfig(t, url)
+		for _, todo := range []string{"a", "b"} {
+			if _, err := run(configPath, "add", todo); err != nil {
+				t.Fatal(err)
+			}
+		}
+
+		if _, err := run(configPath, "done", "2"); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		out, err := run(configPath, "ls", "--done", "-o", "json")
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		var todos []models.TodoItem
+		if err = json.Unmarshal([]byte(out), &todos); err != nil {
+			t.Fatal(err)
+		}
+		if len(todos) != 1 || todos[0].ID != 2 || !todos[0].Done {
+			t.Errorf("wrong todos: got %+v", todos)
+		}
+	})
+
+	t.Run("editInEditor", func(t *testing.T) {
+		api, url := newFakeAPI(t)
+		configPath := initConfig(t, url)
+		if _, err := run(configPath, "add", "buy", "milk"); err != nil {
+			t.Fatal(err)
+		}
+		os.Setenv("VISUAL", "sed -i -e s/milk/bread/ -e s/done:\\ false/done:\\ true/")
+		defer os.Unsetenv("VISUAL")
+
+		if _, err := run(configPath, "edit", "1"); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if todo := api.todos[1]; todo.Todo != "buy bread" || !todo.Done {
+			t.Errorf("wrong todo: got %+v", todo)
+		}
+	})
+
+	t.Run("removeMissing", func(t *testing.T) {
+		_, url := newFakeAPI(t)
+		configPath := initConfig(t, url)
+
+		_, err := run(configPath, "rm", "1")
+		if err == nil || !strings.Contains(err.Error(), "todo 1: todo not found") {
+			t.Errorf("wrong error: got %v want %v", err, "todo 1: todo not found")
+		}
+	})
+}
+
+func TestProfileCommands(t *testing.T) {
+	configPath := initConfig(t, "http://localhost:8080")
+
+	_, err := run(configPath, "profile", "set", "prod", "--url", "https://todo.example.com", "--token", "t")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, err := run(configPath, "profile", "use", "prod"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, err := run(configPath, "profile", "use", "missing"); err == nil {
+		t.Errorf("expected error of the unknown profile")
+	}
+
+	out, err := run(configPath, "profile", "ls")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expected := "   NAME  URL                       USER\n*  prod  https://todo.example.com  \n" +
+		"   test  http://localhost:8080     alice\n"
+	if out != expected {
+		t.Errorf("wrong output: got %q want %q", out, expected)
+	}
+
+	profiles, err := loadProfiles(configPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if profiles.Profiles["prod"].Token != "t" {
+		t.Errorf("wrong token: got %v want %v", profiles.Profiles["prod"].Token, "t")
+	}
+
+	if _, err = run(configPath, "profile", "rm", "prod"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, err = run(configPath, "ls"); err == nil || !strings.Contains(err.Error(), "no current profile") {
+		t.Errorf("wrong error: got %v want %v", err, "no current profile")
+	}
+}
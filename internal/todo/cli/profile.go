@@ -0,0 +1,223 @@
+package cli
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"text/tabwriter"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v2"
+)
+
+const defaultURL = "http://localhost:8080"
+
+// Profile of the API the CLI connects to and the identity of the user
+type Profile struct {
+	URL        string `yaml:"url"`
+	User       string `yaml:"user,omitempty"`
+	UserHeader string `yaml:"user_header,omitempty"`
+	Token      string `yaml:"token,omitempty"`
+}
+
+// Profiles of the config file of the CLI, the current profile is used unless another is selected
+type Profiles struct {
+	Current  string             `yaml:"current,omitempty"`
+	Profiles map[string]Profile `yaml:"profiles,omitempty"`
+}
+
+// defaultConfigPath of the config file in the config directory of the user
+func defaultConfigPath() string {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return ".todo.yaml"
+	}
+	return filepath.Join(dir, "todo", "config.yaml")
+}
+
+// loadProfiles loads the profiles of the config file, a missing file has no profiles
+func loadProfiles(path string) (Profiles, error) {
+	profiles := Profiles{Profiles: map[string]Profile{}}
+	content, err := ioutil.ReadFile(path)
+	if os.IsNotExist(err) {
+		return profiles, nil
+	}
+	if err != nil {
+		return Profiles{}, err
+	}
+
+	if err = yaml.UnmarshalStrict(content, &profiles); err != nil {
+		return Profiles{}, errors.Wrapf(err, "invalid config file %s", path)
+	}
+	if profiles.Profiles == nil {
+		profiles.Profiles = map[string]Profile{}
+	}
+	return profiles, nil
+}
+
+// save the profiles to the config file, readable only by the user since profiles have tokens
+func (p Profiles) save(path string) error {
+	content, err := yaml.Marshal(p)
+	if err != nil {
+		return err
+	}
+	if err = os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return err
+	}
+	return ioutil.WriteFile(path, content, 0600)
+}
+
+// profile returns the profile of the name, or the current profile if the name is empty. Without profiles the API is
+// expected on localhost.
+func (p Profiles) profile(name string) (Profile, error) {
+	if name == "" {
+		name = p.Current
+	}
+	if name == "" {
+		if len(p.Profiles) == 0 {
+			return Profile{URL: defaultURL}, nil
+		}
+		return Profile{}, errors.New("no current profile, select one with `todo profile use <name>`")
+	}
+
+	profile, ok := p.Profiles[name]
+	if !ok {
+		return Profile{}, errors.Errorf("unknown profile %q", name)
+	}
+	return profile, nil
+}
+
+// names of the profiles in order
+func (p Profiles) names() []string {
+	names := make([]string, 0, len(p.Profiles))
+	for name := range p.Profiles {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// newProfileCommand creates the commands managing the profiles of the config file
+func newProfileCommand(opts *options) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "profile",
+		Short: "Manage the profiles of the APIs and users",
+	}
+
+	cmd.AddCommand(&cobra.Command{
+		Use:   "ls",
+		Short: "List the profiles, the current profile is marked with *",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			profiles, err := loadProfiles(opts.configPath)
+			if err != nil {
+				return err
+			}
+
+			w := tabwriter.NewWriter(cmd.OutOrStdout(), 0, 4, 2, ' ', 0)
+			fmt.Fprintln(w, "\tNAME\tURL\tUSER")
+			for _, name := range profiles.names() {
+				current := ""
+				if name == profiles.Current {
+					current = "*"
+				}
+				fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", current, name, profiles.Profiles[name].URL, profiles.Profiles[name].User)
+			}
+			return w.Flush()
+		},
+	})
+
+	var profile Profile
+	setCmd := &cobra.Command{
+		Use:   "set <name>",
+		Short: "Create or update a profile, the first profile becomes the current",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			profiles, err := loadProfiles(opts.configPath)
+			if err != nil {
+				return err
+			}
+
+			existing, ok := profiles.Profiles[args[0]]
+			if !ok {
+				existing = Profile{URL: defaultURL}
+			}
+			flags := cmd.Flags()
+			if flags.Changed("url") {
+				existing.URL = profile.URL
+			}
+			if flags.Changed("user") {
+				existing.User = profile.User
+			}
+			if flags.Changed("user-header") {
+				existing.UserHeader = profile.UserHeader
+			}
+			if flags.Changed("token") {
+				existing.Token = profile.Token
+			}
+			profiles.Profiles[args[0]] = existing
+			if profiles.Current == "" {
+				profiles.Current = args[0]
+			}
+			return profiles.save(opts.configPath)
+		},
+	}
+	setCmd.Flags().StringVar(&profile.URL, "url", "", "base url of the API, defaults to "+defaultURL)
+	setCmd.Flags().StringVar(&profile.User, "user", "", "user sent in the user header")
+	setCmd.Flags().StringVar(&profile.UserHeader, "user-header", "", "header of the user, defaults to X-User-Id")
+	setCmd.Flags().StringVar(&profile.Token, "token", "", "bearer token of the authenticating proxy of the API")
+	cmd.AddCommand(setCmd)
+
+	cmd.AddCommand(&cobra.Command{
+		Use:               "use <name>",
+		Short:             "Set the current profile",
+		Args:              cobra.ExactArgs(1),
+		ValidArgsFunction: opts.completeProfiles,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			profiles, err := loadProfiles(opts.configPath)
+			if err != nil {
+				return err
+			}
+			if _, ok := profiles.Profiles[args[0]]; !ok {
+				return errors.Errorf("unknown profile %q", args[0])
+			}
+			profiles.Current = args[0]
+			return profiles.save(opts.configPath)
+		},
+	})
+
+	cmd.AddCommand(&cobra.Command{
+		Use:               "rm <name>",
+		Short:             "Remove a profile",
+		Args:              cobra.ExactArgs(1),
+		ValidArgsFunction: opts.completeProfiles,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			profiles, err := loadProfiles(opts.configPath)
+			if err != nil {
+				return err
+			}
+			if _, ok := profiles.Profiles[args[0]]; !ok {
+				return errors.Errorf("unknown profile %q", args[0])
+			}
+			delete(profiles.Profiles, args[0])
+			if profiles.Current == args[0] {
+				profiles.Current = ""
+			}
+			return profiles.save(opts.configPath)
+		},
+	})
+
+	return cmd
+}
+
+// completeProfiles completes the names of the profiles
+func (o *options) completeProfiles(*cobra.Command, []string, string) ([]string, cobra.ShellCompDirective) {
+	profiles, err := loadProfiles(o.configPath)
+	if err != nil {
+		return nil, cobra.ShellCompDirectiveError
+	}
+	return profiles.names(), cobra.ShellCompDirectiveNoFileComp
+}
@@ -0,0 +1,309 @@
+package cli
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"runtime"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v2"
+
+	"github.com/alexsniffin/go-api-starter/pkg/client"
+)
+
+// newAddCommand creates the command adding a todo
+func newAddCommand(opts *options) *cobra.Command {
+	var list string
+
+	cmd := &cobra.Command{
+		Use:   "add <todo>...",
+		Short: "Add a todo, the arguments are joined with spaces",
+		Args:  cobra.MinimumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			newClient, err := opts.newClient()
+			if err != nil {
+				return err
+			}
+
+			id, err := newClient.PostTodo(cmd.Context(), client.TodoPostRequest{Todo: strings.Join(args, " "), List: list})
+			if err != nil {
+				return err
+			}
+			fmt.Fprintln(cmd.OutOrStdout(), id)
+			return nil
+		},
+	}
+	cmd.Flags().StringVarP(&list, "list", "l", "", "shared list of the todo")
+	return cmd
+}
+
+// newListCommand creates the command listing todos as a table or JSON
+func newListCommand(opts *options) *cobra.Command {
+	var listOpts client.ListOptions
+	var done, open bool
+	var limit int
+	var output string
+
+	cmd := &cobra.Command{
+		Use:     "ls",
+		Aliases: []string{"list"},
+		Short:   "List your todos, or the todos of a shared list",
+		Args:    cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if output != "table" && output != "json" {
+				return errors.Errorf("invalid output %q, must be table or json", output)
+			}
+			if done && open {
+				return errors.New("--done and --open can't be combined")
+			}
+			if done || open {
+				listOpts.Done = &done
+			}
+
+			newClient, err := opts.newClient()
+			if err != nil {
+				return err
+			}
+
+			todos := []client.TodoItem{}
+			it := newClient.Todos(cmd.Context(), listOpts)
+			for (limit <= 0 || len(todos) < limit) && it.Next() {
+				todos = append(todos, it.Todo())
+			}
+			if err = it.Err(); err != nil {
+				return err
+			}
+
+			if output == "json" {
+				enc := json.NewEncoder(cmd.OutOrStdout())
+				enc.SetIndent("", "  ")
+				return enc.Encode(todos)
+			}
+			w := tabwriter.NewWriter(cmd.OutOrStdout(), 0, 4, 2, ' ', 0)
+			fmt.Fprintln(w, "ID\tDONE\tLIST\tTODO")
+			for _, todo := range todos {
+				state := ""
+				if todo.Done {
+					state = "x"
+				}
+				fmt.Fprintf(w, "%d\t%s\t%s\t%s\n", todo.ID, state, todo.List, todo.Todo)
+			}
+			return w.Flush()
+		},
+	}
+	cmd.Flags().StringVarP(&listOpts.List, "list", "l", "", "shared list of the todos")
+	cmd.Flags().BoolVar(&done, "done", false, "only list todos which are done")
+	cmd.Flags().BoolVar(&open, "open", false, "only list todos which aren't done")
+	cmd.Flags().IntVarP(&limit, "limit", "n", 0, "maximum number of todos, 0 lists all")
+	cmd.Flags().StringVarP(&output, "output", "o", "table", "output format, table or json")
+	_ = cmd.RegisterFlagCompletionFunc("output",
+		func(*cobra.Command, []string, string) ([]string, cobra.ShellCompDirective) {
+			return []string{"table", "json"}, cobra.ShellCompDirectiveNoFileComp
+		})
+	return cmd
+}
+
+// newDoneCommand creates the command marking todos as done
+func newDoneCommand(opts *options) *cobra.Command {
+	var undo bool
+
+	cmd := &cobra.Command{
+		Use:               "done <id>...",
+		Short:             "Mark todos as done",
+		Args:              cobra.MinimumNArgs(1),
+		ValidArgsFunction: opts.completeTodos,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			ids, err := parseIDs(args)
+			if err != nil {
+				return err
+			}
+			newClient, err := opts.newClient()
+			if err != nil {
+				return err
+			}
+
+			for _, id := range ids {
+				todo, err := newClient.GetTodo(cmd.Context(), id)
+				if err != nil {
+					return errors.Wrapf(err, "todo %d", id)
+				}
+				_, err = newClient.UpdateTodo(cmd.Context(), id, client.TodoPutRequest{
+					Todo: todo.Todo,
+					List: todo.List,
+					Done: !undo,
+				})
+				if err != nil {
+					return errors.Wrapf(err, "todo %d", id)
+				}
+			}
+			return nil
+		},
+	}
+	cmd.Flags().BoolVar(&undo, "undo", false, "mark the todos as not done")
+	return cmd
+}
+
+// newRemoveCommand creates the command deleting todos
+func newRemoveCommand(opts *options) *cobra.Command {
+	return &cobra.Command{
+		Use:               "rm <id>...",
+		Short:             "Delete todos",
+		Args:              cobra.MinimumNArgs(1),
+		ValidArgsFunction: opts.completeTodos,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			ids, err := parseIDs(args)
+			if err != nil {
+				return err
+			}
+			newClient, err := opts.newClient()
+			if err != nil {
+				return err
+			}
+
+			for _, id := range ids {
+				if err = newClient.DeleteTodo(cmd.Context(), id); err != nil {
+					return errors.Wrapf(err, "todo %d", id)
+				}
+			}
+			return nil
+		},
+	}
+}
+
+// editableTodo is the document of a todo edited in the editor
+type editableTodo struct {
+	Todo string `yaml:"todo"`
+	List string `yaml:"list"`
+	Done bool   `yaml:"done"`
+}
+
+// newEditCommand creates the command editing a todo in the editor of $VISUAL or $EDITOR
+func newEditCommand(opts *options) *cobra.Command {
+	return &cobra.Command{
+		Use:               "edit <id>",
+		Short:             "Edit a todo in $EDITOR",
+		Args:              cobra.ExactArgs(1),
+		ValidArgsFunction: opts.completeTodos,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			ids, err := parseIDs(args)
+			if err != nil {
+				return err
+			}
+			newClient, err := opts.newClient()
+			if err != nil {
+				return err
+			}
+
+			todo, err := newClient.GetTodo(cmd.Context(), ids[0])
+			if err != nil {
+				return err
+			}
+			original, err := yaml.Marshal(editableTodo{Todo: todo.Todo, List: todo.List, Done: todo.Done})
+			if err != nil {
+				return err
+			}
+
+			edited, err := editInEditor(cmd, original)
+			if err != nil {
+				return err
+			}
+			if bytes.Equal(edited, original) {
+				fmt.Fprintln(cmd.ErrOrStderr(), "todo unchanged")
+				return nil
+			}
+
+			var update editableTodo
+			if err = yaml.UnmarshalStrict(edited, &update); err != nil {
+				return errors.Wrap(err, "invalid todo")
+			}
+			_, err = newClient.UpdateTodo(cmd.Context(), ids[0], client.TodoPutRequest{
+				Todo: update.Todo,
+				List: update.List,
+				Done: update.Done,
+			})
+			return err
+		},
+	}
+}
+
+// editInEditor opens the content in the editor of the user and returns it once the editor exits
+func editInEditor(cmd *cobra.Command, content []byte) ([]byte, error) {
+	f, err := ioutil.TempFile("", "todo-*.yaml")
+	if err != nil {
+		return nil, err
+	}
+	defer os.Remove(f.Name())
+	if _, err = f.Write(content); err != nil {
+		f.Close()
+		return nil, err
+	}
+	if err = f.Close(); err != nil {
+		return nil, err
+	}
+
+	editor := os.Getenv("VISUAL")
+	if editor == "" {
+		editor = os.Getenv("EDITOR")
+	}
+	if editor == "" {
+		editor = "vi"
+		if runtime.GOOS == "windows" {
+			editor = "notepad"
+		}
+	}
+
+	// the editor is run by the shell since it may have arguments, e.g. `code --wait`
+	editorCmd := exec.Command("sh", "-c", editor+` "$1"`, "sh", f.Name())
+	if runtime.GOOS == "windows" {
+		editorCmd = exec.Command("cmd", "/C", editor, f.Name())
+	}
+	editorCmd.Stdin = cmd.InOrStdin()
+	editorCmd.Stdout = cmd.OutOrStdout()
+	editorCmd.Stderr = cmd.ErrOrStderr()
+	if err = editorCmd.Run(); err != nil {
+		return nil, errors.Wrap(err, "editor failed")
+	}
+
+	return ioutil.ReadFile(f.Name())
+}
+
+// completeTodos completes the IDs of the open todos of the user with the todo as description
+func (o *options) completeTodos(cmd *cobra.Command, _ []string, _ string) ([]string, cobra.ShellCompDirective) {
+	newClient, err := o.newClient()
+	if err != nil {
+		return nil, cobra.ShellCompDirectiveError
+	}
+
+	open := false
+	page, err := newClient.ListTodos(cmd.Context(), client.ListOptions{Done: &open})
+	if err != nil {
+		return nil, cobra.ShellCompDirectiveError
+	}
+
+	completions := make([]string, 0, len(page.Items))
+	for _, todo := range page.Items {
+		completions = append(completions, fmt.Sprintf("%d\t%s", todo.ID, todo.Todo))
+	}
+	return completions, cobra.ShellCompDirectiveNoFileComp
+}
+
+// parseIDs parses the IDs of todos of the arguments
+func parseIDs(args []string) ([]int, error) {
+	ids := make([]int, 0, len(args))
+	for _, arg := range args {
+		id, err := strconv.Atoi(arg)
+		if err != nil || id < 1 {
+			return nil, errors.Errorf("invalid id %q", arg)
+		}
+		ids = append(ids, id)
+	}
+	return ids, nil
+}
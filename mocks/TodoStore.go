@@ -63,6 +63,52 @@ func (_m *TodoStore) GetTodo(ctx context.Context, id int) (models.TodoItem, bool
 	return r0, r1, r2
 }
 
+// ListTodoEvents provides a mock function with given fields: ctx, todoIDs
+func (_m *TodoStore) ListTodoEvents(ctx context.Context, todoIDs []int) ([]models.TodoEvent, error) {
+	ret := _m.Called(ctx, todoIDs)
+
+	var r0 []models.TodoEvent
+	if rf, ok := ret.Get(0).(func(context.Context, []int) []models.TodoEvent); ok {
+		r0 = rf(ctx, todoIDs)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]models.TodoEvent)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, []int) error); ok {
+		r1 = rf(ctx, todoIDs)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// ListTodos provides a mock function with given fields: ctx, filter
+func (_m *TodoStore) ListTodos(ctx context.Context, filter models.TodoFilter) ([]models.TodoItem, error) {
+	ret := _m.Called(ctx, filter)
+
+	var r0 []models.TodoItem
+	if rf, ok := ret.Get(0).(func(context.Context, models.TodoFilter) []models.TodoItem); ok {
+		r0 = rf(ctx, filter)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]models.TodoItem)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, models.TodoFilter) error); ok {
+		r1 = rf(ctx, filter)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // PostTodo provides a mock function with given fields: ctx, _a1
 func (_m *TodoStore) PostTodo(ctx context.Context, _a1 models.TodoItem) (int, error) {
 	ret := _m.Called(ctx, _a1)
@@ -83,3 +129,59 @@ func (_m *TodoStore) PostTodo(ctx context.Context, _a1 models.TodoItem) (int, er
 
 	return r0, r1
 }
+
+// UpdateTodo provides a mock function with given fields: ctx, _a1
+func (_m *TodoStore) UpdateTodo(ctx context.Context, _a1 models.TodoItem) (models.TodoItem, bool, error) {
+	ret := _m.Called(ctx, _a1)
+
+	var r0 models.TodoItem
+	if rf, ok := ret.Get(0).(func(context.Context, models.TodoItem) models.TodoItem); ok {
+		r0 = rf(ctx, _a1)
+	} else {
+		r0 = ret.Get(0).(models.TodoItem)
+	}
+
+	var r1 bool
+	if rf, ok := ret.Get(1).(func(context.Context, models.TodoItem) bool); ok {
+		r1 = rf(ctx, _a1)
+	} else {
+		r1 = ret.Get(1).(bool)
+	}
+
+	var r2 error
+	if rf, ok := ret.Get(2).(func(context.Context, models.TodoItem) error); ok {
+		r2 = rf(ctx, _a1)
+	} else {
+		r2 = ret.Error(2)
+	}
+
+	return r0, r1, r2
+}
+
+// UpsertTodo provides a mock function with given fields: ctx, _a1
+func (_m *TodoStore) UpsertTodo(ctx context.Context, _a1 models.TodoItem) (models.TodoItem, bool, error) {
+	ret := _m.Called(ctx, _a1)
+
+	var r0 models.TodoItem
+	if rf, ok := ret.Get(0).(func(context.Context, models.TodoItem) models.TodoItem); ok {
+		r0 = rf(ctx, _a1)
+	} else {
+		r0 = ret.Get(0).(models.TodoItem)
+	}
+
+	var r1 bool
+	if rf, ok := ret.Get(1).(func(context.Context, models.TodoItem) bool); ok {
+		r1 = rf(ctx, _a1)
+	} else {
+		r1 = ret.Get(1).(bool)
+	}
+
+	var r2 error
+	if rf, ok := ret.Get(2).(func(context.Context, models.TodoItem) error); ok {
+		r2 = rf(ctx, _a1)
+	} else {
+		r2 = ret.Error(2)
+	}
+
+	return r0, r1, r2
+}
@@ -0,0 +1,228 @@
+package client
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	mathRand "math/rand"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/alexsniffin/go-api-starter/internal/todo-api/models"
+	"github.com/alexsniffin/go-api-starter/pkg/version"
+)
+
+const (
+	defaultUserHeader  = "X-User-Id"
+	defaultTimeout     = 30 * time.Second
+	defaultMaxAttempts = 3
+	defaultMinBackoff  = 100 * time.Millisecond
+	defaultMaxBackoff  = 5 * time.Second
+)
+
+// Config of a Client, zero values are set to their defaults
+type Config struct {
+	// BaseURL of the API, e.g. `http://localhost:8080`
+	BaseURL string
+	// UserID identifies the caller in the UserHeader, requests without it are anonymous
+	UserID string
+	// UserHeader of the UserID, defaults to `X-User-Id`
+	UserHeader string
+	// Token is sent as bearer token in the Authorization header, e.g. to an authenticating proxy in front of the API
+	Token string
+	// HTTPClient sends the requests, defaults to a client with a timeout of 30 seconds
+	HTTPClient *http.Client
+	Retry      RetryConfig
+}
+
+// RetryConfig of the requests which are safe to retry, GET, PUT, DELETE and POST with an idempotency key. Requests are
+// retried on network errors and on 429, 502, 503 and 504 responses, waiting for the backoff or `Retry-After`.
+type RetryConfig struct {
+	// MaxAttempts of a request including the first, defaults to 3 and 1 disables retries
+	MaxAttempts int
+	// MinBackoff before the first retry, doubled for every further retry up to MaxBackoff
+	MinBackoff time.Duration
+	MaxBackoff time.Duration
+}
+
+// Client of the Todo API
+type Client struct {
+	cfg     Config
+	baseURL *url.URL
+}
+
+// NewClient creates a Client of the API at the base URL of the config
+func NewClient(cfg Config) (*Client, error) {
+	baseURL, err := url.Parse(strings.TrimRight(cfg.BaseURL, "/"))
+	if err != nil {
+		return nil, errors.Wrap(err, "invalid base url")
+	}
+	if baseURL.Scheme != "http" && baseURL.Scheme != "https" {
+		return nil, errors.Errorf("invalid base url %q, must be http or https", cfg.BaseURL)
+	}
+
+	if cfg.UserHeader == "" {
+		cfg.UserHeader = defaultUserHeader
+	}
+	if cfg.HTTPClient == nil {
+		cfg.HTTPClient = &http.Client{Timeout: defaultTimeout}
+	}
+	if cfg.Retry.MaxAttempts == 0 {
+		cfg.Retry.MaxAttempts = defaultMaxAttempts
+	}
+	if cfg.Retry.MinBackoff == 0 {
+		cfg.Retry.MinBackoff = defaultMinBackoff
+	}
+	if cfg.Retry.MaxBackoff == 0 {
+		cfg.Retry.MaxBackoff = defaultMaxBackoff
+	}
+
+	return &Client{
+		cfg:     cfg,
+		baseURL: baseURL,
+	}, nil
+}
+
+// request to the API, the body is encoded as JSON
+type request struct {
+	method string
+	path   string
+	query  url.Values
+	header http.Header
+	body   interface{}
+}
+
+// do sends the request, retrying it if it's safe to, and decodes the JSON response into out. Returns the status code of
+// a successful response, otherwise an *Error of the response.
+func (c *Client) do(ctx context.Context, req request, out interface{}) (int, error) {
+	var body []byte
+	if req.body != nil {
+		var err error
+		if body, err = json.Marshal(req.body); err != nil {
+			return 0, err
+		}
+	}
+
+	retryable := req.method != http.MethodPost || req.header.Get(models.IdempotencyKeyHeader) != ""
+	for attempt := 1; ; attempt++ {
+		resp, err := c.send(ctx, req, body)
+		if err == nil && resp.StatusCode < http.StatusBadRequest {
+			defer resp.Body.Close()
+			if out != nil && resp.StatusCode != http.StatusNoContent {
+				if err = json.NewDecoder(resp.Body).Decode(out); err != nil {
+					return 0, errors.Wrap(err, "failed to decode response")
+				}
+			}
+			return resp.StatusCode, nil
+		}
+
+		var retryAfter time.Duration
+		if err == nil {
+			retryAfter = parseRetryAfter(resp.Header.Get("Retry-After"))
+			err = decodeError(resp)
+			resp.Body.Close()
+		}
+		if !retryable || attempt >= c.cfg.Retry.MaxAttempts || !shouldRetry(ctx, err) {
+			return 0, err
+		}
+
+		backoff := c.backoff(attempt)
+		if retryAfter > backoff {
+			backoff = retryAfter
+			if backoff > c.cfg.Retry.MaxBackoff {
+				backoff = c.cfg.Retry.MaxBackoff
+			}
+		}
+		select {
+		case <-time.After(backoff):
+		case <-ctx.Done():
+			return 0, ctx.Err()
+		}
+	}
+}
+
+// send an attempt of the request
+func (c *Client) send(ctx context.Context, req request, body []byte) (*http.Response, error) {
+	u := *c.baseURL
+	u.Path += req.path
+	u.RawQuery = req.query.Encode()
+
+	var bodyReader io.Reader
+	if body != nil {
+		bodyReader = bytes.NewReader(body)
+	}
+	httpReq, err := http.NewRequest(req.method, u.String(), bodyReader)
+	if err != nil {
+		return nil, err
+	}
+	httpReq = httpReq.WithContext(ctx)
+
+	for key := range req.header {
+		httpReq.Header.Set(key, req.header.Get(key))
+	}
+	httpReq.Header.Set("Accept", "application/json")
+	httpReq.Header.Set("User-Agent", "todo-api-client/"+version.Version)
+	if body != nil {
+		httpReq.Header.Set("Content-Type", "application/json")
+	}
+	if c.cfg.UserID != "" {
+		httpReq.Header.Set(c.cfg.UserHeader, c.cfg.UserID)
+	}
+	if c.cfg.Token != "" {
+		httpReq.Header.Set("Authorization", "Bearer "+c.cfg.Token)
+	}
+
+	return c.cfg.HTTPClient.Do(httpReq)
+}
+
+// backoff before the retry of the attempt, with jitter of up to half of the backoff so clients don't retry in sync
+func (c *Client) backoff(attempt int) time.Duration {
+	backoff := c.cfg.Retry.MinBackoff * time.Duration(1<<uint(attempt-1))
+	if backoff > c.cfg.Retry.MaxBackoff || backoff <= 0 {
+		backoff = c.cfg.Retry.MaxBackoff
+	}
+	return backoff/2 + time.Duration(mathRand.Int63n(int64(backoff/2)+1))
+}
+
+// shouldRetry reports whether the error of an attempt is transient, a canceled context isn't
+func shouldRetry(ctx context.Context, err error) bool {
+	if ctx.Err() != nil {
+		return false
+	}
+
+	var apiErr *Error
+	if errors.As(err, &apiErr) {
+		switch apiErr.StatusCode {
+		case http.StatusTooManyRequests, http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+			return true
+		}
+		return false
+	}
+	return true
+}
+
+// parseRetryAfter parses the delay of a `Retry-After` header in seconds, dates aren't supported
+func parseRetryAfter(value string) time.Duration {
+	seconds, err := strconv.Atoi(value)
+	if err != nil || seconds < 0 {
+		return 0
+	}
+	return time.Duration(seconds) * time.Second
+}
+
+// newIdempotencyKey generates a random key of a POST
+func newIdempotencyKey() (string, error) {
+	key := make([]byte, 16)
+	if _, err := rand.Read(key); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(key), nil
+}
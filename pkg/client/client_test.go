@@ -0,0 +1,182 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/mock"
+	"github.com/unrolled/render"
+
+	"github.com/alexsniffin/go-api-starter/internal/todo-api/handlers/events"
+	"github.com/alexsniffin/go-api-starter/internal/todo-api/handlers/gql"
+	"github.com/alexsniffin/go-api-starter/internal/todo-api/handlers/health"
+	"github.com/alexsniffin/go-api-starter/internal/todo-api/handlers/todo"
+	"github.com/alexsniffin/go-api-starter/internal/todo-api/handlers/ws"
+	"github.com/alexsniffin/go-api-starter/internal/todo-api/models"
+	"github.com/alexsniffin/go-api-starter/internal/todo-api/router"
+	"github.com/alexsniffin/go-api-starter/internal/todo-api/utils"
+	"github.com/alexsniffin/go-api-starter/mocks"
+	pkgModels "github.com/alexsniffin/go-api-starter/pkg/models"
+)
+
+// initClient creates a client of an httptest server running the router of the API, the first `failures` requests are
+// answered with 503
+func initClient(t *testing.T, failures int32) (*Client, *mocks.TodoStore, *int32) {
+	logger := zerolog.New(os.Stdout)
+	routerCfg := models.HTTPRouterConfig{
+		TimeoutSec:     5,
+		UserHeader:     "X-User-Id",
+		AllowedOrigins: []string{"*"},
+	}
+	todoStoreMock := &mocks.TodoStore{}
+	testRouter := router.NewRouter(routerCfg, pkgModels.Logger{Level: "debug"}, router.NewSettings(routerCfg),
+		logger, prometheus.NewRegistry(),
+		todo.NewHandler(models.RESTConfig{MaxBodyBytes: 1 << 20, MaxImportBytes: 1 << 20}, logger, render.New(),
+			todoStoreMock),
+		events.Handler{}, ws.Handler{}, gql.Handler{}, health.Handler{})
+
+	var requests int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if atomic.AddInt32(&requests, 1) <= failures {
+			w.Header().Set("Retry-After", "0")
+			http.Error(w, "unavailable", http.StatusServiceUnavailable)
+			return
+		}
+		testRouter.ServeHTTP(w, req)
+	}))
+	t.Cleanup(server.Close)
+
+	client, err := NewClient(Config{
+		BaseURL: server.URL,
+		UserID:  "user",
+		Retry:   RetryConfig{MinBackoff: time.Millisecond, MaxBackoff: 10 * time.Millisecond},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return client, todoStoreMock, &requests
+}
+
+func TestClient(t *testing.T) {
+	t.Run("getTodo", func(t *testing.T) {
+		client, todoStoreMock, _ := initClient(t, 0)
+		todoStoreMock.On("GetTodo", mock.Anything, 1).Return(models.TodoItem{ID: 1, Todo: "test"}, true, nil)
+
+		todo, err := client.GetTodo(context.Background(), 1)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if todo.ID != 1 || todo.Todo != "test" {
+			t.Errorf("wrong todo: got %+v", todo)
+		}
+	})
+
+	t.Run("notFound", func(t *testing.T) {
+		client, todoStoreMock, _ := initClient(t, 0)
+		todoStoreMock.On("GetTodo", mock.Anything, 1).Return(models.TodoItem{}, false, nil)
+		todoStoreMock.On("DeleteTodo", mock.Anything, 1).Return(0, nil)
+		todoStoreMock.On("UpdateTodo", mock.Anything, mock.Anything).Return(models.TodoItem{}, false, nil)
+
+		if _, err := client.GetTodo(context.Background(), 1); !errors.Is(err, ErrNotFound) {
+			t.Errorf("wrong error of get: got %v want %v", err, ErrNotFound)
+		}
+		if err := client.DeleteTodo(context.Background(), 1); !errors.Is(err, ErrNotFound) {
+			t.Errorf("wrong error of delete: got %v want %v", err, ErrNotFound)
+		}
+		_, err := client.UpdateTodo(context.Background(), 1, TodoPutRequest{Todo: "test"})
+		if !errors.Is(err, ErrNotFound) {
+			t.Errorf("wrong error of update: got %v want %v", err, ErrNotFound)
+		}
+	})
+
+	t.Run("problem", func(t *testing.T) {
+		client, _, _ := initClient(t, 0)
+
+		_, err := client.UpdateTodo(context.Background(), 1, TodoPutRequest{})
+		var apiErr *Error
+		if !errors.As(err, &apiErr) {
+			t.Fatalf("wrong error: got %v want *Error", err)
+		}
+		if apiErr.StatusCode != http.StatusBadRequest || apiErr.Title != "Bad Request" ||
+			apiErr.Detail != "todo: cannot be blank." {
+			t.Errorf("wrong error: got %+v", apiErr)
+		}
+	})
+
+	t.Run("postRetry", func(t *testing.T) {
+		client, todoStoreMock, requests := initClient(t, 2)
+		todoStoreMock.On("PostTodo", mock.Anything, mock.MatchedBy(func(todo models.TodoItem) bool {
+			return todo.Todo == "test" && todo.UserID == "user" && todo.IdempotencyKey == "key"
+		})).Return(7, nil)
+
+		id, err := client.PostTodoWithKey(context.Background(), "key", TodoPostRequest{Todo: "test"})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if id != 7 {
+			t.Errorf("wrong id: got %v want %v", id, 7)
+		}
+		if *requests != 3 {
+			t.Errorf("wrong number of requests: got %v want %v", *requests, 3)
+		}
+		todoStoreMock.AssertNumberOfCalls(t, "PostTodo", 1)
+	})
+
+	t.Run("retryLimit", func(t *testing.T) {
+		client, _, requests := initClient(t, 5)
+
+		_, err := client.GetTodo(context.Background(), 1)
+		var apiErr *Error
+		if !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusServiceUnavailable {
+			t.Errorf("wrong error: got %v want %v", err, http.StatusServiceUnavailable)
+		}
+		if *requests != 3 {
+			t.Errorf("wrong number of requests: got %v want %v", *requests, 3)
+		}
+	})
+
+	t.Run("iterator", func(t *testing.T) {
+		client, todoStoreMock, _ := initClient(t, 0)
+		todoStoreMock.On("ListTodos", mock.Anything, models.TodoFilter{UserID: "user", Limit: 2}).
+			Return([]models.TodoItem{{ID: 1}, {ID: 2}}, nil)
+		todoStoreMock.On("ListTodos", mock.Anything, models.TodoFilter{UserID: "user", AfterID: 2, Limit: 2}).
+			Return([]models.TodoItem{{ID: 3}}, nil)
+
+		var ids []int
+		it := client.Todos(context.Background(), ListOptions{First: 2})
+		for it.Next() {
+			ids = append(ids, it.Todo().ID)
+		}
+		if err := it.Err(); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if len(ids) != 3 || ids[0] != 1 || ids[2] != 3 {
+			t.Errorf("wrong todos: got %v want %v", ids, []int{1, 2, 3})
+		}
+		todoStoreMock.AssertNumberOfCalls(t, "ListTodos", 2)
+	})
+
+	t.Run("filters", func(t *testing.T) {
+		client, todoStoreMock, _ := initClient(t, 0)
+		done := true
+		todoStoreMock.On("ListTodos", mock.Anything, models.TodoFilter{UserID: "user", List: "home", Done: &done,
+			AfterID: 5, Limit: 50}).Return([]models.TodoItem{}, nil)
+
+		page, err := client.ListTodos(context.Background(), ListOptions{List: "home", Done: &done,
+			After: utils.EncodePageToken(5)})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if len(page.Items) != 0 || page.NextCursor != "" {
+			t.Errorf("wrong page: got %+v", page)
+		}
+	})
+}
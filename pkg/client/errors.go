@@ -0,0 +1,74 @@
+package client
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"mime"
+	"net/http"
+	"strings"
+
+	"github.com/pkg/errors"
+
+	"github.com/alexsniffin/go-api-starter/internal/todo-api/models"
+)
+
+// maxErrorBodyBytes of an error response which are read
+const maxErrorBodyBytes = 64 << 10
+
+// ErrNotFound is returned if the todo doesn't exist, which the API answers with 204 No Content. errors.Is also matches
+// an *Error of a 404 response with it
+var ErrNotFound = errors.New("todo not found")
+
+// Error is an error response of the API, decoded from an RFC 7807 problem if the response is one
+type Error struct {
+	StatusCode int
+	Type       string
+	Title      string
+	Detail     string
+}
+
+func (e *Error) Error() string {
+	if e.Detail == "" {
+		return fmt.Sprintf("todo api: %d %s", e.StatusCode, e.Title)
+	}
+	return fmt.Sprintf("todo api: %d %s: %s", e.StatusCode, e.Title, e.Detail)
+}
+
+// Is reports whether the error is of a missing todo
+func (e *Error) Is(target error) bool {
+	return target == ErrNotFound && e.StatusCode == http.StatusNotFound
+}
+
+// decodeError decodes the *Error of a failed response, the body is used as detail if it isn't a problem
+func decodeError(resp *http.Response) error {
+	apiErr := &Error{
+		StatusCode: resp.StatusCode,
+		Title:      http.StatusText(resp.StatusCode),
+	}
+
+	body, err := ioutil.ReadAll(io.LimitReader(resp.Body, maxErrorBodyBytes))
+	if err != nil {
+		return apiErr
+	}
+
+	mediaType, _, _ := mime.ParseMediaType(resp.Header.Get("Content-Type"))
+	if mediaType == models.ProblemContentType || mediaType == "application/json" {
+		var problem models.Problem
+		if err = json.Unmarshal(body, &problem); err == nil {
+			apiErr.Type = problem.Type
+			if problem.Title != "" {
+				apiErr.Title = problem.Title
+			}
+			apiErr.Detail = problem.Detail
+			if apiErr.Detail == "" {
+				apiErr.Detail = problem.Message
+			}
+			return apiErr
+		}
+	}
+
+	apiErr.Detail = strings.TrimSpace(string(body))
+	return apiErr
+}
@@ -0,0 +1,169 @@
+package client
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"strconv"
+
+	"github.com/alexsniffin/go-api-starter/internal/todo-api/models"
+)
+
+// Models of the API
+type (
+	TodoItem         = models.TodoItem
+	TodoPostRequest  = models.TodoPostRequest
+	TodoPutRequest   = models.TodoPutRequest
+	TodoListResponse = models.TodoListResponse
+)
+
+// ListOptions selects the todos of a list, or of the caller if no list is given
+type ListOptions struct {
+	List string
+	// Done filters the todos by their state if set
+	Done *bool
+	// First is the size of a page, defaults to the page size of the API
+	First int
+	// After is the cursor of the previous page
+	After string
+}
+
+// GetTodo gets a todo, returns ErrNotFound if it doesn't exist
+func (c *Client) GetTodo(ctx context.Context, id int) (TodoItem, error) {
+	var todo TodoItem
+	status, err := c.do(ctx, request{method: http.MethodGet, path: todoPath(id)}, &todo)
+	if err != nil {
+		return TodoItem{}, err
+	}
+	if status == http.StatusNoContent {
+		return TodoItem{}, ErrNotFound
+	}
+	return todo, nil
+}
+
+// ListTodos lists a page of todos, the next page is listed with the cursor of the response
+func (c *Client) ListTodos(ctx context.Context, opts ListOptions) (TodoListResponse, error) {
+	query := url.Values{}
+	if opts.List != "" {
+		query.Set("list", opts.List)
+	}
+	if opts.Done != nil {
+		query.Set("done", strconv.FormatBool(*opts.Done))
+	}
+	if opts.First > 0 {
+		query.Set("first", strconv.Itoa(opts.First))
+	}
+	if opts.After != "" {
+		query.Set("after", opts.After)
+	}
+
+	var page TodoListResponse
+	_, err := c.do(ctx, request{method: http.MethodGet, path: "/api/todo", query: query}, &page)
+	return page, err
+}
+
+// Todos iterates over the todos of every page starting at the cursor of the options, pages are listed as they're
+// reached
+func (c *Client) Todos(ctx context.Context, opts ListOptions) *TodoIterator {
+	return &TodoIterator{
+		client: c,
+		ctx:    ctx,
+		opts:   opts,
+	}
+}
+
+// PostTodo creates a todo and returns its ID. The request has a generated idempotency key so it's created once even if
+// the request is retried.
+func (c *Client) PostTodo(ctx context.Context, todo TodoPostRequest) (int, error) {
+	key, err := newIdempotencyKey()
+	if err != nil {
+		return 0, err
+	}
+	return c.PostTodoWithKey(ctx, key, todo)
+}
+
+// PostTodoWithKey creates a todo with the idempotency key and returns its ID, posting the key again returns the ID of
+// the todo created with it, e.g. to retry after a restart of the caller
+func (c *Client) PostTodoWithKey(ctx context.Context, key string, todo TodoPostRequest) (int, error) {
+	header := http.Header{}
+	header.Set(models.IdempotencyKeyHeader, key)
+
+	var response models.TodoPostResponse
+	_, err := c.do(ctx, request{method: http.MethodPost, path: "/api/todo", header: header, body: todo}, &response)
+	return response.ID, err
+}
+
+// UpdateTodo updates a todo and returns it, returns ErrNotFound if it doesn't exist
+func (c *Client) UpdateTodo(ctx context.Context, id int, todo TodoPutRequest) (TodoItem, error) {
+	var updated TodoItem
+	status, err := c.do(ctx, request{method: http.MethodPut, path: todoPath(id), body: todo}, &updated)
+	if err != nil {
+		return TodoItem{}, err
+	}
+	if status == http.StatusNoContent {
+		return TodoItem{}, ErrNotFound
+	}
+	return updated, nil
+}
+
+// DeleteTodo deletes a todo, returns ErrNotFound if it doesn't exist
+func (c *Client) DeleteTodo(ctx context.Context, id int) error {
+	status, err := c.do(ctx, request{method: http.MethodDelete, path: todoPath(id)}, nil)
+	if err != nil {
+		return err
+	}
+	if status == http.StatusNoContent {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// TodoIterator iterates over the todos of the pages of a list
+type TodoIterator struct {
+	client *Client
+	ctx    context.Context
+	opts   ListOptions
+
+	page []TodoItem
+	last bool
+	todo TodoItem
+	err  error
+}
+
+// Next advances to the next todo, listing the next page if needed. Returns false at the end of the todos or on an
+// error, which is returned by Err.
+func (it *TodoIterator) Next() bool {
+	for len(it.page) == 0 {
+		if it.last || it.err != nil {
+			return false
+		}
+
+		page, err := it.client.ListTodos(it.ctx, it.opts)
+		if err != nil {
+			it.err = err
+			return false
+		}
+		it.page = page.Items
+		it.opts.After = page.NextCursor
+		it.last = page.NextCursor == ""
+	}
+
+	it.todo = it.page[0]
+	it.page = it.page[1:]
+	return true
+}
+
+// Todo returns the current todo
+func (it *TodoIterator) Todo() TodoItem {
+	return it.todo
+}
+
+// Err returns the error which stopped the iteration
+func (it *TodoIterator) Err() error {
+	return it.err
+}
+
+// todoPath of the todo of the ID
+func todoPath(id int) string {
+	return "/api/todo/" + strconv.Itoa(id)
+}
@@ -1,20 +1,65 @@
 package config
 
 import (
+	"io/ioutil"
+	"os"
 	"strings"
+	"sync"
 
+	"github.com/pkg/errors"
+	"github.com/spf13/pflag"
 	"github.com/spf13/viper"
 )
 
-// Creates a config model with viper
-func NewConfig(fileName, prefix string, cfg interface{}) error {
+// Options of loading a config
+type Options struct {
+	// Strict rejects keys of the config file which don't match a field of the config model
+	Strict bool
+	// File is the path of the config file, instead of searching `configs` and the working directory for it
+	File string
+	// Flags set the config keys they're mapped to, overriding the config file and environment variables if changed
+	Flags map[string]*pflag.Flag
+}
+
+// Creates a config model with viper. Values are read from the config file and overridden by environment variables,
+// a `<prefix>_<KEY>_FILE` variable sets the key to the content of the file it names, e.g. a mounted secret. The model
+// is validated if it implements `Validate() error`.
+func NewConfig(fileName, prefix string, opts Options, cfg interface{}) error {
+	return NewLoader(fileName, prefix, opts).Load(cfg)
+}
+
+// Loader loads a config model again from the same file and environment, e.g. to reload it
+type Loader struct {
+	fileName string
+	prefix   string
+	opts     Options
+
+	mu   sync.Mutex
+	path string
+}
+
+// NewLoader creates a Loader of the config file with the environment variables of the prefix
+func NewLoader(fileName, prefix string, opts Options) *Loader {
+	return &Loader{
+		fileName: fileName,
+		prefix:   prefix,
+		opts:     opts,
+	}
+}
+
+// Load the config model, see NewConfig
+func (l *Loader) Load(cfg interface{}) error {
 	v := viper.New()
 
-	v.SetConfigName(fileName)
-	v.AddConfigPath("configs")
-	v.AddConfigPath(".")
+	if l.opts.File != "" {
+		v.SetConfigFile(l.opts.File)
+	} else {
+		v.SetConfigName(l.fileName)
+		v.AddConfigPath("configs")
+		v.AddConfigPath(".")
+	}
 	v.SetConfigType("yaml")
-	v.SetEnvPrefix(prefix)
+	v.SetEnvPrefix(l.prefix)
 	v.SetEnvKeyReplacer(strings.NewReplacer(".", "_"))
 	v.AutomaticEnv()
 
@@ -22,10 +67,70 @@ func NewConfig(fileName, prefix string, cfg interface{}) error {
 	if err != nil {
 		return err
 	}
+	l.mu.Lock()
+	l.path = v.ConfigFileUsed()
+	l.mu.Unlock()
 
-	err = v.Unmarshal(&cfg)
+	err = setSecretFiles(v, l.prefix)
 	if err != nil {
 		return err
 	}
+
+	for key, flag := range l.opts.Flags {
+		if !flag.Changed {
+			continue
+		}
+		if err = v.BindPFlag(key, flag); err != nil {
+			return errors.Wrapf(err, "failed to bind flag %s", flag.Name)
+		}
+	}
+
+	if l.opts.Strict {
+		err = v.UnmarshalExact(&cfg)
+	} else {
+		err = v.Unmarshal(&cfg)
+	}
+	if err != nil {
+		return err
+	}
+
+	if validatable, ok := cfg.(interface{ Validate() error }); ok {
+		if err = validatable.Validate(); err != nil {
+			return errors.Wrap(err, "invalid config")
+		}
+	}
+	return nil
+}
+
+// Path returns the path of the config file found by the last Load
+func (l *Loader) Path() string {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.path
+}
+
+// setSecretFiles sets the keys of the `<prefix>_<KEY>_FILE` environment variables to the content of their files
+func setSecretFiles(v *viper.Viper, prefix string) error {
+	envPrefix := strings.ToUpper(prefix) + "_"
+	for _, env := range os.Environ() {
+		pair := strings.SplitN(env, "=", 2)
+		name, path := pair[0], pair[1]
+		if !strings.HasPrefix(name, envPrefix) || !strings.HasSuffix(name, "_FILE") || path == "" {
+			continue
+		}
+
+		envKey := strings.TrimSuffix(name, "_FILE")
+		if _, ok := os.LookupEnv(envKey); ok {
+			return errors.Errorf("both %s and %s are set", envKey, name)
+		}
+
+		content, err := ioutil.ReadFile(path)
+		if err != nil {
+			return errors.Wrapf(err, "failed to read secret file of %s", name)
+		}
+		key := strings.ToLower(strings.ReplaceAll(strings.TrimPrefix(envKey, envPrefix), "_", "."))
+		v.Set(key, strings.TrimRight(string(content), "\r\n"))
+	}
+
 	return nil
 }
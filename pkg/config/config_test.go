@@ -0,0 +1,145 @@
+package config
+
+import (
+	"bytes"
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/spf13/pflag"
+)
+
+type testConfig struct {
+	Server struct {
+		Port int
+	}
+	Password string `secret:"true"`
+}
+
+func (c testConfig) Validate() error {
+	if c.Server.Port == 0 {
+		return errors.New("Server.Port: cannot be blank")
+	}
+	return nil
+}
+
+// inConfigDir runs the test in a directory containing the config file
+func inConfigDir(t *testing.T, content string) {
+	dir, err := ioutil.TempDir("", "config")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = ioutil.WriteFile(filepath.Join(dir, "test.yaml"), []byte(content), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = os.Chdir(dir); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		os.Chdir(wd)
+		os.RemoveAll(dir)
+	})
+}
+
+func TestNewConfig(t *testing.T) {
+	t.Run("strictUnknownKeys", func(t *testing.T) {
+		inConfigDir(t, "Server:\n  Port: 80\nServr:\n  Port: 80\n")
+
+		var cfg testConfig
+		if err := NewConfig("test", "TEST", Options{}, &cfg); err != nil {
+			t.Errorf("unexpected error: %v", err)
+		}
+		if err := NewConfig("test", "TEST", Options{Strict: true}, &cfg); err == nil {
+			t.Errorf("expected error of the unknown key")
+		}
+	})
+
+	t.Run("invalidConfig", func(t *testing.T) {
+		inConfigDir(t, "Server:\n  Port: 0\n")
+
+		var cfg testConfig
+		err := NewConfig("test", "TEST", Options{}, &cfg)
+		if err == nil || !strings.Contains(err.Error(), "Server.Port: cannot be blank") {
+			t.Errorf("wrong error: got %v want %v", err, "Server.Port: cannot be blank")
+		}
+	})
+
+	t.Run("secretFile", func(t *testing.T) {
+		inConfigDir(t, "Server:\n  Port: 80\nPassword: \"\"\n")
+		if err := ioutil.WriteFile("password", []byte("s3cret\n"), 0600); err != nil {
+			t.Fatal(err)
+		}
+		os.Setenv("TEST_PASSWORD_FILE", "password")
+		defer os.Unsetenv("TEST_PASSWORD_FILE")
+
+		var cfg testConfig
+		if err := NewConfig("test", "TEST", Options{Strict: true}, &cfg); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if cfg.Password != "s3cret" {
+			t.Errorf("wrong password: got %v want %v", cfg.Password, "s3cret")
+		}
+	})
+
+	t.Run("changedFlagOverride", func(t *testing.T) {
+		inConfigDir(t, "Server:\n  Port: 80\n")
+
+		flags := pflag.NewFlagSet("test", pflag.ContinueOnError)
+		flags.Int("port", 0, "")
+		flags.String("password", "", "")
+		if err := flags.Parse([]string{"--port", "8080"}); err != nil {
+			t.Fatal(err)
+		}
+		opts := Options{Strict: true, Flags: map[string]*pflag.Flag{
+			"Server.Port": flags.Lookup("port"),
+			"Password":    flags.Lookup("password"),
+		}}
+
+		var cfg testConfig
+		if err := NewConfig("test", "TEST", opts, &cfg); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if cfg.Server.Port != 8080 {
+			t.Errorf("wrong port: got %v want %v", cfg.Server.Port, 8080)
+		}
+	})
+
+	t.Run("configPath", func(t *testing.T) {
+		inConfigDir(t, "Server:\n  Port: 80\n")
+		if err := ioutil.WriteFile("other.yaml", []byte("Server:\n  Port: 81\n"), 0600); err != nil {
+			t.Fatal(err)
+		}
+
+		var cfg testConfig
+		if err := NewConfig("test", "TEST", Options{File: "other.yaml"}, &cfg); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if cfg.Server.Port != 81 {
+			t.Errorf("wrong port: got %v want %v", cfg.Server.Port, 81)
+		}
+	})
+}
+
+func TestPrint(t *testing.T) {
+	var cfg testConfig
+	cfg.Server.Port = 80
+	cfg.Password = "s3cret"
+
+	var buf bytes.Buffer
+	if err := Print(&buf, cfg); err != nil {
+		t.Fatal(err)
+	}
+
+	want := "Server:\n  Port: 80\nPassword: '" + masked + "'\n"
+	if got := buf.String(); got != want {
+		t.Errorf("wrong output: got %v want %v", got, want)
+	}
+}
@@ -0,0 +1,41 @@
+package config
+
+import (
+	"fmt"
+	"reflect"
+)
+
+// Diff lists the fields which differ between two config models as `<path>: <old> -> <new>`, with the values of
+// secrets masked
+func Diff(old, new interface{}) []string {
+	var changes []string
+	diff("", reflect.ValueOf(old), reflect.ValueOf(new), false, &changes)
+	return changes
+}
+
+func diff(path string, old, new reflect.Value, secret bool, changes *[]string) {
+	if old.Kind() == reflect.Struct {
+		for i := 0; i < old.NumField(); i++ {
+			field := old.Type().Field(i)
+			if field.PkgPath != "" {
+				continue
+			}
+
+			fieldPath := field.Name
+			if path != "" {
+				fieldPath = path + "." + field.Name
+			}
+			diff(fieldPath, old.Field(i), new.Field(i), field.Tag.Get("secret") == "true", changes)
+		}
+		return
+	}
+
+	if reflect.DeepEqual(old.Interface(), new.Interface()) {
+		return
+	}
+	if secret {
+		*changes = append(*changes, fmt.Sprintf("%s: %v -> %v", path, mask(old), mask(new)))
+		return
+	}
+	*changes = append(*changes, fmt.Sprintf("%s: %v -> %v", path, old.Interface(), new.Interface()))
+}
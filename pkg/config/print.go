@@ -0,0 +1,62 @@
+package config
+
+import (
+	"io"
+	"reflect"
+
+	"gopkg.in/yaml.v2"
+)
+
+// masked replaces the value of a field tagged with `secret:"true"` when printed
+const masked = "********"
+
+// Print writes the config model as YAML, in the order of its fields and with the values of secrets masked
+func Print(w io.Writer, cfg interface{}) error {
+	out, err := yaml.Marshal(printable(reflect.ValueOf(cfg)))
+	if err != nil {
+		return err
+	}
+
+	_, err = w.Write(out)
+	return err
+}
+
+func printable(value reflect.Value) interface{} {
+	switch value.Kind() {
+	case reflect.Ptr, reflect.Interface:
+		if value.IsNil() {
+			return nil
+		}
+		return printable(value.Elem())
+	case reflect.Struct:
+		fields := yaml.MapSlice{}
+		for i := 0; i < value.NumField(); i++ {
+			field := value.Type().Field(i)
+			if field.PkgPath != "" {
+				continue
+			}
+			if field.Tag.Get("secret") == "true" {
+				fields = append(fields, yaml.MapItem{Key: field.Name, Value: mask(value.Field(i))})
+				continue
+			}
+			fields = append(fields, yaml.MapItem{Key: field.Name, Value: printable(value.Field(i))})
+		}
+		return fields
+	case reflect.Slice, reflect.Array:
+		items := make([]interface{}, value.Len())
+		for i := 0; i < value.Len(); i++ {
+			items[i] = printable(value.Index(i))
+		}
+		return items
+	default:
+		return value.Interface()
+	}
+}
+
+// mask hides the value of a secret, empty secrets are printed as is so it's visible they aren't set
+func mask(value reflect.Value) interface{} {
+	if value.IsZero() {
+		return value.Interface()
+	}
+	return masked
+}
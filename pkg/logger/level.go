@@ -0,0 +1,97 @@
+package logger
+
+import (
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/rs/zerolog"
+)
+
+// levels holds the level of the loggers created by NewLogger, it's changed at runtime with SetLevel
+var levels = struct {
+	sync.Mutex
+	current    int32
+	configured zerolog.Level
+	revert     *time.Timer
+	revertOn   time.Time
+}{}
+
+// Level returns the current level of the loggers and when it reverts to the configured level, zero if it doesn't
+func Level() (zerolog.Level, time.Time) {
+	levels.Lock()
+	defer levels.Unlock()
+	return zerolog.Level(atomic.LoadInt32(&levels.current)), levels.revertOn
+}
+
+// SetLevel changes the level of the loggers until the ttl passes, then it's reverted to the configured level. Returns
+// the time of the revert, the level is kept if the ttl isn't positive.
+func SetLevel(level zerolog.Level, ttl time.Duration) time.Time {
+	levels.Lock()
+	defer levels.Unlock()
+
+	if levels.revert != nil {
+		levels.revert.Stop()
+	}
+	atomic.StoreInt32(&levels.current, int32(level))
+	if ttl <= 0 {
+		levels.revert = nil
+		levels.revertOn = time.Time{}
+		return levels.revertOn
+	}
+
+	var revert *time.Timer
+	revert = time.AfterFunc(ttl, func() {
+		levels.Lock()
+		defer levels.Unlock()
+		if levels.revert != revert {
+			return
+		}
+		atomic.StoreInt32(&levels.current, int32(levels.configured))
+		levels.revert = nil
+		levels.revertOn = time.Time{}
+	})
+	levels.revert = revert
+	levels.revertOn = time.Now().Add(ttl)
+	return levels.revertOn
+}
+
+// setConfiguredLevel sets the level the loggers start with and revert to
+func setConfiguredLevel(level zerolog.Level) {
+	levels.Lock()
+	defer levels.Unlock()
+
+	if levels.revert != nil {
+		levels.revert.Stop()
+	}
+	levels.configured = level
+	levels.revert = nil
+	levels.revertOn = time.Time{}
+	atomic.StoreInt32(&levels.current, int32(level))
+}
+
+// levelSampler drops the events below the current level and samples the remaining with the next sampler, if any. It's
+// used instead of the global level of zerolog, which can't be lowered for a single logger, so debug logging can be
+// forced for a request with `Sample(nil)`.
+type levelSampler struct {
+	next zerolog.Sampler
+}
+
+func (s levelSampler) Sample(lvl zerolog.Level) bool {
+	if lvl < zerolog.Level(atomic.LoadInt32(&levels.current)) {
+		return false
+	}
+	return s.next == nil || s.next.Sample(lvl)
+}
+
+// SetConfiguredLevel replaces the level the loggers revert to, e.g. when the config is reloaded. A level set with
+// SetLevel is kept until it's reverted.
+func SetConfiguredLevel(level zerolog.Level) {
+	levels.Lock()
+	defer levels.Unlock()
+
+	levels.configured = level
+	if levels.revert == nil {
+		atomic.StoreInt32(&levels.current, int32(level))
+	}
+}
@@ -0,0 +1,45 @@
+package logger
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/rs/zerolog"
+)
+
+func TestSetLevel(t *testing.T) {
+	setConfiguredLevel(zerolog.InfoLevel)
+
+	var buf bytes.Buffer
+	logger := zerolog.New(&buf).Sample(levelSampler{})
+
+	t.Run("revertAfterTTL", func(t *testing.T) {
+		SetLevel(zerolog.DebugLevel, 50*time.Millisecond)
+		logger.Debug().Msg("debug")
+		if buf.Len() == 0 {
+			t.Errorf("debug event dropped after lowering the level")
+		}
+
+		time.Sleep(100 * time.Millisecond)
+		buf.Reset()
+		logger.Debug().Msg("debug")
+		if buf.Len() != 0 {
+			t.Errorf("debug event written after the level was reverted: %s", buf.String())
+		}
+		if level, revertOn := Level(); level != zerolog.InfoLevel || !revertOn.IsZero() {
+			t.Errorf("wrong level: got %v until %v want %v", level, revertOn, zerolog.InfoLevel)
+		}
+	})
+
+	t.Run("newLevelReplacesRevert", func(t *testing.T) {
+		SetLevel(zerolog.DebugLevel, 50*time.Millisecond)
+		SetLevel(zerolog.WarnLevel, time.Minute)
+
+		time.Sleep(100 * time.Millisecond)
+		if level, _ := Level(); level != zerolog.WarnLevel {
+			t.Errorf("wrong level: got %v want %v", level, zerolog.WarnLevel)
+		}
+		setConfiguredLevel(zerolog.InfoLevel)
+	})
+}
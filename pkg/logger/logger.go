@@ -1,26 +1,73 @@
 package logger
 
 import (
+	"io"
 	"os"
+	"time"
 
 	"github.com/rs/zerolog"
+	"gopkg.in/natefinch/lumberjack.v2"
 
 	"github.com/alexsniffin/go-api-starter/internal/todo-api/models"
+	pkgModels "github.com/alexsniffin/go-api-starter/pkg/models"
 )
 
-// Creates a zerolog logger
-func NewLogger(cfg models.Config) (zerolog.Logger, error) {
+// Creates a zerolog logger, the level is shared by all loggers so it can be changed at runtime with SetLevel. Events
+// are redacted and sampled as configured and written to out, or to stderr for the console on localhost, and to a
+// rotating file if a path is set.
+func NewLogger(cfg models.Config, out io.Writer) (zerolog.Logger, error) {
 	level, err := zerolog.ParseLevel(cfg.Logger.Level)
 	if err != nil {
 		return zerolog.Logger{}, err
 	}
+	zerolog.SetGlobalLevel(zerolog.TraceLevel)
+	setConfiguredLevel(level)
 
-	logger := zerolog.New(os.Stdout).Level(level).With().Timestamp().Logger()
 	if cfg.Environment == "localhost" {
-		logger = logger.Output(zerolog.ConsoleWriter{
+		out = zerolog.ConsoleWriter{
 			Out: os.Stderr,
+		}
+	}
+	if cfg.Logger.File.Path != "" {
+		out = zerolog.MultiLevelWriter(out, &lumberjack.Logger{
+			Filename:   cfg.Logger.File.Path,
+			MaxSize:    cfg.Logger.File.MaxSizeMB,
+			MaxBackups: cfg.Logger.File.MaxBackups,
+			MaxAge:     cfg.Logger.File.MaxAgeDays,
+			Compress:   cfg.Logger.File.Compress,
 		})
 	}
+	out, err = newRedactWriter(out, cfg.Logger.Redact)
+	if err != nil {
+		return zerolog.Logger{}, err
+	}
+
+	logger := zerolog.New(out).
+		Level(zerolog.TraceLevel).
+		Sample(levelSampler{next: newSampler(cfg.Logger.Sampling)}).
+		With().Timestamp().Logger()
 
 	return logger, nil
 }
+
+// newSampler samples info and lower events in bursts, warnings and errors are never sampled
+func newSampler(cfg pkgModels.LogSampling) zerolog.Sampler {
+	if cfg.Burst == 0 {
+		return nil
+	}
+
+	var next zerolog.Sampler
+	if cfg.Thereafter > 0 {
+		next = &zerolog.BasicSampler{N: cfg.Thereafter}
+	}
+	burst := &zerolog.BurstSampler{
+		Burst:       cfg.Burst,
+		Period:      time.Duration(cfg.PeriodMs) * time.Millisecond,
+		NextSampler: next,
+	}
+	return zerolog.LevelSampler{
+		TraceSampler: burst,
+		DebugSampler: burst,
+		InfoSampler:  burst,
+	}
+}
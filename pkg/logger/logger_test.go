@@ -0,0 +1,32 @@
+package logger
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/rs/zerolog"
+
+	"github.com/alexsniffin/go-api-starter/pkg/models"
+)
+
+func TestNewSampler(t *testing.T) {
+	setConfiguredLevel(zerolog.DebugLevel)
+
+	var buf bytes.Buffer
+	logger := zerolog.New(&buf).Sample(levelSampler{
+		next: newSampler(models.LogSampling{Burst: 2, PeriodMs: 60000, Thereafter: 0}),
+	})
+
+	for i := 0; i < 5; i++ {
+		logger.Info().Msg("sampled")
+		logger.Error().Msg("kept")
+	}
+
+	if count := strings.Count(buf.String(), "sampled"); count != 2 {
+		t.Errorf("wrong number of info events: got %v want %v", count, 2)
+	}
+	if count := strings.Count(buf.String(), "kept"); count != 5 {
+		t.Errorf("wrong number of error events: got %v want %v", count, 5)
+	}
+}
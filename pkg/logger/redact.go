@@ -0,0 +1,166 @@
+package logger
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"regexp"
+	"strconv"
+
+	"github.com/alexsniffin/go-api-starter/pkg/models"
+)
+
+const redacted = "[REDACTED]"
+
+// redactionFailed replaces an event which has to be redacted but can't be decoded, so it's never written as is
+var redactionFailed = []byte(`{"level":"error","message":"log event dropped: redaction failed"}` + "\n")
+
+// redactWriter redacts the log events before writing them, the events must be JSON. Events without any of the fields
+// or a match of the patterns in their raw bytes are written as is, the others are rewritten token by token keeping the
+// order of their fields. Events which can't be rewritten are dropped for an error event.
+type redactWriter struct {
+	next io.Writer
+
+	fields   map[string]struct{}
+	keys     [][]byte
+	patterns []*regexp.Regexp
+}
+
+func newRedactWriter(next io.Writer, cfg models.LogRedact) (io.Writer, error) {
+	if len(cfg.Fields) == 0 && len(cfg.Patterns) == 0 {
+		return next, nil
+	}
+
+	w := &redactWriter{
+		next:   next,
+		fields: make(map[string]struct{}, len(cfg.Fields)),
+	}
+	for i := 0; i < len(cfg.Fields); i++ {
+		w.fields[cfg.Fields[i]] = struct{}{}
+		w.keys = append(w.keys, []byte(`"`+cfg.Fields[i]+`":`))
+	}
+	for i := 0; i < len(cfg.Patterns); i++ {
+		pattern, err := regexp.Compile(cfg.Patterns[i])
+		if err != nil {
+			return nil, err
+		}
+		w.patterns = append(w.patterns, pattern)
+	}
+
+	return w, nil
+}
+
+func (w *redactWriter) Write(p []byte) (int, error) {
+	if !w.hasField(p) && !w.hasMatch(p) {
+		return w.next.Write(p)
+	}
+
+	var buf bytes.Buffer
+	decoder := json.NewDecoder(bytes.NewReader(p))
+	decoder.UseNumber()
+	if err := w.redact(decoder, &buf); err != nil {
+		if _, err = w.next.Write(redactionFailed); err != nil {
+			return 0, err
+		}
+		return len(p), nil
+	}
+	buf.WriteByte('\n')
+	if _, err := w.next.Write(buf.Bytes()); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
+
+func (w *redactWriter) hasField(p []byte) bool {
+	for i := 0; i < len(w.keys); i++ {
+		if bytes.Contains(p, w.keys[i]) {
+			return true
+		}
+	}
+	return false
+}
+
+// hasMatch reports if any pattern matches the raw event, a match may be outside of a string but the event is only
+// rewritten needlessly then
+func (w *redactWriter) hasMatch(p []byte) bool {
+	for i := 0; i < len(w.patterns); i++ {
+		if w.patterns[i].Match(p) {
+			return true
+		}
+	}
+	return false
+}
+
+// redact copies the next value of the decoder to buf, replacing the values of the fields and the matches of the
+// patterns in strings, nested values included
+func (w *redactWriter) redact(decoder *json.Decoder, buf *bytes.Buffer) error {
+	token, err := decoder.Token()
+	if err != nil {
+		return err
+	}
+
+	switch v := token.(type) {
+	case json.Delim:
+		if v == '[' {
+			buf.WriteByte('[')
+			for i := 0; decoder.More(); i++ {
+				if i > 0 {
+					buf.WriteByte(',')
+				}
+				if err = w.redact(decoder, buf); err != nil {
+					return err
+				}
+			}
+			buf.WriteByte(']')
+		} else {
+			buf.WriteByte('{')
+			for i := 0; decoder.More(); i++ {
+				if i > 0 {
+					buf.WriteByte(',')
+				}
+				if token, err = decoder.Token(); err != nil {
+					return err
+				}
+				key, _ := token.(string)
+				writeString(buf, key)
+				buf.WriteByte(':')
+
+				if _, ok := w.fields[key]; ok {
+					var skipped json.RawMessage
+					if err = decoder.Decode(&skipped); err != nil {
+						return err
+					}
+					writeString(buf, redacted)
+					continue
+				}
+				if err = w.redact(decoder, buf); err != nil {
+					return err
+				}
+			}
+			buf.WriteByte('}')
+		}
+		// closing delimiter
+		_, err = decoder.Token()
+		return err
+	case string:
+		for i := 0; i < len(w.patterns); i++ {
+			v = w.patterns[i].ReplaceAllString(v, redacted)
+		}
+		writeString(buf, v)
+	case json.Number:
+		buf.WriteString(v.String())
+	case bool:
+		buf.WriteString(strconv.FormatBool(v))
+	case nil:
+		buf.WriteString("null")
+	}
+	return nil
+}
+
+// writeString writes the JSON string of s without escaping HTML characters, like zerolog
+func writeString(buf *bytes.Buffer, s string) {
+	encoder := json.NewEncoder(buf)
+	encoder.SetEscapeHTML(false)
+	_ = encoder.Encode(s)
+	buf.Truncate(buf.Len() - 1)
+}
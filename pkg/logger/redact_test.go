@@ -0,0 +1,87 @@
+package logger
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/rs/zerolog"
+
+	"github.com/alexsniffin/go-api-starter/pkg/models"
+)
+
+func TestRedactWriter(t *testing.T) {
+	var buf bytes.Buffer
+	w, err := newRedactWriter(&buf, models.LogRedact{
+		Fields:   []string{"password"},
+		Patterns: []string{`[a-z]+@example\.com`},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	logger := zerolog.New(w)
+
+	tests := []struct {
+		name string
+		log  func()
+		want string
+	}{
+		{
+			name: "field",
+			log:  func() { logger.Info().Str("password", "hunter2").Msg("login") },
+			want: `{"level":"info","password":"[REDACTED]","message":"login"}` + "\n",
+		},
+		{
+			name: "pattern",
+			log:  func() { logger.Info().Int("id", 1).Msg("sent to alice@example.com") },
+			want: `{"level":"info","id":1,"message":"sent to [REDACTED]"}` + "\n",
+		},
+		{
+			name: "nestedField",
+			log: func() {
+				logger.Info().Interface("user", map[string]string{"password": "hunter2", "name": "alice"}).Msg("")
+			},
+			want: `{"level":"info","user":{"name":"alice","password":"[REDACTED]"}}` + "\n",
+		},
+		{
+			name: "orderKept",
+			log: func() {
+				logger.Info().Str("z", "<a&b>").Floats64("values", []float64{1.5, 2}).Bool("ok", true).
+					Interface("none", nil).Msg("to bob@example.com")
+			},
+			want: `{"level":"info","z":"<a&b>","values":[1.5,2],"ok":true,"none":null,"message":"to [REDACTED]"}` + "\n",
+		},
+		{
+			name: "noMatch",
+			log:  func() { logger.Info().Str("z", "a").RawJSON("raw", []byte(`{ "b": 1 }`)).Msg("") },
+			want: `{"level":"info","z":"a","raw":{ "b": 1 }}` + "\n",
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			buf.Reset()
+			test.log()
+			if got := buf.String(); got != test.want {
+				t.Errorf("wrong event: got %v want %v", got, test.want)
+			}
+		})
+	}
+}
+
+func TestRedactWriter_Malformed(t *testing.T) {
+	var buf bytes.Buffer
+	w, err := newRedactWriter(&buf, models.LogRedact{Fields: []string{"password"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, event := range []string{`{"level":"info","password":"hunter2"`, `{"password":"hunter2",}`} {
+		buf.Reset()
+		n, err := w.Write([]byte(event))
+		if err != nil || n != len(event) {
+			t.Errorf("unexpected write: got %v, %v want %v, nil", n, err, len(event))
+		}
+		if got := buf.String(); got != string(redactionFailed) {
+			t.Errorf("wrong event: got %v want %s", got, redactionFailed)
+		}
+	}
+}
@@ -1,5 +1,67 @@
 package models
 
+import (
+	validation "github.com/go-ozzo/ozzo-validation/v4"
+	"github.com/rs/zerolog"
+)
+
 type Logger struct {
-	Level string
+	Level       string
+	LevelTTLSec int
+	DebugHeader string
+	DebugKey    string `secret:"true"`
+	Redact      LogRedact
+	Sampling    LogSampling
+	File        LogFile
+}
+
+func (l Logger) Validate() error {
+	return validation.ValidateStruct(&l,
+		validation.Field(&l.Level, validation.Required, validation.By(func(value interface{}) error {
+			_, err := zerolog.ParseLevel(value.(string))
+			return err
+		})),
+		validation.Field(&l.LevelTTLSec, validation.Min(0)),
+		validation.Field(&l.DebugKey, validation.When(l.DebugKey != "", validation.Length(16, 0).
+			Error("must be at least 16 characters"))),
+		validation.Field(&l.Sampling),
+		validation.Field(&l.File),
+	)
+}
+
+// LogRedact replaces the values of the fields and the matches of the patterns in string values of the log events
+type LogRedact struct {
+	Fields   []string
+	Patterns []string
+}
+
+// LogSampling of info and lower events, the first `Burst` events of every period are logged, then every
+// `Thereafter`th event. Sampling is disabled if `Burst` is 0.
+type LogSampling struct {
+	Burst      uint32
+	PeriodMs   int
+	Thereafter uint32
+}
+
+func (s LogSampling) Validate() error {
+	return validation.ValidateStruct(&s,
+		validation.Field(&s.PeriodMs, validation.When(s.Burst > 0, validation.Required, validation.Min(1))),
+	)
+}
+
+// LogFile rotates the log file once it reaches `MaxSizeMB`, logs are only written to stdout if `Path` is empty
+type LogFile struct {
+	Path       string
+	MaxSizeMB  int
+	MaxBackups int
+	MaxAgeDays int
+	Compress   bool
+}
+
+func (f LogFile) Validate() error {
+	return validation.ValidateStruct(&f,
+		validation.Field(&f.MaxSizeMB, validation.Min(0)),
+		validation.Field(&f.MaxBackups, validation.Min(0)),
+		validation.Field(&f.MaxAgeDays, validation.Min(0)),
+	)
 }
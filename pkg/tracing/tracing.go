@@ -0,0 +1,77 @@
+package tracing
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracegrpc"
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.4.0"
+
+	"github.com/alexsniffin/go-api-starter/internal/todo-api/models"
+)
+
+// Creates an OpenTelemetry tracer provider and installs it globally with the W3C trace context propagator. Spans are
+// exported with OTLP over gRPC, to stdout or not at all.
+func NewTracerProvider(cfg models.Config) (*sdktrace.TracerProvider, error) {
+	sampler, err := newSampler(cfg.Tracing)
+	if err != nil {
+		return nil, err
+	}
+
+	opts := []sdktrace.TracerProviderOption{
+		sdktrace.WithSampler(sampler),
+		sdktrace.WithResource(resource.NewWithAttributes(semconv.SchemaURL,
+			semconv.ServiceNameKey.String(cfg.Tracing.ServiceName),
+			semconv.DeploymentEnvironmentKey.String(cfg.Environment),
+		)),
+	}
+
+	switch cfg.Tracing.Exporter {
+	case "otlp":
+		clientOpts := []otlptracegrpc.Option{otlptracegrpc.WithEndpoint(cfg.Tracing.Endpoint)}
+		if cfg.Tracing.Insecure {
+			clientOpts = append(clientOpts, otlptracegrpc.WithInsecure())
+		}
+		exporter, err := otlptracegrpc.New(context.Background(), clientOpts...)
+		if err != nil {
+			return nil, err
+		}
+		opts = append(opts, sdktrace.WithBatcher(exporter))
+	case "stdout":
+		exporter, err := stdouttrace.New(stdouttrace.WithWriter(os.Stdout))
+		if err != nil {
+			return nil, err
+		}
+		opts = append(opts, sdktrace.WithSyncer(exporter))
+	case "none", "":
+	default:
+		return nil, fmt.Errorf("unknown tracing exporter %q", cfg.Tracing.Exporter)
+	}
+
+	provider := sdktrace.NewTracerProvider(opts...)
+	otel.SetTracerProvider(provider)
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(propagation.TraceContext{},
+		propagation.Baggage{}))
+
+	return provider, nil
+}
+
+// newSampler samples the root spans according to the config, child spans follow the decision of their parent
+func newSampler(cfg models.TracingConfig) (sdktrace.Sampler, error) {
+	switch cfg.Sampler {
+	case "always":
+		return sdktrace.ParentBased(sdktrace.AlwaysSample()), nil
+	case "never":
+		return sdktrace.ParentBased(sdktrace.NeverSample()), nil
+	case "ratio", "":
+		return sdktrace.ParentBased(sdktrace.TraceIDRatioBased(cfg.SampleRatio)), nil
+	default:
+		return nil, fmt.Errorf("unknown tracing sampler %q", cfg.Sampler)
+	}
+}
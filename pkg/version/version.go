@@ -0,0 +1,8 @@
+package version
+
+// Build details of the binary, set with `-ldflags "-X github.com/alexsniffin/go-api-starter/pkg/version.Version=..."`
+var (
+	Version   = "dev"
+	Commit    = "unknown"
+	BuildDate = "unknown"
+)